@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/chrismalek/oktasdk-go/okta/apply"
+)
+
+func applyCommand() *command {
+	return &command{
+		name:    "apply",
+		args:    "<file.yaml>",
+		summary: "Make groups, memberships, group rules and app group assignments match a YAML file",
+		run:     applyRun,
+	}
+}
+
+func applyRun(fs *flag.FlagSet) func(a *app, args []string) error {
+	prune := fs.Bool("prune", false, "delete members, assignments, groups and rules the file doesn't declare")
+	planOnly := fs.Bool("plan", false, "print the changes without making them")
+	return func(a *app, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+		cfg, err := apply.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		client, err := a.oktaClient()
+		if err != nil {
+			return err
+		}
+		opt := &apply.Options{Prune: *prune, DryRun: *planOnly}
+		plan, applyErr := apply.Apply(a.ctx, client, cfg, opt)
+		if plan == nil {
+			return applyErr
+		}
+		if a.output == "json" {
+			err = plan.WriteJSON(a.stdout)
+		} else {
+			err = plan.WriteText(a.stdout)
+		}
+		if applyErr != nil {
+			return applyErr
+		}
+		return err
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+func appsCommand() *command {
+	return &command{
+		name:    "apps",
+		summary: "List apps and their assigned users and groups",
+		subcommands: []*command{
+			{name: "list", summary: "List apps", run: appsList},
+			{name: "users", args: "<app id>", summary: "List the users assigned to an app", run: appsUsers},
+			{name: "groups", args: "<app id>", summary: "List the groups assigned to an app", run: appsGroups},
+		},
+	}
+}
+
+func appsList(fs *flag.FlagSet) func(a *app, args []string) error {
+	filter := fs.String("filter", "", "OKTA filter `expression`, e.g. 'status eq \"ACTIVE\"'")
+	limit := fs.Int("limit", 0, "page size")
+	all := fs.Bool("all", false, "get every page, not just the first")
+	return func(a *app, args []string) error {
+		if len(args) != 0 {
+			return errUsage
+		}
+		client, err := a.oktaClient()
+		if err != nil {
+			return err
+		}
+		apps, _, err := client.Apps.ListWithFilter(&okta.AppFilterOptions{FilterString: *filter, Limit: *limit, GetAllPages: *all})
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, len(apps))
+		for i, app := range apps {
+			rows[i] = []string{app.ID, app.Name, app.Label, app.Status, app.SignOnMode}
+		}
+		if apps == nil {
+			apps = []okta.App{}
+		}
+		return a.print(apps, []string{"id", "name", "label", "status", "signOnMode"}, rows)
+	}
+}
+
+func appsUsers(fs *flag.FlagSet) func(a *app, args []string) error {
+	return func(a *app, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+		client, err := a.oktaClient()
+		if err != nil {
+			return err
+		}
+		users, _, err := client.Apps.GetUsers(args[0], &okta.AppFilterOptions{GetAllPages: true})
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, len(users))
+		for i, u := range users {
+			rows[i] = []string{u.ID, u.Scope, u.Status, u.Credentials.UserName}
+		}
+		if users == nil {
+			users = []okta.AppUser{}
+		}
+		return a.print(users, []string{"id", "scope", "status", "userName"}, rows)
+	}
+}
+
+func appsGroups(fs *flag.FlagSet) func(a *app, args []string) error {
+	return func(a *app, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+		client, err := a.oktaClient()
+		if err != nil {
+			return err
+		}
+		groups, _, err := client.Apps.GetGroups(args[0])
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, len(groups))
+		for i, g := range groups {
+			rows[i] = []string{g.ID, strconv.Itoa(g.Priority), formatTime(g.LastUpdated)}
+		}
+		if groups == nil {
+			groups = []okta.AppGroups{}
+		}
+		return a.print(groups, []string{"id", "priority", "lastUpdated"}, rows)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+func completionCommand() *command {
+	return &command{
+		name:    "completion",
+		args:    "bash|zsh",
+		summary: "Print a shell completion script, e.g. source <(oktactl completion bash)",
+		run: func(fs *flag.FlagSet) func(a *app, args []string) error {
+			return func(a *app, args []string) error {
+				if len(args) != 1 {
+					return errUsage
+				}
+				switch args[0] {
+				case "bash":
+					_, err := fmt.Fprint(a.stdout, bashCompletion(rootCommand()))
+					return err
+				case "zsh":
+					_, err := fmt.Fprint(a.stdout, "autoload -U +X bashcompinit && bashcompinit\n"+bashCompletion(rootCommand()))
+					return err
+				}
+				return fmt.Errorf("no completion for shell %q, use bash or zsh", args[0])
+			}
+		},
+	}
+}
+
+// bashCompletion completes command names from the tree and the flags of each leaf
+func bashCompletion(root *command) string {
+	var cases strings.Builder
+	var walk func(c *command, path string)
+	walk = func(c *command, path string) {
+		var words []string
+		if c.run != nil {
+			fs := flag.NewFlagSet(path, flag.ContinueOnError)
+			(&app{}).globalFlags(fs)
+			c.run(fs)
+			fs.VisitAll(func(f *flag.Flag) { words = append(words, "-"+f.Name) })
+		}
+		for _, sub := range c.subcommands {
+			words = append(words, sub.name)
+			walk(sub, path+" "+sub.name)
+		}
+		if c == root {
+			words = append(words, "help")
+		}
+		// arguments with a fixed set of values, like bash|zsh
+		if c.args != "" && !strings.ContainsAny(c.args, " <") {
+			words = append(words, strings.Split(c.args, "|")...)
+		}
+		sort.Strings(words)
+		fmt.Fprintf(&cases, "    %q) words=%q ;;\n", path, strings.Join(words, " "))
+	}
+	walk(root, root.name)
+
+	return `# bash completion for oktactl
+_oktactl() {
+  local cur path words i
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  path="oktactl"
+  for ((i = 1; i < COMP_CWORD; i++)); do
+    case "${COMP_WORDS[i]}" in
+      -*) ;;
+      *) [[ " $(_oktactl_words "$path") " == *" ${COMP_WORDS[i]} "* ]] && path="$path ${COMP_WORDS[i]}" ;;
+    esac
+  done
+  COMPREPLY=($(compgen -W "$(_oktactl_words "$path")" -- "$cur"))
+}
+_oktactl_words() {
+  local words=""
+  case "$1" in
+` + cases.String() + `  esac
+  echo "$words"
+}
+complete -F _oktactl oktactl
+`
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+var groupColumns = []string{"id", "type", "name", "description"}
+
+func groupsCommand() *command {
+	return &command{
+		name:    "groups",
+		summary: "List groups and change their members",
+		subcommands: []*command{
+			{name: "list", summary: "List groups", run: groupsList},
+			{name: "members", args: "<group id>", summary: "List the members of a group", run: groupsMembers},
+			{name: "add", args: "<group id> <user id>...", summary: "Add users to a group", run: groupsChange("add")},
+			{name: "remove", args: "<group id> <user id>...", summary: "Remove users from a group", run: groupsChange("remove")},
+		},
+	}
+}
+
+func groupsList(fs *flag.FlagSet) func(a *app, args []string) error {
+	q := fs.String("q", "", "groups whose name starts with this")
+	groupType := fs.String("type", "", "only groups of this `type`: OKTA_GROUP, APP_GROUP or BUILT_IN")
+	limit := fs.Int("limit", 0, "page size")
+	all := fs.Bool("all", false, "get every page, not just the first")
+	return func(a *app, args []string) error {
+		if len(args) != 0 {
+			return errUsage
+		}
+		client, err := a.oktaClient()
+		if err != nil {
+			return err
+		}
+		groups, _, err := client.Groups.ListWithFilter(&okta.GroupFilterOptions{NameStartsWith: *q, GroupTypeEqual: *groupType, Limit: *limit, GetAllPages: *all})
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, len(groups))
+		for i, g := range groups {
+			rows[i] = []string{g.ID, g.Type, g.Profile.Name, g.Profile.Description}
+		}
+		if groups == nil {
+			groups = []okta.Group{}
+		}
+		return a.print(groups, groupColumns, rows)
+	}
+}
+
+func groupsMembers(fs *flag.FlagSet) func(a *app, args []string) error {
+	return func(a *app, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+		client, err := a.oktaClient()
+		if err != nil {
+			return err
+		}
+		users, _, err := client.Groups.GetUsers(args[0], &okta.GroupUserFilterOptions{GetAllPages: true})
+		if err != nil {
+			return err
+		}
+		if users == nil {
+			users = []okta.User{}
+		}
+		return a.print(users, userColumns, userRows(users))
+	}
+}
+
+func groupsChange(action string) func(fs *flag.FlagSet) func(a *app, args []string) error {
+	return func(fs *flag.FlagSet) func(a *app, args []string) error {
+		workers := fs.Int("workers", 4, "how many calls run at the same time")
+		return func(a *app, args []string) error {
+			if len(args) < 2 {
+				return errUsage
+			}
+			client, err := a.oktaClient()
+			if err != nil {
+				return err
+			}
+			opt := &okta.BulkOptions{Workers: *workers, RateLimitRetries: okta.DefaultBulkRateLimitRetries}
+			var report *okta.BulkReport
+			if action == "add" {
+				report = client.Bulk.AddUsersToGroup(a.ctx, args[0], args[1:], opt)
+			} else {
+				report = client.Bulk.RemoveUsersFromGroup(a.ctx, args[0], args[1:], opt)
+			}
+			if err := a.printBulkReport(report); err != nil {
+				return err
+			}
+			if report.Failed > 0 {
+				return fmt.Errorf("%d of %d users failed", report.Failed, len(report.Results))
+			}
+			return nil
+		}
+	}
+}
+
+func (a *app) printBulkReport(report *okta.BulkReport) error {
+	rows := make([][]string, len(report.Results))
+	for i, r := range report.Results {
+		status := ""
+		if r.StatusCode != 0 {
+			status = strconv.Itoa(r.StatusCode)
+		}
+		rows[i] = []string{r.Key, r.Status, status, r.Error}
+	}
+	return a.print(report, []string{"id", "result", "status", "error"}, rows)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+var logColumns = []string{"published", "eventType", "outcome", "actor", "target", "message"}
+
+func logRow(e okta.LogEvent) []string {
+	target := ""
+	if len(e.Target) > 0 {
+		target = e.Target[0].AlternateID
+	}
+	return []string{formatTime(e.Published), e.EventType, e.Outcome.Result, e.Actor.AlternateID, target, e.DisplayMessage}
+}
+
+func logsCommand() *command {
+	return &command{
+		name:    "logs",
+		summary: "Query and tail the System Log",
+		subcommands: []*command{
+			{name: "query", summary: "Query the System Log", run: logsQuery},
+			{name: "tail", summary: "Print System Log events as they happen, until interrupted", run: logsTail},
+		},
+	}
+}
+
+func logsQuery(fs *flag.FlagSet) func(a *app, args []string) error {
+	since := fs.String("since", "24h", "oldest event, RFC 3339 or a duration before now")
+	until := fs.String("until", "", "newest event, RFC 3339 or a duration before now")
+	filter := fs.String("filter", "", "filter `expression`, e.g. 'eventType eq \"user.session.start\"'")
+	q := fs.String("q", "", "keyword search")
+	limit := fs.Int("limit", 0, "page size")
+	all := fs.Bool("all", false, "get every page, not just the first")
+	return func(a *app, args []string) error {
+		if len(args) != 0 {
+			return errUsage
+		}
+		opt := &okta.LogFilterOptions{Filter: *filter, Q: *q, Limit: *limit, GetAllPages: *all}
+		var err error
+		if opt.Since, err = parseTime(*since); err != nil {
+			return err
+		}
+		if opt.Until, err = parseTime(*until); err != nil {
+			return err
+		}
+		// without until OKTA treats the query as polling and always has a next page
+		if opt.Until.IsZero() && opt.GetAllPages {
+			opt.Until = time.Now()
+		}
+		client, err := a.oktaClient()
+		if err != nil {
+			return err
+		}
+		events, _, err := client.Logs.ListWithFilter(opt)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, len(events))
+		for i, e := range events {
+			rows[i] = logRow(e)
+		}
+		if events == nil {
+			events = []okta.LogEvent{}
+		}
+		return a.print(events, logColumns, rows)
+	}
+}
+
+func logsTail(fs *flag.FlagSet) func(a *app, args []string) error {
+	since := fs.String("since", "", "start this far back, RFC 3339 or a duration before now (default now)")
+	filter := fs.String("filter", "", "filter `expression`")
+	q := fs.String("q", "", "keyword search")
+	cursorFile := fs.String("cursor-file", "", "save the position here and resume from it next time")
+	return func(a *app, args []string) error {
+		if len(args) != 0 {
+			return errUsage
+		}
+		opt := &okta.LogTailOptions{Filter: *filter, Q: *q}
+		var err error
+		if opt.Since, err = parseTime(*since); err != nil {
+			return err
+		}
+		if *cursorFile != "" {
+			opt.Cursors = &okta.FileLogCursorStore{Path: *cursorFile}
+		}
+		client, err := a.oktaClient()
+		if err != nil {
+			return err
+		}
+		out := a.stream(logColumns)
+		err = client.Logs.TailFunc(a.ctx, opt, func(e okta.LogEvent) error {
+			return out.write(e, logRow(e))
+		})
+		if err == context.Canceled {
+			return nil
+		}
+		return err
+	}
+}
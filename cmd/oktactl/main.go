@@ -0,0 +1,246 @@
+// Command oktactl manages an OKTA org from the command line: users, groups, apps and the System
+// Log, with table, JSON or CSV output.
+//
+// The org and credentials come from okta.yaml and OKTA_CLIENT_* environment variables, read by the
+// okta/config package. --profile selects a named profile and --config a file.
+//
+//	oktactl users list --status ACTIVE -o csv
+//	oktactl users create --login anna@example.com --first Anna --last Smith --attr costCenter=10
+//	oktactl groups add 00g1 00u1 00u2
+//	oktactl logs tail --filter 'eventType eq "user.session.start"'
+//	oktactl apply --plan --prune groups.yaml
+//	source <(oktactl completion bash)
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+	"github.com/chrismalek/oktasdk-go/okta/config"
+)
+
+// command is a node of the command tree. Leaves have run.
+type command struct {
+	name        string
+	args        string
+	summary     string
+	subcommands []*command
+	// flags registers the command's flags and returns the function that runs it
+	run func(fs *flag.FlagSet) func(a *app, args []string) error
+}
+
+// app is the state shared by every command
+type app struct {
+	ctx    context.Context
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	output     string
+	configFile string
+	profile    string
+	readOnly   bool
+	dryRun     bool
+
+	// newClient builds the client once flags are parsed, tests replace it
+	newClient func(a *app) (*okta.Client, error)
+	client    *okta.Client
+}
+
+var errUsage = errors.New("usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	a := &app{ctx: ctx, stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr, newClient: configClient}
+	os.Exit(a.run(os.Args[1:]))
+}
+
+func configClient(a *app) (*okta.Client, error) {
+	return config.NewClient(config.Options{File: a.configFile, Profile: a.profile})
+}
+
+func rootCommand() *command {
+	return &command{
+		name:    "oktactl",
+		summary: "Manage an OKTA org",
+		subcommands: []*command{
+			usersCommand(),
+			groupsCommand(),
+			appsCommand(),
+			logsCommand(),
+			applyCommand(),
+			reportCommand(),
+			completionCommand(),
+		},
+	}
+}
+
+// run runs the command line and returns the exit code
+func (a *app) run(args []string) int {
+	root := rootCommand()
+	cmd, path, args := root.find(args)
+	if cmd.run == nil {
+		if len(args) > 0 && args[0] != "-h" && args[0] != "-help" && args[0] != "--help" && args[0] != "help" {
+			fmt.Fprintf(a.stderr, "oktactl: unknown command %q\n\n", strings.Join(append(path, args[0]), " "))
+			cmd.usage(a.stderr, path)
+			return 2
+		}
+		cmd.usage(a.stdout, path)
+		return 0
+	}
+
+	fs := flag.NewFlagSet(strings.Join(path, " "), flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	a.globalFlags(fs)
+	run := cmd.run(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: %v %v\n\n%v\n\nFlags:\n", strings.Join(path, " "), cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		return 2
+	}
+	switch a.output {
+	case "table", "json", "csv":
+	default:
+		fmt.Fprintf(a.stderr, "oktactl: unknown output %q, use table, json or csv\n", a.output)
+		return 2
+	}
+	if a.readOnly && a.dryRun {
+		fmt.Fprintln(a.stderr, "oktactl: --read-only and --dry-run can't be used together")
+		return 2
+	}
+
+	if err := run(a, positional); err != nil {
+		if err == errUsage {
+			fs.Usage()
+			return 2
+		}
+		fmt.Fprintf(a.stderr, "oktactl: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func (a *app) globalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&a.output, "o", "table", "output `format`: table, json or csv")
+	fs.StringVar(&a.output, "output", "table", "output `format`: table, json or csv")
+	fs.StringVar(&a.configFile, "config", "", "read this okta.yaml instead of ~/.okta/okta.yaml and ./okta.yaml")
+	fs.StringVar(&a.profile, "profile", "", "named profile from the config file (default $OKTA_PROFILE)")
+	fs.BoolVar(&a.readOnly, "read-only", false, "refuse any change to the org")
+	fs.BoolVar(&a.dryRun, "dry-run", false, "log changes instead of sending them")
+}
+
+// oktaClient builds the client on first use
+func (a *app) oktaClient() (*okta.Client, error) {
+	if a.client != nil {
+		return a.client, nil
+	}
+	client, err := a.newClient(a)
+	if err != nil {
+		return nil, err
+	}
+	if a.readOnly {
+		client.Mode = okta.ModeReadOnly
+	}
+	if a.dryRun {
+		client.Mode = okta.ModeDryRun
+	}
+	a.client = client
+	return client, nil
+}
+
+// find walks args down the command tree. It returns the deepest command, its path and the rest
+// of the arguments. Global flags may come before the command names.
+func (c *command) find(args []string) (*command, []string, []string) {
+	path := []string{c.name}
+	var flags []string
+	for len(args) > 0 && len(c.subcommands) > 0 {
+		if strings.HasPrefix(args[0], "-") && len(args[0]) > 1 {
+			flags = append(flags, args[0])
+			args = args[1:]
+			if takesValue(flags[len(flags)-1]) && len(args) > 0 {
+				flags, args = append(flags, args[0]), args[1:]
+			}
+			continue
+		}
+		next := c.sub(args[0])
+		if next == nil {
+			break
+		}
+		c, path, args = next, append(path, next.name), args[1:]
+	}
+	return c, path, append(flags, args...)
+}
+
+// takesValue reports whether a global flag without "=" is followed by its value
+func takesValue(flag string) bool {
+	switch strings.TrimLeft(flag, "-") {
+	case "o", "output", "config", "profile":
+		return true
+	}
+	return false
+}
+
+func (c *command) sub(name string) *command {
+	for _, sub := range c.subcommands {
+		if sub.name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+func (c *command) usage(w io.Writer, path []string) {
+	fmt.Fprintf(w, "%v\n\nUsage: %v <command>\n\nCommands:\n", c.summary, strings.Join(path, " "))
+	names := make([]string, 0, len(c.subcommands))
+	width := 0
+	for _, sub := range c.subcommands {
+		names = append(names, sub.name)
+		if len(sub.name) > width {
+			width = len(sub.name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-*v  %v\n", width, name, c.sub(name).summary)
+	}
+}
+
+// parseInterspersed parses flags that come before, between or after positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// multiFlag collects a flag given several times
+type multiFlag []string
+
+func (m *multiFlag) String() string     { return strings.Join(*m, ",") }
+func (m *multiFlag) Set(v string) error { *m = append(*m, v); return nil }
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+	"github.com/chrismalek/oktasdk-go/okta/oktatest"
+)
+
+func newTestApp(server *oktatest.Server) (*app, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	return &app{
+		ctx:       context.Background(),
+		stdout:    &stdout,
+		stderr:    &stderr,
+		newClient: func(*app) (*okta.Client, error) { return server.Client(), nil },
+	}, &stdout, &stderr
+}
+
+func TestUsersCommands(t *testing.T) {
+	server := oktatest.NewServer()
+	defer server.Close()
+	anna := server.AddUser(oktatest.User{ID: "00uanna", Profile: map[string]interface{}{
+		"login": "anna@example.com", "email": "anna@example.com", "firstName": "Anna", "lastName": "Smith", "department": "Engineering",
+	}})
+	server.AddUser(oktatest.User{ID: "00ubob", Profile: map[string]interface{}{
+		"login": "bob@example.com", "email": "bob@example.com", "firstName": "Bob", "lastName": "=Jones", "department": "Sales",
+	}})
+
+	a, stdout, stderr := newTestApp(server)
+	if code := a.run([]string{"users", "list", "-o", "csv"}); code != 0 {
+		t.Fatalf("users list exited %v: %v", code, stderr)
+	}
+	want := "id,status,login,email,firstName,lastName,lastLogin\n00uanna,ACTIVE,anna@example.com,anna@example.com,Anna,Smith,\n00ubob,ACTIVE,bob@example.com,bob@example.com,Bob,'=Jones,\n"
+	if stdout.String() != want {
+		t.Errorf("users list should print\n%v\nbut printed\n%v", want, stdout)
+	}
+
+	a, stdout, _ = newTestApp(server)
+	a.run([]string{"users", "search", `profile.department eq "Sales"`, "--output=json"})
+	var users []okta.User
+	if err := json.Unmarshal(stdout.Bytes(), &users); err != nil || len(users) != 1 || users[0].ID != "00ubob" {
+		t.Errorf("users search should find bob, got %v (%v)", stdout, err)
+	}
+
+	a, stdout, stderr = newTestApp(server)
+	a.stdin = strings.NewReader("Abcd1234!\n")
+	if code := a.run([]string{"users", "create", "--login", "carol@example.com", "--first", "Carol", "--last", "White", "--attr", "department=Legal", "--password-stdin", "--activate"}); code != 0 {
+		t.Fatalf("users create exited %v: %v", code, stderr)
+	}
+	if !strings.Contains(stdout.String(), "carol@example.com") {
+		t.Errorf("users create should print the user, got %v", stdout)
+	}
+	var carol oktatest.User
+	for _, u := range server.Users() {
+		if u.Profile["login"] == "carol@example.com" {
+			carol = u
+		}
+	}
+	// with a password, activation makes the user ACTIVE rather than PROVISIONED
+	if carol.Profile["department"] != "Legal" || carol.Status != okta.UserStatusActive || carol.Password != "Abcd1234!" {
+		t.Errorf("users create should set custom attributes, the password from stdin and activate, got %+v", carol)
+	}
+
+	a, _, _ = newTestApp(server)
+	a.run([]string{"users", "update", anna, "department=Legal", "title=Lawyer"})
+	a, _, _ = newTestApp(server)
+	a.run([]string{"users", "suspend", anna})
+	updated, _ := server.GetUser(anna)
+	if updated.Profile["department"] != "Legal" || updated.Profile["title"] != "Lawyer" || updated.Status != okta.UserStatusSuspended {
+		t.Errorf("users update and suspend should change the user, got %+v", updated)
+	}
+
+	a, _, stderr = newTestApp(server)
+	if code := a.run([]string{"users", "get", "00unobody"}); code != 1 || !strings.Contains(stderr.String(), "E0000007") {
+		t.Errorf("a missing user should fail with the OKTA error, got %v %v", code, stderr)
+	}
+}
+
+func TestGroupsAndAppsCommands(t *testing.T) {
+	server := oktatest.NewServer()
+	defer server.Close()
+	anna := server.AddUser(oktatest.User{ID: "00uanna", Profile: map[string]interface{}{"login": "anna@example.com"}})
+	bob := server.AddUser(oktatest.User{ID: "00ubob", Profile: map[string]interface{}{"login": "bob@example.com"}})
+	eng := server.AddGroup(oktatest.Group{ID: "00geng", Name: "Engineering"})
+	app := server.AddApp(oktatest.App{ID: "0oaapp", Name: "salesforce", Label: "Salesforce"})
+	server.AssignGroupToApp(app, eng)
+
+	a, stdout, stderr := newTestApp(server)
+	if code := a.run([]string{"groups", "add", eng, anna, bob}); code != 0 {
+		t.Fatalf("groups add exited %v: %v", code, stderr)
+	}
+	if members := server.GroupMembers(eng); len(members) != 2 {
+		t.Errorf("groups add should add both users, got %v", members)
+	}
+
+	a, stdout, _ = newTestApp(server)
+	a.run([]string{"groups", "members", eng})
+	if lines := strings.Split(strings.TrimSpace(stdout.String()), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[0], "ID ") {
+		t.Errorf("groups members should print a table, got\n%v", stdout)
+	}
+
+	a, stdout, _ = newTestApp(server)
+	a.run([]string{"apps", "users", app, "-o", "csv"})
+	if want := "id,scope,status,userName\n00uanna,GROUP,ACTIVE,anna@example.com\n00ubob,GROUP,ACTIVE,bob@example.com\n"; stdout.String() != want {
+		t.Errorf("apps users should print\n%v\nbut printed\n%v", want, stdout)
+	}
+
+	a, _, _ = newTestApp(server)
+	if code := a.run([]string{"--read-only", "--dry-run", "groups", "remove", eng, anna}); code != 2 {
+		t.Errorf("read-only and dry-run together should be a usage error, exited %v", code)
+	}
+	if code := a.run([]string{"--read-only", "groups", "remove", eng, anna}); code != 1 {
+		t.Errorf("read-only should refuse changes, exited %v", code)
+	}
+	if members := server.GroupMembers(eng); len(members) != 2 {
+		t.Errorf("read-only should leave the members alone, got %v", members)
+	}
+}
+
+func TestUsageAndCompletion(t *testing.T) {
+	a, stdout, stderr := newTestApp(nil)
+	if code := a.run([]string{"users", "frobnicate"}); code != 2 || !strings.Contains(stderr.String(), `unknown command "oktactl users frobnicate"`) {
+		t.Errorf("unknown command should fail, got %v %v", code, stderr)
+	}
+	if code := a.run([]string{"users", "get"}); code != 2 {
+		t.Errorf("missing argument should print usage, got %v", code)
+	}
+
+	a, stdout, _ = newTestApp(nil)
+	a.run([]string{"-o", "json", "completion", "bash"})
+	for _, want := range []string{`"oktactl users get") words="-config -dry-run`, `"oktactl users") words="activate create deactivate get list`, `"oktactl completion") words="-config -dry-run -o -output -profile -read-only bash zsh"`, `"oktactl logs tail") words=`, "complete -F _oktactl oktactl"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("bash completion should contain %q:\n%v", want, stdout)
+		}
+	}
+}
+
+func TestApplyCommand(t *testing.T) {
+	server := oktatest.NewServer()
+	defer server.Close()
+	server.AddUser(oktatest.User{ID: "00uanna", Profile: map[string]interface{}{"login": "anna@example.com"}})
+	file := t.TempDir() + "/groups.yaml"
+	os.WriteFile(file, []byte("groups:\n  - name: Engineering\n    members: [anna@example.com]\n"), 0600)
+
+	a, stdout, stderr := newTestApp(server)
+	if code := a.run([]string{"apply", "--plan", file}); code != 0 {
+		t.Fatalf("apply --plan exited %v: %v", code, stderr)
+	}
+	if want := "Plan: 2 to create, 0 to update, 0 to delete\n+ group Engineering\n+ groupMember Engineering: anna@example.com\n"; stdout.String() != want {
+		t.Errorf("apply --plan should print\n%v\nbut printed\n%v", want, stdout)
+	}
+
+	// created groups get a synthetic ID in dry-run mode, so their members can be added too
+	a, _, stderr = newTestApp(server)
+	if code := a.run([]string{"--dry-run", "apply", file}); code != 0 {
+		t.Fatalf("--dry-run apply exited %v: %v", code, stderr)
+	}
+	for _, r := range server.Requests() {
+		if r.Method != "GET" {
+			t.Errorf("--dry-run apply should only read, sent %v %v", r.Method, r.Path)
+		}
+	}
+
+	a, _, stderr = newTestApp(server)
+	if code := a.run([]string{"apply", file}); code != 0 {
+		t.Fatalf("apply exited %v: %v", code, stderr)
+	}
+	a, stdout, _ = newTestApp(server)
+	a.run([]string{"apply", "--plan", file})
+	if stdout.String() != "Plan: 0 to create, 0 to update, 0 to delete\n" {
+		t.Errorf("nothing should be left to apply, got\n%v", stdout)
+	}
+}
+
+func TestReportCommands(t *testing.T) {
+	server := oktatest.NewServer()
+	defer server.Close()
+	anna := server.AddUser(oktatest.User{ID: "00uanna", Profile: map[string]interface{}{"login": "anna@example.com"}})
+	server.AddUser(oktatest.User{ID: "00ubob", Profile: map[string]interface{}{"login": "bob@example.com"}})
+	server.AddFactor(anna, oktatest.Factor{FactorType: "push", Provider: "OKTA"})
+	app := server.AddApp(oktatest.App{ID: "0oaapp", Name: "salesforce", Label: "Salesforce"})
+	server.AssignUserToApp(app, anna)
+
+	a, stdout, stderr := newTestApp(server)
+	if code := a.run([]string{"report", "access", "-o", "csv"}); code != 0 {
+		t.Fatalf("report access exited %v: %v", code, stderr)
+	}
+	if !strings.Contains(stdout.String(), "0oaapp,Salesforce,00uanna,anna@example.com,ACTIVE,anna@example.com,ACTIVE,USER,true,,direct\n") {
+		t.Errorf("report access should list anna's direct assignment, got\n%v", stdout)
+	}
+
+	a, stdout, _ = newTestApp(server)
+	a.run([]string{"report", "mfa", "--summary", "-o", "csv"})
+	if !strings.Contains(stdout.String(), "coverage:none,1\n") || !strings.Contains(stdout.String(), "factorType:push,1\n") {
+		t.Errorf("report mfa --summary should count bob without factors and anna's push, got\n%v", stdout)
+	}
+	server.AddUser(oktatest.User{ID: "00ueve", Profile: map[string]interface{}{"login": "=HYPERLINK(\"x\")"}})
+	a, stdout, _ = newTestApp(server)
+	a.run([]string{"report", "mfa", "-o", "csv"})
+	if !strings.Contains(stdout.String(), `"'=HYPERLINK(""x"")"`) {
+		t.Errorf("report mfa -o csv should keep a login from running as a formula, got\n%v", stdout)
+	}
+}
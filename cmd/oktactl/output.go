@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+// print writes v as JSON, or the rows under the columns as a table or CSV
+func (a *app) print(v interface{}, columns []string, rows [][]string) error {
+	switch a.output {
+	case "json":
+		encoder := json.NewEncoder(a.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case "csv":
+		w := csv.NewWriter(a.stdout)
+		w.Write(columns)
+		for _, row := range rows {
+			w.Write(csvRow(row))
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(columns, "\t")))
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	}
+}
+
+// stream writes rows one at a time as they arrive, for logs tail
+type stream struct {
+	a       *app
+	columns []string
+	csv     *csv.Writer
+	header  bool
+}
+
+func (a *app) stream(columns []string) *stream {
+	s := &stream{a: a, columns: columns}
+	if a.output == "csv" {
+		s.csv = csv.NewWriter(a.stdout)
+	}
+	return s
+}
+
+func (s *stream) write(v interface{}, row []string) error {
+	switch s.a.output {
+	case "json":
+		return json.NewEncoder(s.a.stdout).Encode(v)
+	case "csv":
+		if !s.header {
+			s.csv.Write(s.columns)
+			s.header = true
+		}
+		s.csv.Write(csvRow(row))
+		s.csv.Flush()
+		return s.csv.Error()
+	default:
+		_, err := fmt.Fprintln(s.a.stdout, strings.Join(row, "  "))
+		return err
+	}
+}
+
+// csvRow keeps the cells of a row from running as formulas in a spreadsheet
+func csvRow(row []string) []string {
+	safe := make([]string, len(row))
+	for i, cell := range row {
+		safe[i] = okta.CSVText(cell)
+	}
+	return safe
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// parseTime takes RFC 3339 or a duration before now, like 24h
+func parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a RFC 3339 time nor a duration", v)
+	}
+	return t, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/chrismalek/oktasdk-go/okta/report"
+)
+
+func reportCommand() *command {
+	return &command{
+		name:    "report",
+		summary: "Audit reports",
+		subcommands: []*command{
+			{name: "access", summary: "Who has access to which app, directly or through which group", run: reportAccess},
+			{name: "mfa", summary: "MFA coverage of ACTIVE users: no factors, only weak factors or pending activations", run: reportMFA},
+		},
+	}
+}
+
+func reportAccess(fs *flag.FlagSet) func(a *app, args []string) error {
+	var apps multiFlag
+	fs.Var(&apps, "app", "review this app `id`, repeat for more (default every ACTIVE app)")
+	inactive := fs.Bool("include-inactive", false, "review INACTIVE apps too")
+	return func(a *app, args []string) error {
+		if len(args) != 0 {
+			return errUsage
+		}
+		client, err := a.oktaClient()
+		if err != nil {
+			return err
+		}
+		review, err := report.Access(a.ctx, client, &report.AccessOptions{AppIDs: apps, IncludeInactive: *inactive})
+		if err != nil {
+			return err
+		}
+		if a.output == "csv" {
+			return review.WriteCSV(a.stdout)
+		}
+		return a.print(review, report.AccessColumns, review.Rows())
+	}
+}
+
+func reportMFA(fs *flag.FlagSet) func(a *app, args []string) error {
+	summary := fs.Bool("summary", false, "print the counts by coverage and factor type instead of the users")
+	workers := fs.Int("workers", 0, "how many users' factors are read at the same time")
+	weak := fs.String("weak", strings.Join(report.DefaultWeakFactorTypes, ","), "comma separated factor `types` that count as weak")
+	return func(a *app, args []string) error {
+		if len(args) != 0 {
+			return errUsage
+		}
+		client, err := a.oktaClient()
+		if err != nil {
+			return err
+		}
+		mfa, err := report.MFA(a.ctx, client, &report.MFAOptions{Workers: *workers, WeakFactorTypes: strings.Split(*weak, ",")})
+		if err != nil {
+			return err
+		}
+		switch {
+		case *summary && a.output == "csv":
+			return mfa.WriteSummaryCSV(a.stdout)
+		case *summary:
+			return a.print(mfa.Summary, report.SummaryColumns, mfa.SummaryRows())
+		case a.output == "csv":
+			return mfa.WriteCSV(a.stdout)
+		}
+		return a.print(mfa, report.MFAColumns, mfa.Rows())
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+var userColumns = []string{"id", "status", "login", "email", "firstName", "lastName", "lastLogin"}
+
+func userRows(users []okta.User) [][]string {
+	rows := make([][]string, len(users))
+	for i, u := range users {
+		rows[i] = []string{u.ID, u.Status, u.Profile.Login, u.Profile.Email, u.Profile.FirstName, u.Profile.LastName, u.LastLogin}
+	}
+	return rows
+}
+
+func usersCommand() *command {
+	return &command{
+		name:    "users",
+		summary: "Get, list, search, create, update and change the status of users",
+		subcommands: []*command{
+			{name: "get", args: "<id or login>", summary: "Show a user", run: usersGet},
+			{name: "list", summary: "List users", run: usersList},
+			{name: "search", args: "<expression>", summary: `Search users, e.g. 'profile.department eq "Engineering"'`, run: usersSearch},
+			{name: "create", summary: "Create a user", run: usersCreate},
+			{name: "update", args: "<id> name=value...", summary: "Set profile attributes, an empty value clears one", run: usersUpdate},
+			lifecycleCommand("activate", "Activate a user", func(c *okta.Client, id string, sendEmail bool) (interface{}, error) {
+				r, _, err := c.Users.Activate(id, sendEmail)
+				return r, err
+			}),
+			lifecycleCommand("deactivate", "Deactivate a user", func(c *okta.Client, id string, _ bool) (interface{}, error) {
+				_, err := c.Users.Deactivate(id)
+				return nil, err
+			}),
+			lifecycleCommand("suspend", "Suspend a user", func(c *okta.Client, id string, _ bool) (interface{}, error) {
+				_, err := c.Users.Suspend(id)
+				return nil, err
+			}),
+			lifecycleCommand("unsuspend", "Unsuspend a user", func(c *okta.Client, id string, _ bool) (interface{}, error) {
+				_, err := c.Users.Unsuspend(id)
+				return nil, err
+			}),
+			lifecycleCommand("unlock", "Unlock a locked out user", func(c *okta.Client, id string, _ bool) (interface{}, error) {
+				_, err := c.Users.Unlock(id)
+				return nil, err
+			}),
+			lifecycleCommand("reset-password", "Start a password reset", func(c *okta.Client, id string, sendEmail bool) (interface{}, error) {
+				r, _, err := c.Users.ResetPassword(id, sendEmail)
+				return r, err
+			}),
+		},
+	}
+}
+
+func usersGet(fs *flag.FlagSet) func(a *app, args []string) error {
+	return func(a *app, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+		client, err := a.oktaClient()
+		if err != nil {
+			return err
+		}
+		user, _, err := client.Users.GetByID(args[0])
+		if err != nil {
+			return err
+		}
+		return a.print(user, userColumns, userRows([]okta.User{*user}))
+	}
+}
+
+func usersList(fs *flag.FlagSet) func(a *app, args []string) error {
+	status := fs.String("status", "", "only users with this `status`, e.g. ACTIVE")
+	filter := fs.String("filter", "", "OKTA filter `expression`")
+	q := fs.String("q", "", "users whose first name, last name or email start with this")
+	limit := fs.Int("limit", 0, "page size")
+	all := fs.Bool("all", false, "get every page, not just the first")
+	return func(a *app, args []string) error {
+		if len(args) != 0 {
+			return errUsage
+		}
+		return a.listUsers(&okta.UserListFilterOptions{StatusEqualTo: *status, FilterString: *filter, Q: *q, Limit: *limit, GetAllPages: *all})
+	}
+}
+
+func usersSearch(fs *flag.FlagSet) func(a *app, args []string) error {
+	return func(a *app, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+		return a.listUsers(&okta.UserListFilterOptions{Search: args[0], GetAllPages: true})
+	}
+}
+
+func (a *app) listUsers(opt *okta.UserListFilterOptions) error {
+	client, err := a.oktaClient()
+	if err != nil {
+		return err
+	}
+	users, _, err := client.Users.ListWithFilter(opt)
+	if err != nil {
+		return err
+	}
+	if users == nil {
+		users = []okta.User{}
+	}
+	return a.print(users, userColumns, userRows(users))
+}
+
+func usersCreate(fs *flag.FlagSet) func(a *app, args []string) error {
+	login := fs.String("login", "", "login (required)")
+	email := fs.String("email", "", "email, defaults to the login")
+	first := fs.String("first", "", "first name (required)")
+	last := fs.String("last", "", "last name (required)")
+	passwordStdin := fs.Bool("password-stdin", false, "read the initial password from the first line of stdin")
+	activate := fs.Bool("activate", false, "activate the user")
+	var attrs multiFlag
+	fs.Var(&attrs, "attr", "profile attribute as `name=value`, can be repeated")
+	return func(a *app, args []string) error {
+		if len(args) != 0 || *login == "" || *first == "" || *last == "" {
+			return errUsage
+		}
+		profile, err := parseAttrs(attrs)
+		if err != nil {
+			return err
+		}
+		// the password isn't a flag so it stays out of shell history and ps
+		var password string
+		if *passwordStdin {
+			if password, err = readPassword(a.stdin); err != nil {
+				return err
+			}
+		}
+		client, err := a.oktaClient()
+		if err != nil {
+			return err
+		}
+
+		user := client.Users.NewUser()
+		user.Profile.Login = *login
+		user.Profile.Email = *email
+		if user.Profile.Email == "" {
+			user.Profile.Email = *login
+		}
+		user.Profile.FirstName = *first
+		user.Profile.LastName = *last
+		user.Profile.Custom = profile
+		user.SetPassword(password)
+
+		created, _, err := client.Users.Create(user, *activate)
+		if err != nil {
+			return err
+		}
+		return a.print(created, userColumns, userRows([]okta.User{*created}))
+	}
+}
+
+// readPassword reads the first line of r without its line ending
+func readPassword(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("--password-stdin: no password on stdin")
+	}
+	return password, nil
+}
+
+func usersUpdate(fs *flag.FlagSet) func(a *app, args []string) error {
+	return func(a *app, args []string) error {
+		if len(args) < 2 {
+			return errUsage
+		}
+		profile, err := parseAttrs(args[1:])
+		if err != nil {
+			return err
+		}
+		client, err := a.oktaClient()
+		if err != nil {
+			return err
+		}
+		user, _, err := client.Users.UpdateProfile(args[0], profile)
+		if err != nil {
+			return err
+		}
+		return a.print(user, userColumns, userRows([]okta.User{*user}))
+	}
+}
+
+// parseAttrs reads name=value pairs. An empty value is nil, which clears the attribute.
+func parseAttrs(attrs []string) (map[string]interface{}, error) {
+	profile := map[string]interface{}{}
+	for _, attr := range attrs {
+		name, value, ok := strings.Cut(attr, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("attribute %q should be name=value", attr)
+		}
+		if value == "" {
+			profile[name] = nil
+		} else {
+			profile[name] = value
+		}
+	}
+	return profile, nil
+}
+
+func lifecycleCommand(name string, summary string, do func(c *okta.Client, id string, sendEmail bool) (interface{}, error)) *command {
+	return &command{name: name, args: "<id>...", summary: summary, run: func(fs *flag.FlagSet) func(a *app, args []string) error {
+		sendEmail := new(bool)
+		if name == "activate" || name == "reset-password" {
+			fs.BoolVar(sendEmail, "send-email", false, "have OKTA email the user, otherwise the link is printed")
+		}
+		return func(a *app, args []string) error {
+			if len(args) == 0 {
+				return errUsage
+			}
+			client, err := a.oktaClient()
+			if err != nil {
+				return err
+			}
+			var results []interface{}
+			var rows [][]string
+			for _, id := range args {
+				result, err := do(client, id, *sendEmail)
+				if err != nil {
+					return fmt.Errorf("%v %v: %v", name, id, err)
+				}
+				link := ""
+				switch r := result.(type) {
+				case *okta.ActivationResponse:
+					link = r.ActivationURL
+				case *okta.ResetPasswordResponse:
+					link = r.ResetPasswordURL
+				}
+				results = append(results, map[string]string{"id": id, "result": "ok", "link": link})
+				rows = append(rows, []string{id, "ok", link})
+			}
+			return a.print(results, []string{"id", "result", "link"}, rows)
+		}
+	}}
+}
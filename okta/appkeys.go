@@ -0,0 +1,419 @@
+package okta
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+const (
+	mediaTypePEM = "application/x-pem-file"
+
+	// KeyUseSignature is the "use" value OKTA returns for application signing keys
+	KeyUseSignature = "sig"
+)
+
+// AppKey is the model for an application signing key credential. OKTA returns these as JSON Web Keys
+// http://developer.okta.com/docs/api/resources/apps.html#application-key-credential-model
+type AppKey struct {
+	Created     time.Time `json:"created"`
+	LastUpdated time.Time `json:"lastUpdated"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	X5c         []string  `json:"x5c"`
+	Kid         string    `json:"kid"`
+	Kty         string    `json:"kty"`
+	Use         string    `json:"use"`
+	X5tS256     string    `json:"x5t#S256"`
+	E           string    `json:"e,omitempty"`
+	N           string    `json:"n,omitempty"`
+}
+
+func (k AppKey) String() string {
+	return fmt.Sprintf("AppKey:(Kid: {%v} - Expires: {%v})\n", k.Kid, k.ExpiresAt)
+}
+
+// Certificates parses the x5c chain on the key. The first certificate is the one OKTA signs with.
+func (k AppKey) Certificates() ([]*x509.Certificate, error) {
+	if len(k.X5c) == 0 {
+		return nil, fmt.Errorf("key %v has no x5c certificate chain", k.Kid)
+	}
+
+	certs := make([]*x509.Certificate, 0, len(k.X5c))
+	for _, encoded := range k.X5c {
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// Certificate returns the parsed signing certificate (the first entry in the x5c chain)
+func (k AppKey) Certificate() (*x509.Certificate, error) {
+	certs, err := k.Certificates()
+	if err != nil {
+		return nil, err
+	}
+	return certs[0], nil
+}
+
+// ExpiresWithin returns true if the key expires before now + d.
+// The certificate NotAfter is used when it can be parsed, otherwise the OKTA expiresAt value.
+func (k AppKey) ExpiresWithin(d time.Duration) bool {
+	expires := k.ExpiresAt
+	if cert, err := k.Certificate(); err == nil {
+		expires = cert.NotAfter
+	}
+	return time.Now().Add(d).After(expires)
+}
+
+// CSRSubject is the subject of a certificate signing request
+type CSRSubject struct {
+	CountryName            string `json:"countryName,omitempty"`
+	StateOrProvinceName    string `json:"stateOrProvinceName,omitempty"`
+	LocalityName           string `json:"localityName,omitempty"`
+	OrganizationName       string `json:"organizationName,omitempty"`
+	OrganizationalUnitName string `json:"organizationalUnitName,omitempty"`
+	CommonName             string `json:"commonName,omitempty"`
+}
+
+// CSRMetadata is the input used to generate a new certificate signing request for an application
+// http://developer.okta.com/docs/api/resources/apps.html#csr-metadata
+type CSRMetadata struct {
+	Subject         CSRSubject `json:"subject"`
+	SubjectAltNames struct {
+		DNSNames []string `json:"dnsNames,omitempty"`
+	} `json:"subjectAltNames"`
+}
+
+// AppCSR is the model for a certificate signing request OKTA generated for an application
+type AppCSR struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	CSR     string    `json:"csr"`
+	Kty     string    `json:"kty"`
+}
+
+// CertificateRequest parses the base64 DER encoded CSR returned by OKTA
+func (c AppCSR) CertificateRequest() (*x509.CertificateRequest, error) {
+	der, err := base64.StdEncoding.DecodeString(c.CSR)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificateRequest(der)
+}
+
+// PEM returns the CSR PEM encoded so it can be handed to a certificate authority
+func (c AppCSR) PEM() ([]byte, error) {
+	der, err := base64.StdEncoding.DecodeString(c.CSR)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+// ListKeys returns all the signing key credentials for an application
+// http://developer.okta.com/docs/api/resources/apps.html#list-key-credentials-for-application
+func (a *AppsService) ListKeys(appID string) ([]AppKey, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/keys", appID)
+	req, err := a.client.NewRequest("GET", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var keys []AppKey
+	resp, err := a.client.Do(req, &keys)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return keys, resp, err
+}
+
+// GetKey returns one signing key credential for an application
+func (a *AppsService) GetKey(appID string, keyID string) (*AppKey, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/keys/%v", appID, keyID)
+	req, err := a.client.NewRequest("GET", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := new(AppKey)
+	resp, err := a.client.Do(req, key)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return key, resp, err
+}
+
+// GenerateKey generates a new X.509 certificate for an application key credential.
+// The new key is not used for signing until the app is updated with SetSigningKey.
+// validityYears must be between 2 and 10.
+func (a *AppsService) GenerateKey(appID string, validityYears int) (*AppKey, *Response, error) {
+
+	if validityYears < 2 || validityYears > 10 {
+		return nil, nil, errors.New("validityYears must be between 2 and 10")
+	}
+
+	u := fmt.Sprintf("apps/%v/credentials/keys/generate?validityYears=%v", appID, validityYears)
+	req, err := a.client.NewRequest("POST", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := new(AppKey)
+	resp, err := a.client.Do(req, key)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return key, resp, err
+}
+
+// CloneKey copies a key credential from one application to another so both can share a certificate
+// http://developer.okta.com/docs/api/resources/apps.html#clone-key-credential-for-application
+func (a *AppsService) CloneKey(appID string, keyID string, targetAppID string) (*AppKey, *Response, error) {
+
+	if targetAppID == "" {
+		return nil, nil, errors.New("targetAppID parameter is required for CloneKey")
+	}
+
+	u := fmt.Sprintf("apps/%v/credentials/keys/%v/clone?targetAid=%v", appID, keyID, targetAppID)
+	req, err := a.client.NewRequest("POST", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := new(AppKey)
+	resp, err := a.client.Do(req, key)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return key, resp, err
+}
+
+// ListCSRs returns the certificate signing requests for an application
+func (a *AppsService) ListCSRs(appID string) ([]AppCSR, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/csrs", appID)
+	req, err := a.client.NewRequest("GET", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var csrs []AppCSR
+	resp, err := a.client.Do(req, &csrs)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return csrs, resp, err
+}
+
+// GetCSR returns one certificate signing request for an application
+func (a *AppsService) GetCSR(appID string, csrID string) (*AppCSR, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/csrs/%v", appID, csrID)
+	req, err := a.client.NewRequest("GET", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	csr := new(AppCSR)
+	resp, err := a.client.Do(req, csr)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return csr, resp, err
+}
+
+// GenerateCSR has OKTA create a new key pair and return a certificate signing request for it
+// http://developer.okta.com/docs/api/resources/apps.html#generate-csr-for-application
+func (a *AppsService) GenerateCSR(appID string, metadata CSRMetadata) (*AppCSR, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/csrs", appID)
+	req, err := a.client.NewRequest("POST", u, metadata)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	csr := new(AppCSR)
+	resp, err := a.client.Do(req, csr)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return csr, resp, err
+}
+
+// RevokeCSR revokes a certificate signing request and deletes the key pair behind it
+func (a *AppsService) RevokeCSR(appID string, csrID string) (*Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/csrs/%v", appID, csrID)
+	req, err := a.client.NewRequest("DELETE", u, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.client.Do(req, nil)
+
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, err
+}
+
+// PublishCSR publishes the certificate a CA issued for a CSR. cert may be PEM or DER encoded.
+// The returned key is not used for signing until the app is updated with SetSigningKey.
+// http://developer.okta.com/docs/api/resources/apps.html#publish-csr-for-application
+func (a *AppsService) PublishCSR(appID string, csrID string, cert []byte) (*AppKey, *Response, error) {
+
+	if len(cert) == 0 {
+		return nil, nil, errors.New("cert parameter is required for PublishCSR")
+	}
+	if block, _ := pem.Decode(cert); block == nil {
+		cert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})
+	}
+
+	u := fmt.Sprintf("apps/%v/credentials/csrs/%v/lifecycle/publish", appID, csrID)
+	req, err := a.client.NewRequest("POST", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(cert))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(cert)), nil
+	}
+	req.ContentLength = int64(len(cert))
+	req.Header.Set("Content-Type", mediaTypePEM)
+
+	key := new(AppKey)
+	resp, err := a.client.Do(req, key)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return key, resp, err
+}
+
+// SetSigningKey updates the application so OKTA signs with the key credential keyID.
+// The app is read and written back as raw JSON so settings the App model does not know about are preserved.
+// http://developer.okta.com/docs/api/resources/apps.html#update-key-credential-for-application
+func SetSigningKey(client *Client, appID string, keyID string) (*App, *Response, error) {
+
+	if keyID == "" {
+		return nil, nil, errors.New("keyID parameter is required for SetSigningKey")
+	}
+
+	u := fmt.Sprintf("apps/%v", appID)
+	req, err := client.NewRequest("GET", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw := make(map[string]interface{})
+	resp, err := client.Do(req, &raw)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	creds, _ := raw["credentials"].(map[string]interface{})
+	if creds == nil {
+		creds = make(map[string]interface{})
+		raw["credentials"] = creds
+	}
+	signing, _ := creds["signing"].(map[string]interface{})
+	if signing == nil {
+		signing = make(map[string]interface{})
+		creds["signing"] = signing
+	}
+	signing["kid"] = keyID
+	delete(raw, "_links")
+	delete(raw, "_embedded")
+
+	req, err = client.NewRequest("PUT", u, raw)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	app := new(App)
+	resp, err = client.Do(req, app)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return app, resp, err
+}
+
+// RotateSigningKey generates a new key credential and switches the application to sign with it.
+// The new key is returned so its certificate can be distributed to service providers.
+func RotateSigningKey(client *Client, appID string, validityYears int) (*AppKey, *Response, error) {
+
+	key, resp, err := client.Apps.GenerateKey(appID, validityYears)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	_, resp, err = SetSigningKey(client, appID, key.Kid)
+
+	if err != nil {
+		return key, resp, err
+	}
+
+	return key, resp, err
+}
+
+// GetSigningKey returns the key credential the application currently signs with
+func GetSigningKey(apps AppsAPI, appID string) (*AppKey, *Response, error) {
+
+	app, resp, err := apps.GetByID(appID)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if app.Credentials.Signing.Kid == "" {
+		return nil, resp, fmt.Errorf("app %v does not have a signing key", appID)
+	}
+
+	return apps.GetKey(appID, app.Credentials.Signing.Kid)
+}
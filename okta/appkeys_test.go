@@ -0,0 +1,147 @@
+package okta
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func testSelfSignedCert(t *testing.T, notAfter time.Time) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test-org"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Error creating certificate: %v", err)
+	}
+	return der
+}
+
+func TestAppListKeys(t *testing.T) {
+	setup()
+	defer teardown()
+
+	notAfter := time.Now().Add(10 * 24 * time.Hour).Truncate(time.Second)
+	der := testSelfSignedCert(t, notAfter)
+
+	mux.HandleFunc("/apps/0oa1/credentials/keys", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testAuthHeader(t, r)
+		fmt.Fprintf(w, `[{"kid":"key1","kty":"RSA","use":"sig","expiresAt":"2030-01-01T00:00:00.000Z","x5c":["%v"]}]`,
+			base64.StdEncoding.EncodeToString(der))
+	})
+
+	keys, _, err := client.Apps.ListKeys("0oa1")
+	if err != nil {
+		t.Fatalf("Apps.ListKeys returned error: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Kid != "key1" {
+		t.Fatalf("Apps.ListKeys returned %+v", keys)
+	}
+
+	cert, err := keys[0].Certificate()
+	if err != nil {
+		t.Fatalf("AppKey.Certificate returned error: %v", err)
+	}
+	if !cert.NotAfter.Equal(notAfter) {
+		t.Errorf("Certificate NotAfter %v, want %v", cert.NotAfter, notAfter)
+	}
+	if !keys[0].ExpiresWithin(30 * 24 * time.Hour) {
+		t.Errorf("Expected key to expire within 30 days")
+	}
+	if keys[0].ExpiresWithin(24 * time.Hour) {
+		t.Errorf("Did not expect key to expire within 1 day")
+	}
+}
+
+func TestAppSetSigningKeyPreservesSettings(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/apps/0oa1", func(w http.ResponseWriter, r *http.Request) {
+		testAuthHeader(t, r)
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `{"id":"0oa1","name":"app","settings":{"app":{"customSetting":"keep"}},"credentials":{"signing":{"kid":"old"}},"_links":{}}`)
+		case "PUT":
+			body, _ := ioutil.ReadAll(r.Body)
+			var raw map[string]interface{}
+			json.Unmarshal(body, &raw)
+			if _, ok := raw["_links"]; ok {
+				t.Errorf("_links should not be sent on update")
+			}
+			settings := raw["settings"].(map[string]interface{})["app"].(map[string]interface{})
+			if settings["customSetting"] != "keep" {
+				t.Errorf("App settings were not preserved: %v", string(body))
+			}
+			fmt.Fprint(w, `{"id":"0oa1","credentials":{"signing":{"kid":"new"}}}`)
+		default:
+			t.Errorf("Unexpected method %v", r.Method)
+		}
+	})
+
+	app, _, err := SetSigningKey(client, "0oa1", "new")
+	if err != nil {
+		t.Fatalf("SetSigningKey returned error: %v", err)
+	}
+	if app.Credentials.Signing.Kid != "new" {
+		t.Errorf("Signing Kid %v, want new", app.Credentials.Signing.Kid)
+	}
+}
+
+func TestAppPublishCSRSendsPEM(t *testing.T) {
+	setup()
+	defer teardown()
+
+	der := testSelfSignedCert(t, time.Now().Add(time.Hour))
+
+	calls := 0
+	mux.HandleFunc("/apps/0oa1/credentials/csrs/csr1/lifecycle/publish", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		if ct := r.Header.Get("Content-Type"); ct != mediaTypePEM {
+			t.Errorf("Content-Type %v, want %v", ct, mediaTypePEM)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if len(body) < 27 || string(body[:27]) != "-----BEGIN CERTIFICATE-----" {
+			t.Errorf("Expected PEM body, got %q", body)
+		}
+		calls++
+		if calls == 1 {
+			w.Header().Add(headerRateLimit, "600")
+			w.Header().Add(headerRateRemaining, "0")
+			w.Header().Add(headerRateReset, strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"errorCode":"E0000047","errorSummary":"API call exceeded rate limit due to too many requests."}`)
+			return
+		}
+		fmt.Fprint(w, `{"kid":"published"}`)
+	})
+
+	// a rate limited publish is sent again with the certificate
+	client.MaxRetries = 1
+	client.MaxRetryWait = 10 * time.Millisecond
+	key, _, err := client.Apps.PublishCSR("0oa1", "csr1", der)
+	if err != nil {
+		t.Fatalf("Apps.PublishCSR returned error: %v", err)
+	}
+	if key.Kid != "published" || calls != 2 {
+		t.Errorf("Kid %v after %v calls, want published after 2", key.Kid, calls)
+	}
+}
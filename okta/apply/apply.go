@@ -0,0 +1,173 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+// Apply plans the changes and, unless opt.DryRun is set, makes them. The plan is returned either
+// way so it can be printed.
+func Apply(ctx context.Context, client *okta.Client, cfg *Config, opt *Options) (*Plan, error) {
+	plan, err := NewPlan(client, cfg, opt)
+	if err != nil {
+		return nil, err
+	}
+	if opt != nil && opt.DryRun {
+		return plan, nil
+	}
+	return plan, plan.Apply(ctx)
+}
+
+// Apply makes the changes in order. A failed change doesn't stop the others, it is marked with
+// its Error and changes that need it, like the members of a group that wasn't created, fail too.
+// Apply stops when ctx is done.
+func (p *Plan) Apply(ctx context.Context) error {
+	failed := 0
+	for i := range p.Changes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c := &p.Changes[i]
+		if c.Applied {
+			continue
+		}
+		if err := p.apply(c); err != nil {
+			c.Error = err.Error()
+			failed++
+			continue
+		}
+		c.Applied = true
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d changes failed", failed, len(p.Changes))
+	}
+	return nil
+}
+
+// groupID returns the ID of a group in the org or created by the plan
+func (p *Plan) groupID(name string) (string, error) {
+	id := p.groupIDs[strings.ToLower(name)]
+	if id == "" {
+		return "", fmt.Errorf("group %q wasn't created", name)
+	}
+	return id, nil
+}
+
+func (p *Plan) apply(c *Change) error {
+	client := p.client
+	switch c.Type {
+	case TypeGroup:
+		switch c.Action {
+		case Create:
+			group, _, err := client.Groups.Add(c.group.Name, c.group.Description)
+			if err != nil {
+				return err
+			}
+			p.groupIDs[strings.ToLower(c.group.Name)] = group.ID
+			return nil
+		case Update:
+			_, _, err := client.Groups.Update(c.id, c.group.Name, c.group.Description)
+			return err
+		case Delete:
+			_, err := client.Groups.Delete(c.id)
+			return err
+		}
+
+	case TypeGroupMember:
+		groupID, err := p.groupID(c.Name)
+		if err != nil {
+			return err
+		}
+		if c.Action == Delete {
+			_, err = client.Groups.RemoveUserFromGroup(groupID, c.memberID)
+		} else {
+			_, err = client.Groups.AddUserToGroup(groupID, c.memberID)
+		}
+		return err
+
+	case TypeRule:
+		if c.Action == Delete {
+			if c.ruleStatus == okta.GroupRuleStatusActive {
+				if _, err := client.GroupRules.Deactivate(c.id); err != nil {
+					return err
+				}
+			}
+			_, err := client.GroupRules.Delete(c.id)
+			return err
+		}
+		return p.applyRule(c)
+
+	case TypeAppGroup:
+		if c.Action == Delete {
+			_, err := client.Apps.UnassignGroup(c.id, c.memberID)
+			return err
+		}
+		groupID, err := p.groupID(c.Member)
+		if err != nil {
+			return err
+		}
+		_, _, err = client.Apps.AssignGroup(c.id, groupID, c.priority)
+		return err
+	}
+	return fmt.Errorf("unknown change %v %v", c.Action, c.Type)
+}
+
+// applyRule creates or updates a rule. OKTA only updates inactive rules, so an active rule is
+// deactivated for the update and activated again after it.
+func (p *Plan) applyRule(c *Change) error {
+	rules := p.client.GroupRules
+	groupIDs := make([]string, 0, len(c.rule.Groups))
+	for _, name := range c.rule.Groups {
+		id, err := p.groupID(name)
+		if err != nil {
+			return err
+		}
+		groupIDs = append(groupIDs, id)
+	}
+
+	active := c.ruleStatus == okta.GroupRuleStatusActive
+	switch {
+	case c.Action == Create:
+		created, _, err := rules.Create(okta.NewGroupRule(c.rule.Name, c.rule.Expression, groupIDs...))
+		if err != nil {
+			return err
+		}
+		c.id = created.ID
+	case c.ruleChanged:
+		deactivated := false
+		if active {
+			if _, err := rules.Deactivate(c.id); err != nil {
+				return err
+			}
+			active = false
+			deactivated = true
+		}
+		// only what the Config declares changes, exclusions on the org rule are kept
+		rule := c.orgRule
+		rule.Name = c.rule.Name
+		rule.Conditions.Expression.Value = c.rule.Expression
+		rule.Actions.AssignUserToGroups.GroupIDs = groupIDs
+		if _, _, err := rules.Update(rule); err != nil {
+			// leave the rule as it was found instead of INACTIVE
+			if deactivated {
+				if _, activateErr := rules.Activate(c.id); activateErr != nil {
+					return fmt.Errorf("%v (and the rule was left INACTIVE: %v)", err, activateErr)
+				}
+			}
+			return err
+		}
+	}
+
+	switch {
+	case c.rule.IsActive() && !active:
+		_, err := rules.Activate(c.id)
+		return err
+	case !c.rule.IsActive() && active:
+		_, err := rules.Deactivate(c.id)
+		return err
+	}
+	return nil
+}
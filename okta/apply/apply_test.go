@@ -0,0 +1,224 @@
+package apply
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+	"github.com/chrismalek/oktasdk-go/okta/oktatest"
+)
+
+const testConfig = `
+prefix: eng-
+groups:
+  - name: eng-backend
+    description: Backend engineers
+    members: [anna@example.com, bob@example.com]
+  - name: eng-oncall
+    members: [00ubob]
+rules:
+  - name: eng-everyone
+    expression: user.department == "Engineering"
+    groups: [eng-backend, eng-oncall]
+  - name: eng-contractors
+    expression: user.userType == "Contractor"
+    groups: [eng-oncall]
+    active: false
+apps:
+  - label: PagerDuty
+    groups:
+      - name: eng-oncall
+        priority: 0
+`
+
+func TestPlanAndApply(t *testing.T) {
+	server := oktatest.NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	anna := server.AddUser(oktatest.User{ID: "00uanna", Profile: map[string]interface{}{"login": "anna@example.com"}})
+	bob := server.AddUser(oktatest.User{ID: "00ubob", Profile: map[string]interface{}{"login": "bob@example.com"}})
+	carol := server.AddUser(oktatest.User{ID: "00ucarol", Profile: map[string]interface{}{"login": "carol@example.com"}})
+	backend := server.AddGroup(oktatest.Group{ID: "00gbackend", Name: "eng-backend", Description: "Backend"})
+	server.AddGroupMember(backend, anna)
+	server.AddGroupMember(backend, carol)
+	legacy := server.AddGroup(oktatest.Group{ID: "00glegacy", Name: "eng-legacy"})
+	sales := server.AddGroup(oktatest.Group{ID: "00gsales", Name: "Sales"})
+	server.AddGroupMember(sales, carol)
+	everyone := server.AddGroupRule(oktatest.GroupRule{ID: "0preveryone", Name: "eng-everyone", Status: okta.GroupRuleStatusActive,
+		Expression: `user.department == "Eng"`, GroupIDs: []string{backend}, ExcludeUsers: []string{carol}})
+	server.AddGroupRule(oktatest.GroupRule{ID: "0prold", Name: "eng-old", Expression: "true", GroupIDs: []string{legacy}})
+	pagerduty := server.AddApp(oktatest.App{ID: "0oapagerduty", Name: "pagerduty", Label: "PagerDuty"})
+	server.AssignGroupToApp(pagerduty, sales)
+	server.AssignGroupToApp(pagerduty, legacy)
+
+	cfg, err := Read(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	plan, err := NewPlan(client, cfg, &Options{Prune: true})
+	if err != nil {
+		t.Fatalf("NewPlan returned error: %v", err)
+	}
+
+	var got []string
+	for _, c := range plan.Changes {
+		got = append(got, c.Action+" "+c.Type+" "+c.Name+" "+c.Member)
+	}
+	want := []string{
+		"update group eng-backend ",
+		"create group eng-oncall ",
+		"create groupMember eng-backend bob@example.com",
+		"create groupMember eng-oncall bob@example.com",
+		"update rule eng-everyone ",
+		"create rule eng-contractors ",
+		"create appGroup PagerDuty eng-oncall",
+		"delete appGroup PagerDuty Sales",
+		"delete appGroup PagerDuty eng-legacy",
+		"delete rule eng-old ",
+		"delete group eng-legacy ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("plan should be\n%v\nbut was\n%v", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	var text bytes.Buffer
+	plan.WriteText(&text)
+	for _, line := range []string{
+		"Plan: 5 to create, 2 to update, 4 to delete\n",
+		"~ group eng-backend\n    description: \"Backend\" -> \"Backend engineers\"\n",
+		"    groups: \"eng-backend\" -> \"eng-backend, eng-oncall\"\n",
+		"- appGroup PagerDuty: Sales\n",
+	} {
+		if !strings.Contains(text.String(), line) {
+			t.Errorf("plan text should contain %q:\n%v", line, text.String())
+		}
+	}
+
+	if err := plan.Apply(context.Background()); err != nil {
+		var out bytes.Buffer
+		plan.WriteText(&out)
+		t.Fatalf("Apply returned error: %v\n%v", err, out.String())
+	}
+
+	group, _ := server.GetGroup(backend)
+	if group.Description != "Backend engineers" {
+		t.Errorf("eng-backend description should be updated, got %q", group.Description)
+	}
+	// eng-backend is assigned by a rule, so carol isn't pruned
+	if members := server.GroupMembers(backend); !reflect.DeepEqual(members, []string{anna, carol, bob}) {
+		t.Errorf("eng-backend members should be anna, carol and bob, got %v", members)
+	}
+	if _, ok := server.GetGroup(legacy); ok {
+		t.Errorf("eng-legacy should be pruned")
+	}
+	if _, ok := server.GetGroupRule("0prold"); ok {
+		t.Errorf("eng-old should be pruned")
+	}
+	rule, _ := server.GetGroupRule(everyone)
+	if rule.Status != okta.GroupRuleStatusActive || rule.Expression != `user.department == "Engineering"` || len(rule.GroupIDs) != 2 {
+		t.Errorf("eng-everyone should be updated and active again, got %+v", rule)
+	}
+	if !reflect.DeepEqual(rule.ExcludeUsers, []string{carol}) {
+		t.Errorf("eng-everyone should still exclude carol, got %v", rule.ExcludeUsers)
+	}
+	if groups := server.AppGroups(pagerduty); len(groups) != 1 || groups[0] == sales {
+		t.Errorf("PagerDuty should only have eng-oncall, got %v", groups)
+	}
+	if _, ok := server.GetGroup(sales); !ok {
+		t.Errorf("Sales doesn't start with the prefix and should be left alone")
+	}
+
+	again, err := NewPlan(client, cfg, &Options{Prune: true})
+	if err != nil {
+		t.Fatalf("NewPlan returned error: %v", err)
+	}
+	if len(again.Changes) != 0 {
+		var out bytes.Buffer
+		again.WriteText(&out)
+		t.Errorf("a second plan should have no changes:\n%v", out.String())
+	}
+}
+
+func TestApplyReactivatesRuleWhenUpdateFails(t *testing.T) {
+	server := oktatest.NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	backend := server.AddGroup(oktatest.Group{ID: "00gbackend", Name: "eng-backend"})
+	everyone := server.AddGroupRule(oktatest.GroupRule{ID: "0preveryone", Name: "eng-everyone", Status: okta.GroupRuleStatusActive,
+		Expression: `user.department == "Eng"`, GroupIDs: []string{backend}})
+
+	cfg, err := Read(strings.NewReader(`
+prefix: eng-
+groups:
+  - name: eng-backend
+rules:
+  - name: eng-everyone
+    expression: user.department == "Engineering"
+    groups: [eng-backend]
+`))
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	plan, err := NewPlan(client, cfg, nil)
+	if err != nil {
+		t.Fatalf("NewPlan returned error: %v", err)
+	}
+
+	server.FailNext("PUT", "groups/rules/"+everyone, http.StatusBadRequest, "E0000001", "Api validation failed: expression")
+	if err := plan.Apply(context.Background()); err == nil {
+		t.Fatalf("Apply should fail when the rule update fails")
+	}
+	if len(plan.Changes) != 1 || !strings.Contains(plan.Changes[0].Error, "E0000001") {
+		t.Fatalf("the rule change should have the update error, got %+v", plan.Changes)
+	}
+	rule, _ := server.GetGroupRule(everyone)
+	if rule.Status != okta.GroupRuleStatusActive || rule.Expression != `user.department == "Eng"` {
+		t.Errorf("eng-everyone should be left unchanged and active, got %+v", rule)
+	}
+}
+
+func TestDryRunAndProblems(t *testing.T) {
+	server := oktatest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	server.AddUser(oktatest.User{ID: "00uanna", Profile: map[string]interface{}{"login": "anna@example.com"}})
+
+	cfg, err := Read(strings.NewReader("groups:\n  - name: Engineering\n    members: [anna@example.com]\n"))
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	plan, err := Apply(context.Background(), client, cfg, &Options{DryRun: true})
+	if err != nil || len(plan.Changes) != 2 {
+		t.Fatalf("dry run should plan 2 changes, got %+v %v", plan, err)
+	}
+	for _, r := range server.Requests() {
+		if r.Method != "GET" {
+			t.Errorf("dry run should only read, sent %v %v", r.Method, r.Path)
+		}
+	}
+
+	if _, err := Read(strings.NewReader("prefix: eng-\ngroups:\n  - name: Sales\n  - name: eng-a\n    member: [x]\n")); err == nil || !strings.Contains(err.Error(), "member not found") {
+		t.Errorf("unknown keys should be an error, got %v", err)
+	}
+	cfg = &Config{
+		Prefix: "eng-",
+		Groups: []Group{{Name: "Sales"}, {Name: "eng-a", Members: []string{"nobody@example.com"}}},
+		Rules:  []Rule{{Name: "eng-rule", Expression: "true", Groups: []string{"eng-missing"}}},
+		Apps:   []App{{Label: "Nothing", Groups: []AppGroup{{Name: "eng-a"}}}},
+	}
+	if _, err := NewPlan(client, cfg, nil); err == nil || len(err.(*Error).Problems) != 1 {
+		t.Errorf("the prefix should be checked first, got %v", err)
+	}
+	cfg.Groups = cfg.Groups[1:]
+	_, err = NewPlan(client, cfg, nil)
+	problems, ok := err.(*Error)
+	if !ok || len(problems.Problems) != 3 {
+		t.Fatalf("NewPlan should report the unknown user, group and app, got %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+// Package apply manages groups, group memberships, group rules and app group assignments from a
+// YAML file. NewPlan reads the org through the SDK and works out the changes that make it match the
+// file, Plan.Apply makes them in dependency order: groups before their members, rules and app
+// assignments, and removals last in the reverse order.
+//
+//	cfg, err := apply.ReadFile("okta-groups.yaml")
+//	plan, err := apply.NewPlan(client, cfg, &apply.Options{Prune: true})
+//	plan.WriteText(os.Stdout)
+//	err = plan.Apply(ctx)
+//
+// A file looks like this. Groups and rules are matched by name, apps by id or label. A member is
+// a login or a user ID.
+//
+//	prefix: eng-
+//	groups:
+//	  - name: eng-backend
+//	    description: Backend engineers
+//	    members:
+//	      - anna@example.com
+//	      - 00u1a2b3c4d5e6f7g8h9
+//	  - name: eng-oncall
+//	rules:
+//	  - name: eng-everyone
+//	    expression: user.department == "Engineering"
+//	    groups: [eng-backend]
+//	apps:
+//	  - label: PagerDuty
+//	    groups:
+//	      - name: eng-oncall
+//	        priority: 0
+//
+// Leaving out members, or the groups of an app, leaves them unmanaged: nothing is added or
+// removed. An empty list manages them as empty.
+package apply
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the desired state read from YAML
+type Config struct {
+	// Prefix is the part of the org the file owns. Every group and rule in the file must start with
+	// it, and with Options.Prune groups and rules that start with it but aren't in the file are deleted.
+	Prefix string  `yaml:"prefix" json:"prefix,omitempty"`
+	Groups []Group `yaml:"groups" json:"groups,omitempty"`
+	Rules  []Rule  `yaml:"rules" json:"rules,omitempty"`
+	Apps   []App   `yaml:"apps" json:"apps,omitempty"`
+}
+
+// Group is an OKTA_GROUP. Members are logins or user IDs, nil leaves membership unmanaged.
+type Group struct {
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description" json:"description,omitempty"`
+	Members     []string `yaml:"members" json:"members,omitempty"`
+}
+
+// Rule is a group rule assigning the users matching Expression to Groups, by group name.
+// Active defaults to true.
+type Rule struct {
+	Name       string   `yaml:"name" json:"name"`
+	Expression string   `yaml:"expression" json:"expression"`
+	Groups     []string `yaml:"groups" json:"groups"`
+	Active     *bool    `yaml:"active" json:"active,omitempty"`
+}
+
+// IsActive reports whether the rule should be ACTIVE
+func (r Rule) IsActive() bool {
+	return r.Active == nil || *r.Active
+}
+
+// App selects an existing app by ID or label and lists the groups assigned to it. Nil Groups
+// leaves the assignments unmanaged.
+type App struct {
+	ID     string     `yaml:"id" json:"id,omitempty"`
+	Label  string     `yaml:"label" json:"label,omitempty"`
+	Groups []AppGroup `yaml:"groups" json:"groups,omitempty"`
+}
+
+// AppGroup is a group assigned to an app. Without a priority an existing assignment keeps its
+// priority and a new one goes last.
+type AppGroup struct {
+	Name     string `yaml:"name" json:"name"`
+	Priority *int   `yaml:"priority" json:"priority,omitempty"`
+}
+
+// Read decodes and validates a config. Unknown keys are an error so typos don't go unnoticed.
+func Read(r io.Reader) (*Config, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	cfg := new(Config)
+	if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ReadFile reads a config from a file
+func ReadFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cfg, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks the config on its own, without looking at the org
+func (c *Config) Validate() error {
+	var problems []string
+	groups := map[string]bool{}
+	for i, g := range c.Groups {
+		switch {
+		case g.Name == "":
+			problems = append(problems, fmt.Sprintf("groups[%d]: name is required", i))
+		case groups[strings.ToLower(g.Name)]:
+			problems = append(problems, fmt.Sprintf("group %q is declared twice", g.Name))
+		case !strings.HasPrefix(g.Name, c.Prefix):
+			problems = append(problems, fmt.Sprintf("group %q doesn't start with the prefix %q", g.Name, c.Prefix))
+		}
+		groups[strings.ToLower(g.Name)] = true
+		members := map[string]bool{}
+		for _, m := range g.Members {
+			if members[strings.ToLower(m)] {
+				problems = append(problems, fmt.Sprintf("group %q lists %q twice", g.Name, m))
+			}
+			members[strings.ToLower(m)] = true
+		}
+	}
+
+	rules := map[string]bool{}
+	for i, r := range c.Rules {
+		switch {
+		case r.Name == "":
+			problems = append(problems, fmt.Sprintf("rules[%d]: name is required", i))
+		case rules[strings.ToLower(r.Name)]:
+			problems = append(problems, fmt.Sprintf("rule %q is declared twice", r.Name))
+		case !strings.HasPrefix(r.Name, c.Prefix):
+			problems = append(problems, fmt.Sprintf("rule %q doesn't start with the prefix %q", r.Name, c.Prefix))
+		}
+		rules[strings.ToLower(r.Name)] = true
+		if r.Expression == "" {
+			problems = append(problems, fmt.Sprintf("rule %q: expression is required", r.Name))
+		}
+		if len(r.Groups) == 0 {
+			problems = append(problems, fmt.Sprintf("rule %q: groups is required", r.Name))
+		}
+	}
+
+	apps := map[string]bool{}
+	for i, a := range c.Apps {
+		if (a.ID == "") == (a.Label == "") {
+			problems = append(problems, fmt.Sprintf("apps[%d]: set one of id or label", i))
+			continue
+		}
+		name := a.name()
+		if apps[strings.ToLower(name)] {
+			problems = append(problems, fmt.Sprintf("app %q is declared twice", name))
+		}
+		apps[strings.ToLower(name)] = true
+		assigned := map[string]bool{}
+		for _, g := range a.Groups {
+			switch {
+			case g.Name == "":
+				problems = append(problems, fmt.Sprintf("app %q: group name is required", name))
+			case assigned[strings.ToLower(g.Name)]:
+				problems = append(problems, fmt.Sprintf("app %q lists group %q twice", name, g.Name))
+			case g.Priority != nil && *g.Priority < 0:
+				problems = append(problems, fmt.Sprintf("app %q: group %q has a negative priority", name, g.Name))
+			}
+			assigned[strings.ToLower(g.Name)] = true
+		}
+	}
+
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
+	}
+	return nil
+}
+
+func (a App) name() string {
+	if a.Label != "" {
+		return a.Label
+	}
+	return a.ID
+}
+
+// Error lists everything wrong with a config, found by Validate or NewPlan
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
@@ -0,0 +1,503 @@
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+// Change types, in the order they are created. Deletes run in the reverse order.
+const (
+	TypeGroup       = "group"
+	TypeGroupMember = "groupMember"
+	TypeRule        = "rule"
+	TypeAppGroup    = "appGroup"
+)
+
+var typeOrder = []string{TypeGroup, TypeGroupMember, TypeRule, TypeAppGroup}
+
+// Change actions
+const (
+	Create = "create"
+	Update = "update"
+	Delete = "delete"
+)
+
+// Options control what a plan changes
+type Options struct {
+	// Prune deletes what the file doesn't declare: extra members of groups that list members, extra
+	// groups of apps that list groups, and groups and rules starting with Config.Prefix. Without a
+	// prefix no group or rule is deleted. Groups a rule assigns users to keep their extra members.
+	Prune bool
+	// DryRun makes Apply return the plan without changing anything
+	DryRun bool
+}
+
+// Field is a value a change sets. Old is empty for creates.
+type Field struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// Change is one call, or for rules a few calls, to OKTA. Name is the group, rule or app label and
+// Member the user login of a membership or the group name of an app assignment.
+type Change struct {
+	Type    string  `json:"type"`
+	Action  string  `json:"action"`
+	Name    string  `json:"name"`
+	Member  string  `json:"member,omitempty"`
+	Fields  []Field `json:"fields,omitempty"`
+	Applied bool    `json:"applied,omitempty"`
+	Error   string  `json:"error,omitempty"`
+
+	// id is the existing group, rule or app and memberID the existing user or group
+	id       string
+	memberID string
+	group    Group
+	rule     Rule
+	// orgRule is the existing rule, updates change it so settings the Config doesn't cover, like
+	// excluded users, are kept
+	orgRule okta.GroupRule
+	// ruleStatus is the status of an existing rule and ruleChanged is true when its expression or
+	// groups change, which needs the rule deactivated
+	ruleStatus  string
+	ruleChanged bool
+	priority    int
+}
+
+// Plan is the list of changes that make the org match a Config, in the order Apply makes them
+type Plan struct {
+	Changes []Change `json:"changes"`
+
+	client *okta.Client
+	// groupIDs maps lower case group names to IDs. Apply adds the groups it creates.
+	groupIDs map[string]string
+}
+
+// Count returns how many changes have the action
+func (p *Plan) Count(action string) int {
+	n := 0
+	for _, change := range p.Changes {
+		if change.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// NewPlan reads the groups, rules and apps of the org and works out the changes. Users that don't
+// exist, unknown apps and rules assigning to unknown groups are returned together in an *Error.
+func NewPlan(client *okta.Client, cfg *Config, opt *Options) (*Plan, error) {
+	if opt == nil {
+		opt = &Options{}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	b := &builder{
+		plan:        &Plan{client: client, groupIDs: map[string]string{}},
+		cfg:         cfg,
+		opt:         opt,
+		orgGroups:   map[string]okta.Group{},
+		groupNames:  map[string]string{},
+		declared:    map[string]bool{},
+		ruleTargets: map[string]bool{},
+		creates:     map[string][]Change{},
+		deletes:     map[string][]Change{},
+	}
+	if err := b.build(); err != nil {
+		return nil, err
+	}
+	if len(b.problems) > 0 {
+		return nil, &Error{Problems: b.problems}
+	}
+
+	b.plan.Changes = []Change{}
+	for _, typ := range typeOrder {
+		b.plan.Changes = append(b.plan.Changes, b.creates[typ]...)
+	}
+	for i := len(typeOrder) - 1; i >= 0; i-- {
+		b.plan.Changes = append(b.plan.Changes, b.deletes[typeOrder[i]]...)
+	}
+	return b.plan, nil
+}
+
+type builder struct {
+	plan *Plan
+	cfg  *Config
+	opt  *Options
+
+	// orgGroups is keyed by lower case name, groupNames maps IDs to names
+	orgGroups   map[string]okta.Group
+	groupNames  map[string]string
+	declared    map[string]bool
+	ruleTargets map[string]bool
+
+	// creates and deletes hold the creates and updates, and the deletes, of each type
+	creates  map[string][]Change
+	deletes  map[string][]Change
+	problems []string
+}
+
+func (b *builder) add(c Change) {
+	if c.Action == Delete {
+		b.deletes[c.Type] = append(b.deletes[c.Type], c)
+	} else {
+		b.creates[c.Type] = append(b.creates[c.Type], c)
+	}
+}
+
+func (b *builder) problem(format string, a ...interface{}) {
+	b.problems = append(b.problems, fmt.Sprintf(format, a...))
+}
+
+// knownGroup reports whether a group name is declared or in the org
+func (b *builder) knownGroup(name string) bool {
+	_, ok := b.orgGroups[strings.ToLower(name)]
+	return ok || b.declared[strings.ToLower(name)]
+}
+
+func (b *builder) build() error {
+	groups, _, err := b.plan.client.Groups.ListWithFilter(&okta.GroupFilterOptions{GetAllPages: true})
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		b.orgGroups[strings.ToLower(g.Profile.Name)] = g
+		b.groupNames[g.ID] = g.Profile.Name
+		b.plan.groupIDs[strings.ToLower(g.Profile.Name)] = g.ID
+	}
+	for _, g := range b.cfg.Groups {
+		b.declared[strings.ToLower(g.Name)] = true
+	}
+	for _, r := range b.cfg.Rules {
+		for _, name := range r.Groups {
+			b.ruleTargets[strings.ToLower(name)] = true
+		}
+	}
+
+	for _, g := range b.cfg.Groups {
+		if err := b.group(g); err != nil {
+			return err
+		}
+	}
+	if err := b.rules(); err != nil {
+		return err
+	}
+	if err := b.apps(); err != nil {
+		return err
+	}
+
+	if b.opt.Prune && b.cfg.Prefix != "" {
+		for _, g := range groups {
+			if g.Type == okta.GroupTypeOKTA && strings.HasPrefix(g.Profile.Name, b.cfg.Prefix) && !b.declared[strings.ToLower(g.Profile.Name)] {
+				b.add(Change{Type: TypeGroup, Action: Delete, Name: g.Profile.Name, id: g.ID})
+			}
+		}
+	}
+	return nil
+}
+
+func (b *builder) group(g Group) error {
+	existing, ok := b.orgGroups[strings.ToLower(g.Name)]
+	switch {
+	case !ok:
+		c := Change{Type: TypeGroup, Action: Create, Name: g.Name, group: g}
+		if g.Description != "" {
+			c.Fields = []Field{{Field: "description", New: g.Description}}
+		}
+		b.add(c)
+	case existing.Type != okta.GroupTypeOKTA:
+		b.problem("group %q is a %v, only %v groups can be managed", g.Name, existing.Type, okta.GroupTypeOKTA)
+		return nil
+	default:
+		var fields []Field
+		if existing.Profile.Name != g.Name {
+			fields = append(fields, Field{Field: "name", Old: existing.Profile.Name, New: g.Name})
+		}
+		if existing.Profile.Description != g.Description {
+			fields = append(fields, Field{Field: "description", Old: existing.Profile.Description, New: g.Description})
+		}
+		if len(fields) > 0 {
+			b.add(Change{Type: TypeGroup, Action: Update, Name: g.Name, Fields: fields, id: existing.ID, group: g})
+		}
+	}
+	if g.Members == nil {
+		return nil
+	}
+
+	var current []okta.User
+	if ok {
+		users, _, err := b.plan.client.Groups.GetUsers(existing.ID, &okta.GroupUserFilterOptions{GetAllPages: true})
+		if err != nil {
+			return err
+		}
+		current = users
+	}
+	find := func(member string) *okta.User {
+		for i, u := range current {
+			if u.ID == member || strings.EqualFold(u.Profile.Login, member) {
+				return &current[i]
+			}
+		}
+		return nil
+	}
+
+	desired := map[string]bool{}
+	for _, member := range g.Members {
+		user := find(member)
+		if user == nil {
+			u, resp, err := b.plan.client.Users.GetByID(member)
+			if err != nil {
+				if resp != nil && resp.StatusCode == http.StatusNotFound {
+					b.problem("group %q: user %q not found", g.Name, member)
+					continue
+				}
+				return err
+			}
+			if !desired[u.ID] {
+				b.add(Change{Type: TypeGroupMember, Action: Create, Name: g.Name, Member: u.Profile.Login, memberID: u.ID})
+			}
+			user = u
+		}
+		desired[user.ID] = true
+	}
+	if b.opt.Prune && !b.ruleTargets[strings.ToLower(g.Name)] {
+		for _, u := range current {
+			if !desired[u.ID] {
+				b.add(Change{Type: TypeGroupMember, Action: Delete, Name: g.Name, Member: u.Profile.Login, memberID: u.ID})
+			}
+		}
+	}
+	return nil
+}
+
+func (b *builder) rules() error {
+	rules, _, err := b.plan.client.GroupRules.ListWithFilter(&okta.GroupRuleFilterOptions{GetAllPages: true})
+	if err != nil {
+		return err
+	}
+	existing := map[string]okta.GroupRule{}
+	for _, r := range rules {
+		existing[strings.ToLower(r.Name)] = r
+	}
+
+	for _, r := range b.cfg.Rules {
+		for _, name := range r.Groups {
+			if !b.knownGroup(name) {
+				b.problem("rule %q: group %q is neither declared nor in the org", r.Name, name)
+			}
+		}
+		status := okta.GroupRuleStatusInactive
+		if r.IsActive() {
+			status = okta.GroupRuleStatusActive
+		}
+
+		current, ok := existing[strings.ToLower(r.Name)]
+		if !ok {
+			b.add(Change{Type: TypeRule, Action: Create, Name: r.Name, rule: r, Fields: []Field{
+				{Field: "expression", New: r.Expression},
+				{Field: "groups", New: strings.Join(r.Groups, ", ")},
+				{Field: "status", New: status},
+			}})
+			continue
+		}
+
+		c := Change{Type: TypeRule, Action: Update, Name: r.Name, rule: r, orgRule: current, id: current.ID, ruleStatus: current.Status}
+		if current.Expression() != r.Expression {
+			c.Fields = append(c.Fields, Field{Field: "expression", Old: current.Expression(), New: r.Expression})
+			c.ruleChanged = true
+		}
+		var currentGroups []string
+		for _, id := range current.GroupIDs() {
+			if name, ok := b.groupNames[id]; ok {
+				currentGroups = append(currentGroups, name)
+			} else {
+				currentGroups = append(currentGroups, id)
+			}
+		}
+		if !sameNames(currentGroups, r.Groups) {
+			c.Fields = append(c.Fields, Field{Field: "groups", Old: strings.Join(currentGroups, ", "), New: strings.Join(r.Groups, ", ")})
+			c.ruleChanged = true
+		}
+		if current.Name != r.Name {
+			c.Fields = append(c.Fields, Field{Field: "name", Old: current.Name, New: r.Name})
+			c.ruleChanged = true
+		}
+		if current.Status != status {
+			c.Fields = append(c.Fields, Field{Field: "status", Old: current.Status, New: status})
+		}
+		if len(c.Fields) > 0 {
+			b.add(c)
+		}
+	}
+
+	if b.opt.Prune && b.cfg.Prefix != "" {
+		declared := map[string]bool{}
+		for _, r := range b.cfg.Rules {
+			declared[strings.ToLower(r.Name)] = true
+		}
+		for _, r := range rules {
+			if strings.HasPrefix(r.Name, b.cfg.Prefix) && !declared[strings.ToLower(r.Name)] {
+				b.add(Change{Type: TypeRule, Action: Delete, Name: r.Name, id: r.ID, ruleStatus: r.Status})
+			}
+		}
+	}
+	return nil
+}
+
+// sameNames compares two lists of group names ignoring order and case
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	lower := func(names []string) []string {
+		l := make([]string, len(names))
+		for i, name := range names {
+			l[i] = strings.ToLower(name)
+		}
+		sort.Strings(l)
+		return l
+	}
+	la, lb := lower(a), lower(b)
+	for i := range la {
+		if la[i] != lb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *builder) apps() error {
+	if len(b.cfg.Apps) == 0 {
+		return nil
+	}
+	apps, _, err := b.plan.client.Apps.ListWithFilter(&okta.AppFilterOptions{GetAllPages: true})
+	if err != nil {
+		return err
+	}
+
+	for _, a := range b.cfg.Apps {
+		var matches []okta.App
+		for _, app := range apps {
+			if (a.ID != "" && app.ID == a.ID) || (a.ID == "" && strings.EqualFold(app.Label, a.Label)) {
+				matches = append(matches, app)
+			}
+		}
+		if len(matches) != 1 {
+			if len(matches) == 0 {
+				b.problem("app %q not found", a.name())
+			} else {
+				b.problem("%d apps are labelled %q, use the id", len(matches), a.Label)
+			}
+			continue
+		}
+		app := matches[0]
+		if a.Groups == nil {
+			continue
+		}
+
+		assigned, _, err := b.plan.client.Apps.GetGroups(app.ID)
+		if err != nil {
+			return err
+		}
+		priorities := map[string]int{}
+		for _, g := range assigned {
+			priorities[g.ID] = g.Priority
+		}
+
+		next := len(assigned)
+		desired := map[string]bool{}
+		var changes []Change
+		for _, g := range a.Groups {
+			if !b.knownGroup(g.Name) {
+				b.problem("app %q: group %q is neither declared nor in the org", app.Label, g.Name)
+				continue
+			}
+			id := b.plan.groupIDs[strings.ToLower(g.Name)]
+			desired[id] = id != ""
+			if priority, ok := priorities[id]; ok && id != "" {
+				if g.Priority != nil && *g.Priority != priority {
+					changes = append(changes, Change{Type: TypeAppGroup, Action: Update, Name: app.Label, Member: g.Name, id: app.ID,
+						priority: *g.Priority, Fields: []Field{{Field: "priority", Old: strconv.Itoa(priority), New: strconv.Itoa(*g.Priority)}}})
+				}
+				continue
+			}
+			priority := next
+			if g.Priority != nil {
+				priority = *g.Priority
+			} else {
+				next++
+			}
+			changes = append(changes, Change{Type: TypeAppGroup, Action: Create, Name: app.Label, Member: g.Name, id: app.ID,
+				priority: priority, Fields: []Field{{Field: "priority", New: strconv.Itoa(priority)}}})
+		}
+		// OKTA shifts the other groups down when a priority is taken, so set the lowest first
+		sort.SliceStable(changes, func(i, j int) bool { return changes[i].priority < changes[j].priority })
+		for _, c := range changes {
+			b.add(c)
+		}
+
+		if b.opt.Prune {
+			for _, g := range assigned {
+				if !desired[g.ID] {
+					name := b.groupNames[g.ID]
+					if name == "" {
+						name = g.ID
+					}
+					b.add(Change{Type: TypeAppGroup, Action: Delete, Name: app.Label, Member: name, id: app.ID, memberID: g.ID})
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the plan for other tools
+func (p *Plan) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p)
+}
+
+// WriteText writes the plan for people: one line per change, "+" create, "~" update and "-"
+// delete, with the fields below and the error of a failed change.
+func (p *Plan) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Plan: %d to create, %d to update, %d to delete\n", p.Count(Create), p.Count(Update), p.Count(Delete))
+	if err != nil {
+		return err
+	}
+	marks := map[string]string{Create: "+", Update: "~", Delete: "-"}
+	for _, change := range p.Changes {
+		line := fmt.Sprintf("%v %v %v", marks[change.Action], change.Type, change.Name)
+		if change.Member != "" {
+			line += ": " + change.Member
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		for _, field := range change.Fields {
+			if change.Action == Create {
+				_, err = fmt.Fprintf(w, "    %v: %q\n", field.Field, field.New)
+			} else {
+				_, err = fmt.Fprintf(w, "    %v: %q -> %q\n", field.Field, field.Old, field.New)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if change.Error != "" {
+			if _, err := fmt.Fprintf(w, "    failed: %v\n", change.Error); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -9,9 +9,52 @@ import (
 // AppsService is a service to retreives applications from OKTA.
 type AppsService service
 
+// AppsAPI is the method set of AppsService.
+type AppsAPI interface {
+	ListWithFilter(opt *AppFilterOptions) ([]App, *Response, error)
+	GetByID(appID string) (*App, *Response, error)
+	GetUsers(appID string, opt *AppFilterOptions) ([]AppUser, *Response, error)
+	GetGroups(appID string) ([]AppGroups, *Response, error)
+	GetUser(appID string, userID string) (AppUser, *Response, error)
+	AssignGroup(appID string, groupID string, priority int) (*AppGroups, *Response, error)
+	UnassignGroup(appID string, groupID string) (*Response, error)
+
+	// Signing keys and certificates (appkeys.go)
+	ListKeys(appID string) ([]AppKey, *Response, error)
+	GetKey(appID string, keyID string) (*AppKey, *Response, error)
+	GenerateKey(appID string, validityYears int) (*AppKey, *Response, error)
+	CloneKey(appID string, keyID string, targetAppID string) (*AppKey, *Response, error)
+	ListCSRs(appID string) ([]AppCSR, *Response, error)
+	GetCSR(appID string, csrID string) (*AppCSR, *Response, error)
+	GenerateCSR(appID string, metadata CSRMetadata) (*AppCSR, *Response, error)
+	RevokeCSR(appID string, csrID string) (*Response, error)
+	PublishCSR(appID string, csrID string, cert []byte) (*AppKey, *Response, error)
+
+	// SAML metadata (saml.go)
+	GetSAMLMetadata(appID string, keyID string) (*SAMLMetadata, *Response, error)
+
+	// OAuth client secrets and JWKS (appsecrets.go)
+	ListClientSecrets(appID string) ([]ClientSecret, *Response, error)
+	GetClientSecret(appID string, secretID string) (*ClientSecret, *Response, error)
+	GenerateClientSecret(appID string) (*ClientSecret, *Response, error)
+	ActivateClientSecret(appID string, secretID string) (*ClientSecret, *Response, error)
+	DeactivateClientSecret(appID string, secretID string) (*ClientSecret, *Response, error)
+	DeleteClientSecret(appID string, secretID string) (*Response, error)
+	ListJWKs(appID string) ([]ClientJWK, *Response, error)
+	GetJWK(appID string, keyID string) (*ClientJWK, *Response, error)
+	AddJWK(appID string, key ClientJWK) (*ClientJWK, *Response, error)
+	ActivateJWK(appID string, keyID string) (*ClientJWK, *Response, error)
+	DeactivateJWK(appID string, keyID string) (*ClientJWK, *Response, error)
+	DeleteJWK(appID string, keyID string) (*Response, error)
+}
+
+var _ AppsAPI = (*AppsService)(nil)
+
 // AppFilterOptions is used to generate a "Filter" to search for different Apps
 // The values here coorelate to API Search paramgters on the group API
 type AppFilterOptions struct {
+	// FilterString is an OKTA filter expression for ListWithFilter, e.g. `status eq "ACTIVE"`
+	FilterString  string   `url:"filter,omitempty"`
 	NextURL       *url.URL `url:"-"`
 	GetAllPages   bool     `url:"-"`
 	NumberOfPages int      `url:"-"`
@@ -49,6 +92,7 @@ type App struct {
 			Type     string `json:"type"`
 		} `json:"userNameTemplate"`
 		Signing struct {
+			Kid string `json:"kid,omitempty"`
 		} `json:"signing"`
 	} `json:"credentials"`
 	Settings struct {
@@ -119,6 +163,65 @@ func (a App) String() string {
 	return fmt.Sprintf("App:(ID: {%v} - Name: {%v})\n", a.ID, a.Name)
 }
 
+// ListWithFilter - Method to list apps. Pass in an AppFilterOptions to filter and page the results
+func (a *AppsService) ListWithFilter(opt *AppFilterOptions) ([]App, *Response, error) {
+
+	var u string
+	var err error
+
+	pagesRetreived := 0
+	if opt.NextURL != nil {
+		u = opt.NextURL.String()
+	} else {
+		if opt.Limit == 0 {
+			opt.Limit = defaultLimit
+		}
+		u, err = addOptions("apps", opt)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	req, err := a.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var apps []App
+	resp, err := a.client.Do(req, &apps)
+	if err != nil {
+		return nil, resp, err
+	}
+	pagesRetreived++
+
+	if (opt.NumberOfPages > 0 && pagesRetreived < opt.NumberOfPages) || opt.GetAllPages {
+
+		for {
+
+			if pagesRetreived == opt.NumberOfPages {
+				break
+			}
+			if resp.NextURL != nil {
+				var appPage []App
+				pageOption := new(AppFilterOptions)
+				pageOption.NextURL = resp.NextURL
+				pageOption.NumberOfPages = 1
+				pageOption.Limit = opt.Limit
+
+				appPage, resp, err = a.ListWithFilter(pageOption)
+				if err != nil {
+					return apps, resp, err
+				}
+				apps = append(apps, appPage...)
+				pagesRetreived++
+
+			} else {
+				break
+			}
+		}
+	}
+	return apps, resp, err
+}
+
 // GetByID gets a group from OKTA by the Gropu ID. An error is returned if the group is not found
 func (a *AppsService) GetByID(appID string) (*App, *Response, error) {
 
@@ -272,24 +375,19 @@ func (a *AppsService) GetGroups(appID string) (appGroups []AppGroups, resp *Resp
 		return nil, resp, err
 	}
 
-	for {
+	for resp.NextURL != nil {
 
-		if resp.NextURL != nil {
+		var appGroupPage []AppGroups
 
-			var appGroupPage []AppGroups
-
-			appGroupPage, resp, err = a.GetGroups(appID)
-
-			if err != nil {
-				return appGroups, resp, err
-			} else {
-				appGroups = append(appGroups, appGroupPage...)
-
-			}
-		} else {
-			break
+		req, err = a.client.NewRequest("GET", resp.NextURL.String(), nil)
+		if err != nil {
+			return appGroups, resp, err
 		}
-
+		resp, err = a.client.Do(req, &appGroupPage)
+		if err != nil {
+			return appGroups, resp, err
+		}
+		appGroups = append(appGroups, appGroupPage...)
 	}
 
 	return appGroups, resp, err
@@ -313,3 +411,41 @@ func (a *AppsService) GetUser(appID string, userID string) (appUser AppUser, res
 	}
 	return appUser, resp, nil
 }
+
+// AssignGroup assigns a group to the application, or changes the priority of an assigned group.
+// Priority orders the groups when their app profiles conflict, 0 is the highest.
+func (a *AppsService) AssignGroup(appID string, groupID string, priority int) (*AppGroups, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/groups/%v", appID, groupID)
+
+	body := struct {
+		Priority int `json:"priority"`
+	}{priority}
+	req, err := a.client.NewRequest("PUT", u, body)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	appGroup := new(AppGroups)
+	resp, err := a.client.Do(req, appGroup)
+
+	if err != nil {
+		return nil, resp, err
+	}
+	return appGroup, resp, nil
+}
+
+// UnassignGroup removes a group assignment from the application. Users assigned only through the
+// group lose the app.
+func (a *AppsService) UnassignGroup(appID string, groupID string) (*Response, error) {
+
+	u := fmt.Sprintf("apps/%v/groups/%v", appID, groupID)
+
+	req, err := a.client.NewRequest("DELETE", u, nil)
+
+	if err != nil {
+		return nil, err
+	}
+	return a.client.Do(req, nil)
+}
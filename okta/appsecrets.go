@@ -0,0 +1,346 @@
+package okta
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	// CredentialStatusActive is the status of a client secret or JWK OKTA will accept
+	CredentialStatusActive = "ACTIVE"
+	// CredentialStatusInactive is the status of a client secret or JWK OKTA will reject. Only inactive credentials can be deleted
+	CredentialStatusInactive = "INACTIVE"
+)
+
+// ClientSecret is the model for an OAuth 2.0 client secret on an OIDC or service app.
+// OKTA only returns the ClientSecret value in full when the secret is created.
+// https://developer.okta.com/docs/reference/api/apps/#application-client-secret-management
+type ClientSecret struct {
+	ID           string    `json:"id"`
+	Status       string    `json:"status"`
+	ClientSecret string    `json:"client_secret"`
+	SecretHash   string    `json:"secret_hash"`
+	Created      time.Time `json:"created"`
+	LastUpdated  time.Time `json:"lastUpdated"`
+}
+
+func (s ClientSecret) String() string {
+	return fmt.Sprintf("ClientSecret:(ID: {%v} - Status: {%v})\n", s.ID, s.Status)
+}
+
+// ClientJWK is a public JSON Web Key registered on a client that authenticates with private_key_jwt
+// https://developer.okta.com/docs/reference/api/apps/#application-client-jwk-management
+type ClientJWK struct {
+	ID          string     `json:"id,omitempty"`
+	Kid         string     `json:"kid,omitempty"`
+	Kty         string     `json:"kty"`
+	Alg         string     `json:"alg,omitempty"`
+	Use         string     `json:"use,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	E           string     `json:"e,omitempty"`
+	N           string     `json:"n,omitempty"`
+	Crv         string     `json:"crv,omitempty"`
+	X           string     `json:"x,omitempty"`
+	Y           string     `json:"y,omitempty"`
+	Created     *time.Time `json:"created,omitempty"`
+	LastUpdated *time.Time `json:"lastUpdated,omitempty"`
+}
+
+func (k ClientJWK) String() string {
+	return fmt.Sprintf("ClientJWK:(ID: {%v} - Kid: {%v} - Status: {%v})\n", k.ID, k.Kid, k.Status)
+}
+
+// NewClientJWK builds a public JWK from an *rsa.PublicKey or *ecdsa.PublicKey (P-256, P-384, P-521)
+// so it can be registered with Apps.AddJWK
+func NewClientJWK(pub crypto.PublicKey, kid string) (*ClientJWK, error) {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return &ClientJWK{
+			Kid: kid,
+			Kty: "RSA",
+			Use: KeyUseSignature,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		var crv string
+		switch key.Curve {
+		case elliptic.P256():
+			crv = "P-256"
+		case elliptic.P384():
+			crv = "P-384"
+		case elliptic.P521():
+			crv = "P-521"
+		default:
+			return nil, errors.New("unsupported elliptic curve")
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return &ClientJWK{
+			Kid: kid,
+			Kty: "EC",
+			Use: KeyUseSignature,
+			Crv: crv,
+			X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported public key type %T", pub)
+}
+
+// ListClientSecrets returns the client secrets for an OAuth app
+func (a *AppsService) ListClientSecrets(appID string) ([]ClientSecret, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/secrets", appID)
+	req, err := a.client.NewRequest("GET", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var secrets []ClientSecret
+	resp, err := a.client.Do(req, &secrets)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return secrets, resp, err
+}
+
+// GetClientSecret returns one client secret for an OAuth app
+func (a *AppsService) GetClientSecret(appID string, secretID string) (*ClientSecret, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/secrets/%v", appID, secretID)
+	req, err := a.client.NewRequest("GET", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secret := new(ClientSecret)
+	resp, err := a.client.Do(req, secret)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return secret, resp, err
+}
+
+// GenerateClientSecret has OKTA generate a new ACTIVE client secret. OKTA allows two secrets per app
+// so an old secret may need to be deactivated and deleted first.
+// The returned ClientSecret.ClientSecret is the only time the secret value is available.
+func (a *AppsService) GenerateClientSecret(appID string) (*ClientSecret, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/secrets", appID)
+	req, err := a.client.NewRequest("POST", u, struct{}{})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secret := new(ClientSecret)
+	resp, err := a.client.Do(req, secret)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return secret, resp, err
+}
+
+// ActivateClientSecret - Activates a client secret
+func (a *AppsService) ActivateClientSecret(appID string, secretID string) (*ClientSecret, *Response, error) {
+	return a.clientSecretLifecycle(appID, secretID, "activate")
+}
+
+// DeactivateClientSecret - Deactivates a client secret. OKTA will not deactivate the only active secret
+func (a *AppsService) DeactivateClientSecret(appID string, secretID string) (*ClientSecret, *Response, error) {
+	return a.clientSecretLifecycle(appID, secretID, "deactivate")
+}
+
+func (a *AppsService) clientSecretLifecycle(appID string, secretID string, action string) (*ClientSecret, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/secrets/%v/lifecycle/%v", appID, secretID, action)
+	req, err := a.client.NewRequest("POST", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secret := new(ClientSecret)
+	resp, err := a.client.Do(req, secret)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return secret, resp, err
+}
+
+// DeleteClientSecret - Deletes an INACTIVE client secret
+func (a *AppsService) DeleteClientSecret(appID string, secretID string) (*Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/secrets/%v", appID, secretID)
+	req, err := a.client.NewRequest("DELETE", u, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.client.Do(req, nil)
+
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, err
+}
+
+// RetireClientSecrets deactivates and deletes every client secret except keepSecretID.
+// A rotation is GenerateClientSecret, roll the new secret out to your services, then RetireClientSecrets with the new ID.
+func RetireClientSecrets(apps AppsAPI, appID string, keepSecretID string) (*Response, error) {
+
+	if keepSecretID == "" {
+		return nil, errors.New("keepSecretID parameter is required for RetireClientSecrets")
+	}
+
+	secrets, resp, err := apps.ListClientSecrets(appID)
+
+	if err != nil {
+		return resp, err
+	}
+
+	for _, secret := range secrets {
+		if secret.ID == keepSecretID {
+			continue
+		}
+		if secret.Status == CredentialStatusActive {
+			_, resp, err = apps.DeactivateClientSecret(appID, secret.ID)
+			if err != nil {
+				return resp, err
+			}
+		}
+		resp, err = apps.DeleteClientSecret(appID, secret.ID)
+		if err != nil {
+			return resp, err
+		}
+	}
+
+	return resp, err
+}
+
+// ListJWKs returns the public keys registered on a private_key_jwt client
+func (a *AppsService) ListJWKs(appID string) ([]ClientJWK, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/jwks", appID)
+	req, err := a.client.NewRequest("GET", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var keys []ClientJWK
+	resp, err := a.client.Do(req, &keys)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return keys, resp, err
+}
+
+// GetJWK returns one public key registered on a private_key_jwt client
+func (a *AppsService) GetJWK(appID string, keyID string) (*ClientJWK, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/jwks/%v", appID, keyID)
+	req, err := a.client.NewRequest("GET", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := new(ClientJWK)
+	resp, err := a.client.Do(req, key)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return key, resp, err
+}
+
+// AddJWK registers a public key on a private_key_jwt client. Use NewClientJWK to build one from a crypto.PublicKey
+func (a *AppsService) AddJWK(appID string, key ClientJWK) (*ClientJWK, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/jwks", appID)
+	req, err := a.client.NewRequest("POST", u, key)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	added := new(ClientJWK)
+	resp, err := a.client.Do(req, added)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return added, resp, err
+}
+
+// ActivateJWK - Activates a client public key
+func (a *AppsService) ActivateJWK(appID string, keyID string) (*ClientJWK, *Response, error) {
+	return a.jwkLifecycle(appID, keyID, "activate")
+}
+
+// DeactivateJWK - Deactivates a client public key
+func (a *AppsService) DeactivateJWK(appID string, keyID string) (*ClientJWK, *Response, error) {
+	return a.jwkLifecycle(appID, keyID, "deactivate")
+}
+
+func (a *AppsService) jwkLifecycle(appID string, keyID string, action string) (*ClientJWK, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/jwks/%v/lifecycle/%v", appID, keyID, action)
+	req, err := a.client.NewRequest("POST", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := new(ClientJWK)
+	resp, err := a.client.Do(req, key)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return key, resp, err
+}
+
+// DeleteJWK - Deletes an INACTIVE client public key
+func (a *AppsService) DeleteJWK(appID string, keyID string) (*Response, error) {
+
+	u := fmt.Sprintf("apps/%v/credentials/jwks/%v", appID, keyID)
+	req, err := a.client.NewRequest("DELETE", u, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.client.Do(req, nil)
+
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, err
+}
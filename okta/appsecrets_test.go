@@ -0,0 +1,65 @@
+package okta
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestAppRetireClientSecrets(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var calls []string
+
+	mux.HandleFunc("/apps/0oa1/credentials/secrets", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testAuthHeader(t, r)
+		fmt.Fprint(w, `[{"id":"old","status":"ACTIVE"},{"id":"new","status":"ACTIVE"},{"id":"stale","status":"INACTIVE"}]`)
+	})
+	mux.HandleFunc("/apps/0oa1/credentials/secrets/", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		fmt.Fprint(w, `{"id":"old","status":"INACTIVE"}`)
+	})
+
+	_, err := RetireClientSecrets(client.Apps, "0oa1", "new")
+	if err != nil {
+		t.Fatalf("RetireClientSecrets returned error: %v", err)
+	}
+
+	want := []string{
+		"POST /apps/0oa1/credentials/secrets/old/lifecycle/deactivate",
+		"DELETE /apps/0oa1/credentials/secrets/old",
+		"DELETE /apps/0oa1/credentials/secrets/stale",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("RetireClientSecrets made calls %v, want %v", calls, want)
+	}
+}
+
+func TestNewClientJWKFromECKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+
+	jwk, err := NewClientJWK(&key.PublicKey, "kid1")
+	if err != nil {
+		t.Fatalf("NewClientJWK returned error: %v", err)
+	}
+	if jwk.Kty != "EC" || jwk.Crv != "P-256" || jwk.Kid != "kid1" {
+		t.Errorf("NewClientJWK returned %+v", jwk)
+	}
+	// P-256 coordinates are 32 bytes which is 43 base64url characters without padding
+	if len(jwk.X) != 43 || len(jwk.Y) != 43 {
+		t.Errorf("Unexpected coordinate lengths x=%v y=%v", len(jwk.X), len(jwk.Y))
+	}
+}
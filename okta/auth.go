@@ -0,0 +1,485 @@
+package okta
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	oauthTokenPath          = "oauth2/v1/token"
+	clientAssertionType     = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	clientAssertionLifetime = 5 * time.Minute
+	mediaTypeForm           = "application/x-www-form-urlencoded"
+
+	// maximum time before expiry a cached access token is refreshed
+	tokenExpirySkew = 30 * time.Second
+)
+
+// Authenticator adds credentials to every request built by Client.NewRequest
+type Authenticator interface {
+	Authorize(req *http.Request) error
+}
+
+// SSWSAuthenticator authenticates with an OKTA API token ("Authorization: SSWS <token>").
+// NewClient uses it for the apiToken it is given.
+type SSWSAuthenticator struct {
+	Token string
+}
+
+// Authorize sets the SSWS Authorization header
+func (a *SSWSAuthenticator) Authorize(req *http.Request) error {
+	if a.Token != "" {
+		req.Header.Set(headerAuthorization, fmt.Sprintf(headerAuthorizationFormat, a.Token))
+	}
+	return nil
+}
+
+// NewClientWithAuthenticator creates a client based on the full base URL that authenticates
+// every request with authenticator instead of an API token. An *OAuthAuthenticator without an
+// HTTPClient gets httpClient, so token requests go through the same transport as API calls.
+func NewClientWithAuthenticator(httpClient *http.Client, baseURL *url.URL, authenticator Authenticator) *Client {
+	if oauth, ok := authenticator.(*OAuthAuthenticator); ok && oauth.HTTPClient == nil && httpClient != nil {
+		oauth.HTTPClient = httpClient
+	}
+	c := NewClientWithBaseURL(httpClient, baseURL, "")
+	c.Authenticator = authenticator
+	return c
+}
+
+// OAuthError is returned when the authorization server rejects a token request
+type OAuthError struct {
+	StatusCode       int
+	ErrorCode        string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (e *OAuthError) Error() string {
+	return fmt.Sprintf("OAuth token request failed - HTTP Status Code: %d, Error: %v, Description: %v", e.StatusCode, e.ErrorCode, e.ErrorDescription)
+}
+
+// OAuthAuthenticator gets scoped access tokens from the org authorization server with the
+// client credentials grant, authenticating the service app with a private_key_jwt client assertion.
+// Tokens are cached and refreshed shortly before they expire.
+// https://developer.okta.com/docs/guides/implement-oauth-for-okta-serviceapp/main/
+type OAuthAuthenticator struct {
+	// ClientID of the API service app
+	ClientID string
+	// Scopes to request, e.g. okta.users.read okta.groups.manage
+	Scopes []string
+	// TokenURL is the org authorization server token endpoint, https://<org>/oauth2/v1/token
+	TokenURL string
+	// Key signs the client assertion. It must be an *rsa.PrivateKey or *ecdsa.PrivateKey
+	Key crypto.Signer
+	// KeyID is sent as the "kid" of the client assertion so OKTA can pick the right public key
+	KeyID string
+	// HTTPClient is used for token requests. Defaults to the client's when passed to
+	// NewClientWithAuthenticator, otherwise to http.DefaultClient.
+	HTTPClient *http.Client
+	// DPoP binds access tokens to a key pair when set. See NewDPoPKey.
+	DPoP *DPoPKey
+
+	mu        sync.Mutex
+	token     string
+	tokenType string
+	expiry    time.Time
+}
+
+// NewOAuthAuthenticator returns an OAuthAuthenticator for the org at orgURL (e.g. https://example.okta.com)
+func NewOAuthAuthenticator(orgURL string, clientID string, scopes []string, key crypto.Signer, keyID string) (*OAuthAuthenticator, error) {
+	base, err := url.Parse(strings.TrimSuffix(orgURL, "/") + "/")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := jwsAlgorithm(key); err != nil {
+		return nil, err
+	}
+	return &OAuthAuthenticator{
+		ClientID: clientID,
+		Scopes:   scopes,
+		TokenURL: base.ResolveReference(&url.URL{Path: oauthTokenPath}).String(),
+		Key:      key,
+		KeyID:    keyID,
+	}, nil
+}
+
+// Authorize sets the access token, requesting a new one if there is no valid cached token.
+// With DPoP enabled a proof for the request is added as well.
+func (a *OAuthAuthenticator) Authorize(req *http.Request) error {
+	token, tokenType, err := a.Token()
+	if err != nil {
+		return err
+	}
+	req.Header.Set(headerAuthorization, tokenType+" "+token)
+	if a.DPoP != nil {
+		proof, err := a.DPoP.Proof(req.Method, req.URL, token)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(headerDPoP, proof)
+	}
+	return nil
+}
+
+// Token returns the cached access token and its type, requesting a new one when needed
+func (a *OAuthAuthenticator) Token() (token string, tokenType string, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Now().Before(a.expiry) {
+		return a.token, a.tokenType, nil
+	}
+	if err := a.refresh(); err != nil {
+		return "", "", err
+	}
+	return a.token, a.tokenType, nil
+}
+
+// Invalidate drops the cached access token so the next request gets a new one.
+// Client.Do calls it when the API answers 401.
+func (a *OAuthAuthenticator) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// refresh requests a new token. a.mu must be held.
+func (a *OAuthAuthenticator) refresh() error {
+	tr, err := a.requestToken()
+	// A DPoP nonce challenge is answered once with a proof carrying the nonce the server sent
+	if oauthErr, ok := err.(*OAuthError); ok && oauthErr.ErrorCode == dpopNonceError && a.DPoP != nil {
+		tr, err = a.requestToken()
+	}
+	if err != nil {
+		return err
+	}
+
+	if tr.AccessToken == "" {
+		return errors.New("OAuth token response did not include an access_token")
+	}
+	lifetime := time.Duration(tr.ExpiresIn) * time.Second
+	skew := lifetime / 10
+	if skew > tokenExpirySkew {
+		skew = tokenExpirySkew
+	}
+	a.token = tr.AccessToken
+	a.tokenType = tr.TokenType
+	if a.tokenType == "" {
+		a.tokenType = "Bearer"
+	}
+	a.expiry = time.Now().Add(lifetime - skew)
+	return nil
+}
+
+// requestToken makes one token request. A new client assertion is signed every time because the
+// authorization server rejects a reused jti.
+func (a *OAuthAuthenticator) requestToken() (*tokenResponse, error) {
+	assertion, err := a.clientAssertion()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("scope", strings.Join(a.Scopes, " "))
+	form.Set("client_assertion_type", clientAssertionType)
+	form.Set("client_assertion", assertion)
+
+	httpClient := a.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	req, err := http.NewRequest("POST", a.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mediaTypeForm)
+	req.Header.Set("Accept", mediaTypeJSON)
+	if a.DPoP != nil {
+		proof, err := a.DPoP.Proof(req.Method, req.URL, "")
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(headerDPoP, proof)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if a.DPoP != nil {
+		a.DPoP.saveNonce(req.URL, resp)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		oauthErr := &OAuthError{StatusCode: resp.StatusCode}
+		json.Unmarshal(data, oauthErr)
+		return nil, oauthErr
+	}
+
+	tr := new(tokenResponse)
+	if err := json.Unmarshal(data, tr); err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// clientAssertion builds the signed JWT that authenticates the client to the token endpoint
+func (a *OAuthAuthenticator) clientAssertion() (string, error) {
+	now := time.Now()
+	jti, err := randomID()
+	if err != nil {
+		return "", err
+	}
+	header := map[string]interface{}{"typ": "JWT"}
+	if a.KeyID != "" {
+		header["kid"] = a.KeyID
+	}
+	claims := map[string]interface{}{
+		"iss": a.ClientID,
+		"sub": a.ClientID,
+		"aud": a.TokenURL,
+		"iat": now.Unix(),
+		"exp": now.Add(clientAssertionLifetime).Unix(),
+		"jti": jti,
+	}
+	return signJWT(a.Key, header, claims)
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// jwsAlgorithm returns the JWS alg for a signing key
+func jwsAlgorithm(key crypto.Signer) (string, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return "RS256", nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return "ES256", nil
+		case elliptic.P384():
+			return "ES384", nil
+		case elliptic.P521():
+			return "ES512", nil
+		}
+		return "", errors.New("unsupported elliptic curve")
+	}
+	return "", fmt.Errorf("unsupported private key type %T", key)
+}
+
+// signJWT signs a compact JWS. The alg header is set from the key type.
+func signJWT(key crypto.Signer, header map[string]interface{}, claims map[string]interface{}) (string, error) {
+	alg, err := jwsAlgorithm(key)
+	if err != nil {
+		return "", err
+	}
+	header["alg"] = alg
+
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	var digest []byte
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		sum := sha256.Sum256([]byte(signingInput))
+		digest, hash = sum[:], crypto.SHA256
+	case "ES384":
+		sum := sha512.Sum384([]byte(signingInput))
+		digest, hash = sum[:], crypto.SHA384
+	case "ES512":
+		sum := sha512.Sum512([]byte(signingInput))
+		digest, hash = sum[:], crypto.SHA512
+	}
+
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, hash, digest)
+	case *ecdsa.PrivateKey:
+		// JWS wants the raw r || s values, not the ASN.1 encoding crypto.Signer returns
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest)
+		if err == nil {
+			size := (k.Curve.Params().BitSize + 7) / 8
+			sig = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// ParsePrivateKeyPEM parses an RSA or EC private key in PKCS#1, SEC 1 or PKCS#8 PEM form
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	// each branch checks err itself, returning a typed nil key would make a non-nil crypto.Signer
+	var signer crypto.Signer
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer = key
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer = key
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if signer, ok = key.(crypto.Signer); !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %v", block.Type)
+	}
+	if _, err := jwsAlgorithm(signer); err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+type privateJWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	D   string `json:"d"`
+	P   string `json:"p"`
+	Q   string `json:"q"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParsePrivateKeyJWK parses an RSA or EC private key in JWK form, as downloaded from the OKTA admin console.
+// The key ID is returned so it can be used as OAuthAuthenticator.KeyID.
+func ParsePrivateKeyJWK(data []byte) (crypto.Signer, string, error) {
+	var jwk privateJWK
+	if err := json.Unmarshal(data, &jwk); err != nil {
+		return nil, "", err
+	}
+	decode := func(name string, v string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(v, "="))
+		if err != nil || len(b) == 0 {
+			return nil, fmt.Errorf("JWK parameter %v is missing or invalid", name)
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decode("n", jwk.N)
+		if err != nil {
+			return nil, "", err
+		}
+		e, err := decode("e", jwk.E)
+		if err != nil {
+			return nil, "", err
+		}
+		d, err := decode("d", jwk.D)
+		if err != nil {
+			return nil, "", err
+		}
+		p, err := decode("p", jwk.P)
+		if err != nil {
+			return nil, "", err
+		}
+		q, err := decode("q", jwk.Q)
+		if err != nil {
+			return nil, "", err
+		}
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: int(e.Int64())},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		if err := key.Validate(); err != nil {
+			return nil, "", err
+		}
+		key.Precompute()
+		return key, jwk.Kid, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, "", fmt.Errorf("unsupported JWK curve %v", jwk.Crv)
+		}
+		x, err := decode("x", jwk.X)
+		if err != nil {
+			return nil, "", err
+		}
+		y, err := decode("y", jwk.Y)
+		if err != nil {
+			return nil, "", err
+		}
+		d, err := decode("d", jwk.D)
+		if err != nil {
+			return nil, "", err
+		}
+		key := &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y}, D: d}
+		return key, jwk.Kid, nil
+	}
+	return nil, "", fmt.Errorf("unsupported JWK key type %v", jwk.Kty)
+}
@@ -0,0 +1,232 @@
+package okta
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// verifyJWT checks a compact JWS against pub and returns its header and claims
+func verifyJWT(t *testing.T, jwt string, pub crypto.PublicKey) (map[string]interface{}, map[string]interface{}) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("JWT should have 3 parts but has %v", len(parts))
+	}
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig); err != nil {
+			t.Fatalf("JWT signature is invalid: %v", err)
+		}
+	case *ecdsa.PublicKey:
+		r := new(big.Int).SetBytes(sig[:len(sig)/2])
+		s := new(big.Int).SetBytes(sig[len(sig)/2:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			t.Fatalf("JWT signature is invalid")
+		}
+	}
+
+	var header, claims map[string]interface{}
+	h, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	c, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	json.Unmarshal(h, &header)
+	json.Unmarshal(c, &claims)
+	return header, claims
+}
+
+// setupTokenEndpoint registers a token endpoint on mux that checks the client assertion and
+// returns a new access token every time it is called
+func setupTokenEndpoint(t *testing.T, pub crypto.PublicKey, alg string, expiresIn int, calls *int) {
+	mux.HandleFunc("/oauth2/v1/token", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		*calls++
+		r.ParseForm()
+		if r.PostForm.Get("grant_type") != "client_credentials" {
+			t.Errorf("grant_type should be client_credentials but got %v", r.PostForm.Get("grant_type"))
+		}
+		if r.PostForm.Get("scope") != "okta.users.read okta.groups.read" {
+			t.Errorf("scope is %v", r.PostForm.Get("scope"))
+		}
+		if r.PostForm.Get("client_assertion_type") != clientAssertionType {
+			t.Errorf("client_assertion_type is %v", r.PostForm.Get("client_assertion_type"))
+		}
+		header, claims := verifyJWT(t, r.PostForm.Get("client_assertion"), pub)
+		if header["alg"] != alg || header["kid"] != "key-1" {
+			t.Errorf("client assertion header is %v", header)
+		}
+		if claims["iss"] != "0oa1client" || claims["sub"] != "0oa1client" {
+			t.Errorf("client assertion iss/sub should be the client id but got %v", claims)
+		}
+		if claims["aud"] != server.URL+"/oauth2/v1/token" {
+			t.Errorf("client assertion aud should be the token url but got %v", claims["aud"])
+		}
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d,"scope":"okta.users.read okta.groups.read"}`, *calls, expiresIn)
+	})
+}
+
+func newTestOAuthClient(t *testing.T, key crypto.Signer) (*Client, *OAuthAuthenticator) {
+	auth, err := NewOAuthAuthenticator(server.URL, "0oa1client", []string{"okta.users.read", "okta.groups.read"}, key, "key-1")
+	if err != nil {
+		t.Fatalf("NewOAuthAuthenticator: %v", err)
+	}
+	c := NewClientWithAuthenticator(nil, client.BaseURL, auth)
+	return c, auth
+}
+
+func TestOAuthAuthenticatorCachesToken(t *testing.T) {
+	setup()
+	defer teardown()
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	calls := 0
+	setupTokenEndpoint(t, &key.PublicKey, "RS256", 3600, &calls)
+
+	var seen []string
+	mux.HandleFunc("/users/me", func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"id":"00u1"}`)
+	})
+
+	c, _ := newTestOAuthClient(t, key)
+	for i := 0; i < 3; i++ {
+		if _, _, err := c.Users.GetByID("me"); err != nil {
+			t.Fatalf("Users.GetByID returned error: %v", err)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("token endpoint should be called once but was called %v times", calls)
+	}
+	for _, h := range seen {
+		if h != "Bearer token-1" {
+			t.Errorf("Authorization header should be Bearer token-1 but got %v", h)
+		}
+	}
+}
+
+func TestOAuthAuthenticatorRefreshesExpiredToken(t *testing.T) {
+	setup()
+	defer teardown()
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	calls := 0
+	setupTokenEndpoint(t, &key.PublicKey, "ES256", 3600, &calls)
+
+	c, auth := newTestOAuthClient(t, key)
+	mux.HandleFunc("/users/me", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"00u1"}`)
+	})
+
+	c.Users.GetByID("me")
+	auth.expiry = time.Now().Add(-time.Second)
+	c.Users.GetByID("me")
+
+	if calls != 2 {
+		t.Errorf("token endpoint should be called again after expiry but was called %v times", calls)
+	}
+}
+
+func TestOAuthAuthenticatorInvalidatesOnUnauthorized(t *testing.T) {
+	setup()
+	defer teardown()
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	calls := 0
+	setupTokenEndpoint(t, &key.PublicKey, "RS256", 3600, &calls)
+
+	c, _ := newTestOAuthClient(t, key)
+	mux.HandleFunc("/users/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"errorCode":"E0000011","errorSummary":"Invalid token provided"}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"00u1"}`)
+	})
+
+	if _, _, err := c.Users.GetByID("me"); err == nil {
+		t.Fatalf("Users.GetByID should fail with a revoked token")
+	}
+	if _, _, err := c.Users.GetByID("me"); err != nil {
+		t.Errorf("Users.GetByID should succeed with a new token but got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("token endpoint should be called twice but was called %v times", calls)
+	}
+}
+
+func TestOAuthAuthenticatorTokenError(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/oauth2/v1/token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":"invalid_client","error_description":"The client_assertion signature is invalid."}`)
+	})
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	c, _ := newTestOAuthClient(t, key)
+	_, _, err := c.Users.GetByID("me")
+	oauthErr, ok := err.(*OAuthError)
+	if !ok {
+		t.Fatalf("error should be an *OAuthError but got %T %v", err, err)
+	}
+	if oauthErr.ErrorCode != "invalid_client" || oauthErr.StatusCode != 401 {
+		t.Errorf("unexpected OAuthError %+v", oauthErr)
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(rsaKey)
+	signer, err := ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+	if err != nil {
+		t.Fatalf("ParsePrivateKeyPEM returned error: %v", err)
+	}
+	if !rsaKey.Equal(signer) {
+		t.Errorf("ParsePrivateKeyPEM returned a different key")
+	}
+	if signer, err := ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte("junk")})); err == nil || signer != nil {
+		t.Errorf("a bad PKCS#1 key should return a nil signer and an error, got %#v %v", signer, err)
+	}
+	p224, _ := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	sec1, _ := x509.MarshalECPrivateKey(p224)
+	if _, err := ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1})); err == nil {
+		t.Errorf("a P-224 key can't sign a JWS and should be rejected")
+	}
+
+	enc := func(b *big.Int) string { return base64.RawURLEncoding.EncodeToString(b.Bytes()) }
+	jwk := fmt.Sprintf(`{"kty":"RSA","kid":"key-1","n":"%v","e":"AQAB","d":"%v","p":"%v","q":"%v"}`,
+		enc(rsaKey.N), enc(rsaKey.D), enc(rsaKey.Primes[0]), enc(rsaKey.Primes[1]))
+	signer, kid, err := ParsePrivateKeyJWK([]byte(jwk))
+	if err != nil {
+		t.Fatalf("ParsePrivateKeyJWK returned error: %v", err)
+	}
+	if kid != "key-1" || !rsaKey.Equal(signer) {
+		t.Errorf("ParsePrivateKeyJWK returned kid %v and a different key", kid)
+	}
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	jwk = fmt.Sprintf(`{"kty":"EC","crv":"P-384","x":"%v","y":"%v","d":"%v"}`, enc(ecKey.X), enc(ecKey.Y), enc(ecKey.D))
+	signer, _, err = ParsePrivateKeyJWK([]byte(jwk))
+	if err != nil {
+		t.Fatalf("ParsePrivateKeyJWK returned error: %v", err)
+	}
+	if !ecKey.Equal(signer) {
+		t.Errorf("ParsePrivateKeyJWK returned a different EC key")
+	}
+}
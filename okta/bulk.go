@@ -0,0 +1,267 @@
+package okta
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Bulk item statuses
+const (
+	BulkSucceeded = "SUCCEEDED"
+	BulkFailed    = "FAILED"
+	BulkSkipped   = "SKIPPED"
+)
+
+const (
+	defaultBulkWorkers = 4
+
+	// DefaultBulkRateLimitRetries is the RateLimitRetries used when no BulkOptions are given
+	DefaultBulkRateLimitRetries = 3
+)
+
+// BulkService runs many calls concurrently and reports the result of each one.
+// It calls Client.Users and Client.Groups, so decorators set on the client apply.
+type BulkService service
+
+// BulkOptions control a bulk run
+type BulkOptions struct {
+	// Workers is how many calls run at the same time. Defaults to 4. Every worker shares the
+	// client's rate limit tracking, so they all pause when Client.RateRemainingFloor is reached.
+	Workers int
+	// RateLimitRetries is how many times an item that failed with a *RateLimitError is tried again
+	// after the rate limit resets. 0 means it isn't retried; set it to DefaultBulkRateLimitRetries
+	// for the retries a nil *BulkOptions gets.
+	RateLimitRetries int
+	// Resume skips the items that succeeded in a previous report, so a failed run can be repeated
+	Resume *BulkReport
+	// Progress is called after each item, from the worker goroutines
+	Progress func(BulkResult)
+}
+
+// BulkResult is the outcome of one item
+type BulkResult struct {
+	// Index of the item in the input
+	Index int `json:"index"`
+	// Key identifies the item, e.g. the user ID or login
+	Key    string `json:"key"`
+	Status string `json:"status"`
+	// ID of the created object, for creates
+	ID string `json:"id,omitempty"`
+	// Action is what was done to the item when an operation can do several things, e.g. created or updated
+	Action        string   `json:"action,omitempty"`
+	StatusCode    int      `json:"statusCode,omitempty"`
+	OKTARequestID string   `json:"oktaRequestId,omitempty"`
+	Error         string   `json:"error,omitempty"`
+	ErrorCode     string   `json:"errorCode,omitempty"`
+	ErrorSummary  string   `json:"errorSummary,omitempty"`
+	ErrorCauses   []string `json:"errorCauses,omitempty"`
+}
+
+// BulkReport has one result per input item, in input order
+type BulkReport struct {
+	Operation string       `json:"operation"`
+	Started   time.Time    `json:"started"`
+	Finished  time.Time    `json:"finished"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Skipped   int          `json:"skipped"`
+	Results   []BulkResult `json:"results"`
+}
+
+// Failures returns the results that failed
+func (r *BulkReport) Failures() []BulkResult {
+	var failures []BulkResult
+	for _, result := range r.Results {
+		if result.Status == BulkFailed {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// succeeded returns the keys of the items that succeeded (or were already skipped) in this report
+func (r *BulkReport) succeeded() map[string]bool {
+	done := map[string]bool{}
+	if r == nil {
+		return done
+	}
+	for _, result := range r.Results {
+		if result.Status == BulkSucceeded || result.Status == BulkSkipped {
+			done[result.Key] = true
+		}
+	}
+	return done
+}
+
+// WriteJSON writes the report so it can be read back with ReadBulkReport to resume a run
+func (r *BulkReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// ReadBulkReport reads a report written by WriteJSON
+func ReadBulkReport(r io.Reader) (*BulkReport, error) {
+	report := new(BulkReport)
+	if err := json.NewDecoder(r).Decode(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// BulkFunc does the work for one item. The returned id is recorded for creates.
+type BulkFunc func(ctx context.Context, key string) (id string, resp *Response, err error)
+
+// Run calls fn for every key on a bounded worker pool and reports each result. It stops starting
+// new items when ctx is done, the remaining items are reported as failed with the context error.
+func (s *BulkService) Run(ctx context.Context, operation string, keys []string, fn BulkFunc, opt *BulkOptions) *BulkReport {
+	if opt == nil {
+		opt = &BulkOptions{RateLimitRetries: DefaultBulkRateLimitRetries}
+	}
+	workers := opt.Workers
+	if workers <= 0 {
+		workers = defaultBulkWorkers
+	}
+	retries := opt.RateLimitRetries
+
+	report := &BulkReport{
+		Operation: operation,
+		Started:   time.Now(),
+		Results:   make([]BulkResult, len(keys)),
+	}
+
+	done := opt.Resume.succeeded()
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result := s.runItem(ctx, i, keys[i], fn, done[keys[i]], retries)
+				report.Results[i] = result
+				if opt.Progress != nil {
+					opt.Progress(result)
+				}
+			}
+		}()
+	}
+
+	for i := range keys {
+		if ctx.Err() != nil {
+			report.Results[i] = BulkResult{Index: i, Key: keys[i], Status: BulkFailed, Error: ctx.Err().Error()}
+			continue
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, result := range report.Results {
+		switch result.Status {
+		case BulkSucceeded:
+			report.Succeeded++
+		case BulkSkipped:
+			report.Skipped++
+		default:
+			report.Failed++
+		}
+	}
+	report.Finished = time.Now()
+	return report
+}
+
+func (s *BulkService) runItem(ctx context.Context, index int, key string, fn BulkFunc, done bool, retries int) BulkResult {
+	result := BulkResult{Index: index, Key: key}
+	if done {
+		result.Status = BulkSkipped
+		return result
+	}
+
+	var id string
+	var resp *Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		id, resp, err = fn(ctx, key)
+		rateErr, ok := err.(*RateLimitError)
+		if !ok || attempt >= retries {
+			break
+		}
+		wait := s.client.retryWait(rateErr.Rate)
+		if err := sleepContext(ctx, wait); err != nil {
+			break
+		}
+	}
+
+	result.ID = id
+	if resp != nil && resp.Response != nil {
+		result.StatusCode = resp.StatusCode
+		result.OKTARequestID = resp.OKTARequestID
+	}
+	if err == nil {
+		result.Status = BulkSucceeded
+		return result
+	}
+
+	result.Status = BulkFailed
+	result.Error = err.Error()
+	var detail *apiError
+	switch e := err.(type) {
+	case *errorResponse:
+		detail = &e.ErrorDetail
+	case *RateLimitError:
+		detail = &e.ErrorDetail
+	}
+	if detail != nil {
+		result.ErrorCode = detail.ErrorCode
+		result.ErrorSummary = detail.ErrorSummary
+		for _, cause := range detail.ErrorCauses {
+			result.ErrorCauses = append(result.ErrorCauses, cause.ErrorSummary)
+		}
+	}
+	return result
+}
+
+// AddUsersToGroup adds every user to the group
+func (s *BulkService) AddUsersToGroup(ctx context.Context, groupID string, userIDs []string, opt *BulkOptions) *BulkReport {
+	return s.Run(ctx, "AddUserToGroup", userIDs, func(ctx context.Context, userID string) (string, *Response, error) {
+		resp, err := s.client.Groups.AddUserToGroup(groupID, userID)
+		return "", resp, err
+	}, opt)
+}
+
+// RemoveUsersFromGroup removes every user from the group
+func (s *BulkService) RemoveUsersFromGroup(ctx context.Context, groupID string, userIDs []string, opt *BulkOptions) *BulkReport {
+	return s.Run(ctx, "RemoveUserFromGroup", userIDs, func(ctx context.Context, userID string) (string, *Response, error) {
+		resp, err := s.client.Groups.RemoveUserFromGroup(groupID, userID)
+		return "", resp, err
+	}, opt)
+}
+
+// DeactivateUsers deactivates every user
+func (s *BulkService) DeactivateUsers(ctx context.Context, userIDs []string, opt *BulkOptions) *BulkReport {
+	return s.Run(ctx, "Deactivate", userIDs, func(ctx context.Context, userID string) (string, *Response, error) {
+		resp, err := s.client.Users.Deactivate(userID)
+		return "", resp, err
+	}, opt)
+}
+
+// CreateUsers creates every user. Results are keyed by login and have the new user ID.
+func (s *BulkService) CreateUsers(ctx context.Context, users []NewUser, activate bool, opt *BulkOptions) *BulkReport {
+	keys := make([]string, len(users))
+	byLogin := make(map[string]NewUser, len(users))
+	for i, user := range users {
+		keys[i] = user.Profile.Login
+		byLogin[user.Profile.Login] = user
+	}
+	return s.Run(ctx, "Create", keys, func(ctx context.Context, login string) (string, *Response, error) {
+		user, resp, err := s.client.Users.Create(byLogin[login], activate)
+		if err != nil {
+			return "", resp, err
+		}
+		return user.ID, resp, nil
+	}, opt)
+}
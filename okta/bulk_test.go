@@ -0,0 +1,121 @@
+package okta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBulkAddUsersToGroup(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	broken := map[string]bool{"00u3": true, "00u7": true}
+	mux.HandleFunc("/groups/00g1/users/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		userID := strings.TrimPrefix(r.URL.Path, "/groups/00g1/users/")
+		mu.Lock()
+		calls[userID]++
+		n, fail := calls[userID], broken[userID]
+		mu.Unlock()
+
+		w.Header().Set(headerOKTARequestID, "req-"+userID)
+		if userID == "00u5" && n == 1 {
+			w.Header().Set(headerRateLimit, "600")
+			w.Header().Set(headerRateRemaining, "0")
+			w.Header().Set(headerRateReset, strconv.FormatInt(time.Now().Unix(), 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"errorCode":"E0000047","errorSummary":"API call exceeded rate limit due to too many requests."}`)
+			return
+		}
+		if fail {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errorCode":"E0000007","errorSummary":"Not found: Resource not found: `+userID+` (User)","errorCauses":[]}`)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	var userIDs []string
+	for i := 0; i < 10; i++ {
+		userIDs = append(userIDs, fmt.Sprintf("00u%d", i))
+	}
+	client.MaxRetryWait = 5 * time.Millisecond
+
+	progress := 0
+	var progressMu sync.Mutex
+	report := client.Bulk.AddUsersToGroup(context.Background(), "00g1", userIDs, &BulkOptions{
+		Workers:          3,
+		RateLimitRetries: DefaultBulkRateLimitRetries,
+		Progress:         func(BulkResult) { progressMu.Lock(); progress++; progressMu.Unlock() },
+	})
+
+	if report.Succeeded != 8 || report.Failed != 2 || len(report.Results) != 10 || progress != 10 {
+		t.Fatalf("unexpected report %+v (progress %v)", report, progress)
+	}
+	failures := report.Failures()
+	if failures[0].Key != "00u3" || failures[1].Key != "00u7" {
+		t.Errorf("failures should be 00u3 and 00u7 in input order, got %+v", failures)
+	}
+	if failures[0].ErrorCode != "E0000007" || failures[0].StatusCode != 404 || failures[0].OKTARequestID != "req-00u3" {
+		t.Errorf("failure should have the OKTA error detail, got %+v", failures[0])
+	}
+	if report.Results[5].Status != BulkSucceeded || calls["00u5"] != 2 {
+		t.Errorf("rate limited item should be retried, got %+v after %v calls", report.Results[5], calls["00u5"])
+	}
+
+	// resume from the written report: only the failures are sent again
+	var buf bytes.Buffer
+	report.WriteJSON(&buf)
+	previous, err := ReadBulkReport(&buf)
+	if err != nil {
+		t.Fatalf("ReadBulkReport returned error: %v", err)
+	}
+	broken = map[string]bool{}
+	calls = map[string]int{}
+	report = client.Bulk.AddUsersToGroup(context.Background(), "00g1", userIDs, &BulkOptions{Resume: previous})
+	if report.Succeeded != 2 || report.Skipped != 8 || report.Failed != 0 {
+		t.Errorf("resumed run should only redo the failures, got %+v", report)
+	}
+	if len(calls) != 2 || calls["00u3"] != 1 || calls["00u7"] != 1 {
+		t.Errorf("resumed run sent %v", calls)
+	}
+
+	// no retries: the rate limited item fails after one call
+	calls = map[string]int{}
+	report = client.Bulk.AddUsersToGroup(context.Background(), "00g1", []string{"00u5"}, &BulkOptions{})
+	if report.Failed != 1 || report.Results[0].ErrorCode != "E0000047" || calls["00u5"] != 1 {
+		t.Errorf("RateLimitRetries 0 should not retry, got %+v after %v calls", report.Results[0], calls["00u5"])
+	}
+}
+
+func TestBulkCreateUsers(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		var user NewUser
+		json.NewDecoder(r.Body).Decode(&user)
+		fmt.Fprintf(w, `{"id":"id-%v"}`, strings.Split(user.Profile.Login, "@")[0])
+	})
+
+	var users []NewUser
+	for _, login := range []string{"isaac@example.com", "judy@example.com"} {
+		user := client.Users.NewUser()
+		user.Profile.Login = login
+		users = append(users, user)
+	}
+	report := client.Bulk.CreateUsers(context.Background(), users, false, nil)
+	if report.Succeeded != 2 || report.Results[0].ID != "id-isaac" || report.Results[1].Key != "judy@example.com" || report.Results[1].ID != "id-judy" {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
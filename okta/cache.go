@@ -0,0 +1,264 @@
+package okta
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// headerCache is set on responses answered from the cache, HIT or REVALIDATED
+	headerCache = "X-Okta-Cache"
+
+	cacheHit         = "HIT"
+	cacheRevalidated = "REVALIDATED"
+)
+
+// CacheEntry is a cached GET response
+type CacheEntry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// ETag is sent as If-None-Match once the entry expires, a 304 makes it fresh again
+	ETag    string
+	Expires time.Time
+}
+
+// CacheStore holds cached responses. Keys are the request path relative to Client.BaseURL plus
+// "?" and the query, e.g. "users/00u1?" or "groups?q=eng". Implementations must be safe for
+// concurrent use. NewMemoryCacheStore is the in-memory implementation, external backends
+// (memcached, redis, ...) only need to implement these four methods.
+type CacheStore interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	Delete(key string)
+	// DeletePrefix removes every entry whose key starts with prefix
+	DeletePrefix(prefix string)
+}
+
+// ResponseCache caches GET responses for the resource types that have a TTL. Responses with an
+// ETag are revalidated with If-None-Match when they expire. Anything the same client changes
+// (any successful non-GET request) is dropped from the cache: the resources named in the path
+// and the lists of that resource type, e.g. a PUT groups/00g1/users/00u1 invalidates
+// groups/00g1, groups/00g1/users, users/00u1, users/00u1/groups, groups?... and users?...
+// A change to a user also drops every cached group and app, as their member lists have the user
+// in them. Users fetched by login or as "me" aren't cached, a change made through the ID could
+// not find them.
+//
+//	client.Cache = okta.NewResponseCache(okta.NewMemoryCacheStore(), 5*time.Minute)
+//	client.Cache.TTL["apps"] = time.Hour
+type ResponseCache struct {
+	Store CacheStore
+	// TTL per resource type, the first path segment after /api/v1/ (users, groups, apps, ...).
+	// Types without a TTL aren't cached.
+	TTL map[string]time.Duration
+}
+
+// NewResponseCache returns a ResponseCache that caches users, groups and apps for ttl
+func NewResponseCache(store CacheStore, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{
+		Store: store,
+		TTL: map[string]time.Duration{
+			"users":  ttl,
+			"groups": ttl,
+			"apps":   ttl,
+		},
+	}
+}
+
+// IsCached reports whether a Response was answered from Client.Cache
+func (r *Response) IsCached() bool {
+	return r != nil && r.Response != nil && r.Header.Get(headerCache) != ""
+}
+
+// cacheKey returns the key and resource type of a request URL
+func (c *Client) cacheKey(u *url.URL) (key string, resourceType string) {
+	path := u.Path
+	if base := c.BaseURL; base != nil && u.Host == base.Host && strings.HasPrefix(path, base.Path) {
+		path = strings.TrimPrefix(path, base.Path)
+	} else {
+		path = u.Host + path
+	}
+	resourceType = strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	return path + "?" + u.RawQuery, resourceType
+}
+
+// cachedSend is limitedSend with Client.Cache in front of it for GET requests
+func (c *Client) cachedSend(req *http.Request, stats *callStats) (*http.Response, error) {
+	if c.Cache == nil || c.Cache.Store == nil || req.Method != "GET" {
+		return c.limitedSend(req, stats)
+	}
+	key, resourceType := c.cacheKey(req.URL)
+	ttl := c.Cache.TTL[resourceType]
+	if ttl <= 0 || isUserAlias(key) {
+		return c.limitedSend(req, stats)
+	}
+
+	entry, found := c.Cache.Store.Get(key)
+	if found && time.Now().Before(entry.Expires) {
+		return entry.response(req, cacheHit), nil
+	}
+	if found && entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := c.limitedSend(req, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && found {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		fresh := *entry
+		fresh.Expires = time.Now().Add(ttl)
+		c.Cache.Store.Set(key, &fresh)
+		return fresh.response(req, cacheRevalidated), nil
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		c.Cache.Store.Set(key, &CacheEntry{
+			StatusCode: resp.StatusCode,
+			Header:     cacheableHeader(resp.Header),
+			Body:       body,
+			ETag:       resp.Header.Get("ETag"),
+			Expires:    time.Now().Add(ttl),
+		})
+	}
+	return resp, nil
+}
+
+// cacheableHeader drops the headers that describe the original call rather than the resource
+func cacheableHeader(h http.Header) http.Header {
+	c := h.Clone()
+	for _, name := range []string{headerRateLimit, headerRateRemaining, headerRateReset, headerOKTARequestID, "Set-Cookie", "Date"} {
+		c.Del(name)
+	}
+	return c
+}
+
+func (e *CacheEntry) response(req *http.Request, status string) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set(headerCache, status)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// invalidateCache drops everything a successful change to u may have made stale
+func (c *Client) invalidateCache(u *url.URL) {
+	if c.Cache == nil || c.Cache.Store == nil {
+		return
+	}
+	key, _ := c.cacheKey(u)
+	path := strings.SplitN(key, "?", 2)[0]
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	prefix := ""
+	if strings.HasPrefix(path, "/") {
+		prefix = "/"
+	}
+
+	// every type/id pair in the path, e.g. groups/00g1 and users/00u1 in groups/00g1/users/00u1,
+	// plus the lists of that type
+	for i := 0; i < len(segments); i += 2 {
+		resourceType := segments[i]
+		c.Cache.Store.DeletePrefix(prefix + resourceType + "?")
+		if i+1 < len(segments) {
+			resource := prefix + resourceType + "/" + segments[i+1]
+			c.Cache.Store.DeletePrefix(resource + "?")
+			c.Cache.Store.DeletePrefix(resource + "/")
+		}
+	}
+	// the resource under its parent, e.g. groups/00g1/users?
+	c.Cache.Store.DeletePrefix(path + "?")
+	c.Cache.Store.DeletePrefix(path + "/")
+
+	// groups/*/users and apps/*/users can't be matched by prefix, so a user change drops both types
+	if segments[0] == "users" && len(segments) > 1 {
+		c.Cache.Store.DeletePrefix(prefix + "groups/")
+		c.Cache.Store.DeletePrefix(prefix + "apps/")
+	}
+}
+
+// isUserAlias reports whether a cache key names a user by login or as "me" instead of by ID
+func isUserAlias(key string) bool {
+	segments := strings.Split(strings.TrimPrefix(strings.SplitN(key, "?", 2)[0], "/"), "/")
+	if segments[0] != "users" || len(segments) < 2 {
+		return false
+	}
+	return segments[1] == "me" || strings.Contains(segments[1], "@")
+}
+
+// MemoryCacheStore is an in-memory CacheStore
+type MemoryCacheStore struct {
+	mu      sync.RWMutex
+	entries map[string]*CacheEntry
+}
+
+// NewMemoryCacheStore returns an empty in-memory CacheStore
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{entries: make(map[string]*CacheEntry)}
+}
+
+// Get implements CacheStore
+func (s *MemoryCacheStore) Get(key string) (*CacheEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.entries[key]
+	return entry, ok
+}
+
+// Set implements CacheStore
+func (s *MemoryCacheStore) Set(key string, entry *CacheEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = entry
+}
+
+// Delete implements CacheStore
+func (s *MemoryCacheStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// DeletePrefix implements CacheStore
+func (s *MemoryCacheStore) DeletePrefix(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// Len returns the number of cached entries
+func (s *MemoryCacheStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
@@ -0,0 +1,178 @@
+package okta
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestResponseCache(t *testing.T) {
+	setup()
+	defer teardown()
+
+	userCalls, notModified := 0, 0
+	mux.HandleFunc("/users/00u1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			fmt.Fprint(w, `{"id":"00u1"}`)
+			return
+		}
+		userCalls++
+		if r.Header.Get("If-None-Match") == `W/"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `W/"v1"`)
+		fmt.Fprint(w, `{"id":"00u1","status":"ACTIVE"}`)
+	})
+
+	store := NewMemoryCacheStore()
+	client.Cache = NewResponseCache(store, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		user, resp, err := client.Users.GetByID("00u1")
+		if err != nil {
+			t.Fatalf("GetByID returned error: %v", err)
+		}
+		if user.Status != "ACTIVE" {
+			t.Errorf("cached user should decode, got %+v", user)
+		}
+		if cached := resp.IsCached(); cached != (i > 0) {
+			t.Errorf("call %v: IsCached should be %v", i, i > 0)
+		}
+	}
+	if userCalls != 1 {
+		t.Errorf("user should be fetched once but was fetched %v times", userCalls)
+	}
+
+	// expired entries are revalidated with their ETag
+	entry, _ := store.Get("/users/00u1?")
+	entry.Expires = time.Now().Add(-time.Second)
+	user, resp, err := client.Users.GetByID("00u1")
+	if err != nil || user.Status != "ACTIVE" || resp.Header.Get(headerCache) != cacheRevalidated {
+		t.Errorf("a 304 should answer from the cache, got %+v %v %v", user, resp.Header, err)
+	}
+	if notModified != 1 {
+		t.Errorf("expired entry should be revalidated with If-None-Match")
+	}
+
+	// a change made through the same client drops the cached user
+	if _, _, err := client.Users.SetPassword("00u1", "Sup3rS3cret!"); err != nil {
+		t.Fatalf("SetPassword returned error: %v", err)
+	}
+	userCalls = 0
+	client.Users.GetByID("00u1")
+	if userCalls != 1 {
+		t.Errorf("SetPassword should invalidate the cached user")
+	}
+}
+
+func TestResponseCacheHitsIgnoreRateLimit(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/00u1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(headerRateLimit, "600")
+		w.Header().Add(headerRateRemaining, "1")
+		w.Header().Add(headerRateReset, strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+		fmt.Fprint(w, `{"id":"00u1","status":"ACTIVE"}`)
+	})
+	client.Cache = NewResponseCache(NewMemoryCacheStore(), time.Minute)
+	client.PauseOnRateLimit = false
+	client.RateRemainingFloor = 5
+
+	if _, _, err := client.Users.GetByID("00u1"); err != nil {
+		t.Fatalf("GetByID returned error: %v", err)
+	}
+	// the org is now under the floor, but the cache answers without calling OKTA
+	if _, resp, err := client.Users.GetByID("00u1"); err != nil || !resp.IsCached() {
+		t.Errorf("a cached user shouldn't wait for the rate limit, got %v", err)
+	}
+	_, _, err := client.Users.GetByID("00u2")
+	if _, ok := err.(*RateLimitError); !ok {
+		t.Errorf("an uncached user should still hit the rate limit check, got %v", err)
+	}
+}
+
+func TestResponseCacheInvalidatesMemberships(t *testing.T) {
+	setup()
+	defer teardown()
+
+	memberCalls := 0
+	mux.HandleFunc("/groups/00g1/users", func(w http.ResponseWriter, r *http.Request) {
+		memberCalls++
+		fmt.Fprint(w, `[{"id":"00u2"}]`)
+	})
+	mux.HandleFunc("/groups/00g1/users/00u1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	logCalls := 0
+	mux.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
+		logCalls++
+		fmt.Fprint(w, `[]`)
+	})
+
+	store := NewMemoryCacheStore()
+	client.Cache = NewResponseCache(store, time.Minute)
+
+	client.Groups.GetUsers("00g1", &GroupUserFilterOptions{})
+	client.Groups.GetUsers("00g1", &GroupUserFilterOptions{})
+	if memberCalls != 1 {
+		t.Errorf("members should be fetched once but were fetched %v times", memberCalls)
+	}
+
+	client.Cache.Store.Set("/users/00u1/groups?", &CacheEntry{StatusCode: 200, Body: []byte(`[]`), Expires: time.Now().Add(time.Minute)})
+	client.Cache.Store.Set("/users/00u9?", &CacheEntry{StatusCode: 200, Body: []byte(`{}`), Expires: time.Now().Add(time.Minute)})
+	if _, err := client.Groups.AddUserToGroup("00g1", "00u1"); err != nil {
+		t.Fatalf("AddUserToGroup returned error: %v", err)
+	}
+	if _, ok := store.Get("/users/00u1/groups?"); ok {
+		t.Errorf("AddUserToGroup should invalidate the user's groups")
+	}
+	if _, ok := store.Get("/users/00u9?"); !ok {
+		t.Errorf("AddUserToGroup should not invalidate other users")
+	}
+	client.Groups.GetUsers("00g1", &GroupUserFilterOptions{})
+	if memberCalls != 2 {
+		t.Errorf("AddUserToGroup should invalidate the group members")
+	}
+
+	// a user's lifecycle change shows in every member list
+	mux.HandleFunc("/users/00u2/lifecycle/deactivate", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	})
+	client.Cache.Store.Set("/apps/0oa1/users?", &CacheEntry{StatusCode: 200, Body: []byte(`[]`), Expires: time.Now().Add(time.Minute)})
+	if _, err := client.Users.Deactivate("00u2"); err != nil {
+		t.Fatalf("Deactivate returned error: %v", err)
+	}
+	if _, ok := store.Get("/apps/0oa1/users?"); ok {
+		t.Errorf("Deactivate should invalidate the app users")
+	}
+	client.Groups.GetUsers("00g1", &GroupUserFilterOptions{})
+	if memberCalls != 3 {
+		t.Errorf("Deactivate should invalidate the group members")
+	}
+
+	// users fetched by login aren't cached
+	loginCalls := 0
+	mux.HandleFunc("/users/anna@example.com", func(w http.ResponseWriter, r *http.Request) {
+		loginCalls++
+		fmt.Fprint(w, `{"id":"00u3"}`)
+	})
+	client.Users.GetByID("anna@example.com")
+	client.Users.GetByID("anna@example.com")
+	if loginCalls != 2 {
+		t.Errorf("users fetched by login should not be cached, fetched %v times", loginCalls)
+	}
+
+	// resource types without a TTL aren't cached
+	req, _ := client.NewRequest("GET", "logs", nil)
+	client.Do(req, nil)
+	req, _ = client.NewRequest("GET", "logs", nil)
+	client.Do(req, nil)
+	if logCalls != 2 {
+		t.Errorf("logs should not be cached")
+	}
+}
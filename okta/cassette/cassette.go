@@ -0,0 +1,241 @@
+// Package cassette records OKTA API traffic to a file and replays it so integration tests
+// can run offline.
+//
+// A Recorder is an http.RoundTripper. In ModeRecord it sends requests to the real org and
+// saves every request/response pair with the SSWS token and PII scrubbed. In ModeReplay it
+// never touches the network and answers each request with the next recorded interaction that
+// matches it.
+//
+//	rec, err := cassette.New("testdata/users.json", cassette.ModeFromEnv("OKTA_CASSETTE"))
+//	defer rec.Stop()
+//	client := okta.NewClient(rec.Client(), orgName, apiToken, false)
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Mode says whether a Recorder talks to the network or replays a cassette
+type Mode int
+
+const (
+	// ModeReplay answers requests from the cassette file and fails requests it has no recording for
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the org and writes the cassette file on Stop
+	ModeRecord
+)
+
+// ModeFromEnv returns ModeRecord when the environment variable is "record" and ModeReplay otherwise,
+// so CI replays by default and a developer can re-record with e.g. OKTA_CASSETTE=record go test ./...
+func ModeFromEnv(name string) Mode {
+	if os.Getenv(name) == "record" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// Cassette is the file format: every interaction in the order it happened
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a scrubbed request
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a scrubbed response
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Headers    http.Header `json:"headers"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Recorder records or replays HTTP interactions. Create one with New.
+type Recorder struct {
+	// Transport sends requests in ModeRecord. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	// Matchers decide which recorded interaction answers a request in ModeReplay.
+	// Defaults to DefaultMatchers (method, path and query).
+	Matchers []Matcher
+
+	// Scrubber removes secrets and PII before an interaction is saved. Defaults to NewScrubber().
+	Scrubber *Scrubber
+
+	path string
+	mode Mode
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New creates a Recorder for the cassette file at path. In ModeReplay the file must exist.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		Transport: http.DefaultTransport,
+		Matchers:  DefaultMatchers,
+		Scrubber:  NewScrubber(),
+		path:      path,
+		mode:      mode,
+	}
+
+	if mode == ModeReplay {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("cassette %v: %v", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+// Mode returns the mode the Recorder was created with
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an *http.Client that uses the Recorder as its transport. Pass it to okta.NewClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Stop writes the cassette file in ModeRecord. It does nothing in ModeReplay.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	if r.mode == ModeRecord {
+		return r.record(req, body)
+	}
+	return r.replay(req, body)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := r.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: cloneHeader(req.Header),
+			Body:    string(body),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    cloneHeader(resp.Header),
+			Body:       string(respBody),
+		},
+	}
+	r.Scrubber.scrubInteraction(&interaction)
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	// The incoming request is scrubbed the same way the recording was, and pseudonyms on both
+	// sides are masked, so values like logins in a filter match their pseudonyms in the cassette.
+	// Requests that only differ by a pseudonym are answered in recorded order.
+	scrubbed := masked(RecordedRequest{
+		Method:  req.Method,
+		URL:     r.Scrubber.scrubURL(req.URL.String()),
+		Headers: req.Header,
+		Body:    r.Scrubber.scrubBody(string(body), req.Header),
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !matchAll(r.Matchers, scrubbed, masked(interaction.Request)) {
+			continue
+		}
+		r.used[i] = true
+		recorded := interaction.Response
+		header := cloneHeader(recorded.Headers)
+		// Scrubbing may have changed the body length
+		header.Del("Content-Length")
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+			StatusCode:    recorded.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(recorded.Body))),
+			ContentLength: int64(len(recorded.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("cassette %v: no recorded interaction for %v %v", r.path, req.Method, scrubbed.URL)
+}
+
+func masked(req RecordedRequest) RecordedRequest {
+	req.URL = maskPseudonyms(req.URL)
+	req.Body = maskPseudonyms(req.Body)
+	return req
+}
+
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
@@ -0,0 +1,174 @@
+package cassette
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+	"github.com/chrismalek/oktasdk-go/okta/oktatest"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oktacassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "users.json")
+
+	server := oktatest.NewServer()
+	groupID := server.AddGroup(oktatest.Group{Name: "Engineering"})
+	for _, login := range []string{"isaac.brock@example.org", "judy.garland@example.org", "ella.fitzgerald@example.org"} {
+		userID := server.AddUser(oktatest.User{Profile: map[string]interface{}{
+			"login": login, "email": login, "firstName": "First", "lastName": "Last", "mobilePhone": "+1-555-415-1337",
+		}})
+		server.AddGroupMember(groupID, userID)
+	}
+
+	// Record
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	client := okta.NewClientWithBaseURL(rec.Client(), server.BaseURL(), oktatest.Token)
+	recorded, _, err := client.Groups.GetUsers(groupID, &okta.GroupUserFilterOptions{Limit: 2, GetAllPages: true})
+	if err != nil {
+		t.Fatalf("Groups.GetUsers returned error while recording: %v", err)
+	}
+	found, _, err := client.Users.ListWithFilter(&okta.UserListFilterOptions{LoginEqualTo: "judy.garland@example.org"})
+	if err != nil || len(found) != 1 {
+		t.Fatalf("Users.ListWithFilter returned %v, %v while recording", found, err)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	server.Close()
+
+	data, _ := ioutil.ReadFile(path)
+	for _, secret := range []string{oktatest.Token, "isaac.brock@example.org", "judy.garland", "+1-555-415-1337"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Cassette contains %q", secret)
+		}
+	}
+
+	// Replay against a base URL that does not exist
+	rec, err = New(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	client = okta.NewClient(rec.Client(), "replay-org", "any-token", false)
+	replayed, _, err := client.Groups.GetUsers(groupID, &okta.GroupUserFilterOptions{Limit: 2, GetAllPages: true})
+	if err != nil {
+		t.Fatalf("Groups.GetUsers returned error on replay: %v", err)
+	}
+	if len(replayed) != len(recorded) || replayed[2].ID != recorded[2].ID {
+		t.Errorf("Replay returned %v users, recorded %v", len(replayed), len(recorded))
+	}
+	if replayed[0].Profile.Login == "isaac.brock@example.org" || !strings.HasSuffix(replayed[0].Profile.Login, "@example.com") {
+		t.Errorf("Replayed login %v was not scrubbed", replayed[0].Profile.Login)
+	}
+
+	// The filter contains a real login which is scrubbed the same way before matching
+	found, _, err = client.Users.ListWithFilter(&okta.UserListFilterOptions{LoginEqualTo: "judy.garland@example.org"})
+	if err != nil || len(found) != 1 || found[0].ID != recorded[1].ID {
+		t.Errorf("Users.ListWithFilter returned %v, %v on replay", found, err)
+	}
+
+	if _, _, err := client.Users.GetByID("00unknown"); err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Errorf("Expected an error for an unrecorded request, got %v", err)
+	}
+}
+
+func TestScrubberPseudonymsAreKeyed(t *testing.T) {
+	a, b := NewScrubber(), NewScrubber()
+	first := a.scrubString("judy.garland@example.org")
+	if again := a.scrubString("contact judy.garland@example.org"); again != "contact "+first {
+		t.Errorf("the same login should get the same pseudonym, got %v and %v", first, again)
+	}
+	if other := b.scrubString("judy.garland@example.org"); other == first {
+		t.Errorf("another recording should use another key, both got %v", first)
+	}
+	if masked := maskPseudonyms(first); masked != "user-pseudonym@example.com" {
+		t.Errorf("pseudonyms should be masked for matching, got %v", masked)
+	}
+}
+
+func TestRecordThenReplayOAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oktacassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "oauth.json")
+
+	const accessToken = "eyJ.live-access-token.sig"
+	var assertion, proof string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth2/v1/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		assertion = r.PostForm.Get("client_assertion")
+		proof = r.Header.Get("DPoP")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":%q,"token_type":"DPoP","expires_in":3600}`, accessToken)
+	})
+	mux.HandleFunc("/api/v1/users/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "DPoP "+accessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"id":"00u1","profile":{"login":"isaac.brock@example.org"}}`)
+	})
+	server := httptest.NewServer(mux)
+
+	newClient := func(rec *Recorder) *okta.Client {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		auth, err := okta.NewOAuthAuthenticator(server.URL, "0oa1client", []string{"okta.users.read"}, key, "key-1")
+		if err != nil {
+			t.Fatalf("NewOAuthAuthenticator returned error: %v", err)
+		}
+		if auth.DPoP, err = okta.NewDPoPKey(); err != nil {
+			t.Fatalf("NewDPoPKey returned error: %v", err)
+		}
+		baseURL, _ := url.Parse(server.URL + "/api/v1/")
+		return okta.NewClientWithAuthenticator(rec.Client(), baseURL, auth)
+	}
+
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	if _, _, err := newClient(rec).Users.GetByID("me"); err != nil {
+		t.Fatalf("Users.GetByID returned error while recording: %v", err)
+	}
+	rec.Stop()
+	server.Close()
+
+	data, _ := ioutil.ReadFile(path)
+	if assertion == "" || proof == "" || len(rec.cassette.Interactions) != 2 {
+		t.Fatalf("the token request should be recorded too, got %v interactions", len(rec.cassette.Interactions))
+	}
+	for _, secret := range []string{accessToken, assertion, proof} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Cassette contains %q", secret)
+		}
+	}
+
+	// the server is closed, so the token request has to be replayed as well
+	rec, err = New(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	if user, _, err := newClient(rec).Users.GetByID("me"); err != nil || user.ID != "00u1" {
+		t.Errorf("Users.GetByID returned %+v, %v on replay", user, err)
+	}
+}
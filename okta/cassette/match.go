@@ -0,0 +1,58 @@
+package cassette
+
+import (
+	"net/url"
+	"reflect"
+)
+
+// Matcher reports whether a recorded request can answer an incoming (already scrubbed) request
+type Matcher func(incoming RecordedRequest, recorded RecordedRequest) bool
+
+// DefaultMatchers match on method, path and query
+var DefaultMatchers = []Matcher{MatchMethod, MatchPath, MatchQuery}
+
+// MatchMethod matches the HTTP method
+func MatchMethod(incoming RecordedRequest, recorded RecordedRequest) bool {
+	return incoming.Method == recorded.Method
+}
+
+// MatchPath matches the URL path. The host is ignored so a cassette recorded against one org
+// replays for a client configured with another.
+func MatchPath(incoming RecordedRequest, recorded RecordedRequest) bool {
+	in, err := url.Parse(incoming.URL)
+	if err != nil {
+		return false
+	}
+	rec, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	return in.Path == rec.Path
+}
+
+// MatchQuery matches the query parameters regardless of their order
+func MatchQuery(incoming RecordedRequest, recorded RecordedRequest) bool {
+	in, err := url.Parse(incoming.URL)
+	if err != nil {
+		return false
+	}
+	rec, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(in.Query(), rec.Query())
+}
+
+// MatchBody matches the scrubbed request body
+func MatchBody(incoming RecordedRequest, recorded RecordedRequest) bool {
+	return incoming.Body == recorded.Body
+}
+
+func matchAll(matchers []Matcher, incoming RecordedRequest, recorded RecordedRequest) bool {
+	for _, m := range matchers {
+		if !m(incoming, recorded) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,206 @@
+package cassette
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+var emailRegex = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+var linkRegex = regexp.MustCompile(`<([^>]*)>`)
+var pseudonymRegex = regexp.MustCompile(`(user|redacted)-[0-9a-f]{10}`)
+
+// DefaultScrubHeaders are replaced with REDACTED in recorded requests and responses
+var DefaultScrubHeaders = []string{"Authorization", "DPoP", "Cookie", "Set-Cookie"}
+
+// DefaultScrubFields are JSON and form keys whose values are replaced with pseudonyms. When a JSON
+// value is an object or array every string inside it is replaced.
+var DefaultScrubFields = []string{
+	"login", "email", "secondEmail", "firstName", "lastName", "middleName", "displayName",
+	"nickName", "nickname", "mobilePhone", "primaryPhone", "streetAddress", "city", "zipCode",
+	"postalCode", "password", "recovery_question", "answer", "client_secret", "activationUrl",
+	"activationToken", "resetPasswordUrl", "ipAddress", "ip", "rawUserAgent", "alternateId",
+	"userName", "displayMessage", "access_token", "refresh_token", "id_token", "client_assertion",
+}
+
+// Scrubber removes secrets and PII from interactions before they are written to a cassette.
+// Values are replaced with pseudonyms, an HMAC of the value under Key, so the same login shows up
+// as the same pseudonym everywhere in a recording. Key is never written, so a pseudonym can't be
+// confirmed by hashing a list of guessed logins.
+type Scrubber struct {
+	// Key of the pseudonym HMAC. NewScrubber sets a random one.
+	Key []byte
+	// Headers are replaced with REDACTED
+	Headers []string
+	// Fields are JSON and form keys whose values are replaced with pseudonyms
+	Fields []string
+	// Emails replaces every email address found in URLs, headers and bodies
+	Emails bool
+}
+
+// NewScrubber returns a Scrubber using DefaultScrubHeaders and DefaultScrubFields that also scrubs email addresses
+func NewScrubber() *Scrubber {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("cassette: no random pseudonym key: " + err.Error())
+	}
+	return &Scrubber{
+		Key:     key,
+		Headers: append([]string(nil), DefaultScrubHeaders...),
+		Fields:  append([]string(nil), DefaultScrubFields...),
+		Emails:  true,
+	}
+}
+
+func (s *Scrubber) pseudonym(value string) string {
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write([]byte(value))
+	hash := hex.EncodeToString(mac.Sum(nil))[:10]
+	if emailRegex.MatchString(value) && emailRegex.FindString(value) == value {
+		return "user-" + hash + "@example.com"
+	}
+	return "redacted-" + hash
+}
+
+func (s *Scrubber) scrubString(v string) string {
+	if !s.Emails {
+		return v
+	}
+	return emailRegex.ReplaceAllStringFunc(v, func(email string) string {
+		if strings.HasSuffix(email, "@example.com") && strings.HasPrefix(email, "user-") {
+			return email
+		}
+		return s.pseudonym(email)
+	})
+}
+
+// scrubURL scrubs the decoded path and query values so percent encoded emails are found too
+func (s *Scrubber) scrubURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return s.scrubString(raw)
+	}
+	q := u.Query()
+	for k, values := range q {
+		for i, v := range values {
+			values[i] = s.scrubString(v)
+		}
+		q[k] = values
+	}
+	u.RawQuery = q.Encode()
+	u.Path = s.scrubString(u.Path)
+	u.RawPath = ""
+	return u.String()
+}
+
+func (s *Scrubber) scrubHeader(h http.Header) {
+	for k, values := range h {
+		for i, v := range values {
+			if k == "Link" {
+				v = linkRegex.ReplaceAllStringFunc(v, func(link string) string {
+					return "<" + s.scrubURL(link[1:len(link)-1]) + ">"
+				})
+			}
+			values[i] = s.scrubString(v)
+		}
+		h[k] = values
+	}
+	for _, name := range s.Headers {
+		if h.Get(name) != "" {
+			h.Set(name, redacted)
+		}
+	}
+}
+
+// scrubBody pseudonymizes sensitive fields of a JSON or form encoded body, like an OAuth token
+// request. Other bodies only have emails scrubbed.
+func (s *Scrubber) scrubBody(body string, header http.Header) string {
+	if body == "" {
+		return body
+	}
+	if mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type")); mediaType == "application/x-www-form-urlencoded" {
+		return s.scrubForm(body)
+	}
+	var v interface{}
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return s.scrubString(body)
+	}
+	v = s.scrubValue(v, false)
+	out, err := json.Marshal(v)
+	if err != nil {
+		return s.scrubString(body)
+	}
+	return string(out)
+}
+
+func (s *Scrubber) scrubForm(body string) string {
+	form, err := url.ParseQuery(body)
+	if err != nil {
+		return s.scrubString(body)
+	}
+	for k, values := range form {
+		for i, v := range values {
+			if s.sensitive(k) && v != "" {
+				values[i] = s.pseudonym(v)
+			} else {
+				values[i] = s.scrubString(v)
+			}
+		}
+	}
+	return form.Encode()
+}
+
+func (s *Scrubber) sensitive(key string) bool {
+	for _, f := range s.Fields {
+		if f == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Scrubber) scrubValue(v interface{}, sensitive bool) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, child := range value {
+			value[k] = s.scrubValue(child, sensitive || s.sensitive(k))
+		}
+		return value
+	case []interface{}:
+		for i, child := range value {
+			value[i] = s.scrubValue(child, sensitive)
+		}
+		return value
+	case string:
+		if sensitive && value != "" {
+			return s.pseudonym(value)
+		}
+		return s.scrubString(value)
+	}
+	return v
+}
+
+// maskPseudonyms replaces every pseudonym with the same placeholder. Pseudonyms differ between
+// recordings, so replay compares requests with their pseudonyms masked.
+func maskPseudonyms(v string) string {
+	return pseudonymRegex.ReplaceAllString(v, "$1-pseudonym")
+}
+
+func (s *Scrubber) scrubInteraction(i *Interaction) {
+	i.Request.URL = s.scrubURL(i.Request.URL)
+	s.scrubHeader(i.Request.Headers)
+	i.Request.Body = s.scrubBody(i.Request.Body, i.Request.Headers)
+	s.scrubHeader(i.Response.Headers)
+	i.Response.Body = s.scrubBody(i.Response.Body, i.Response.Headers)
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"crypto"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+// Values for Config.Mode
+const (
+	ModeReadWrite = "readWrite"
+	ModeReadOnly  = "readOnly"
+	ModeDryRun    = "dryRun"
+)
+
+var safetyModes = map[string]okta.SafetyMode{
+	"":            okta.ModeReadWrite,
+	ModeReadWrite: okta.ModeReadWrite,
+	ModeReadOnly:  okta.ModeReadOnly,
+	ModeDryRun:    okta.ModeDryRun,
+}
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid okta configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks that the configuration can build a client
+func (c *Config) Validate() error {
+	var problems []string
+
+	if c.OrgURL == "" {
+		problems = append(problems, "orgUrl is required")
+	} else if u, err := url.Parse(c.OrgURL); err != nil || u.Host == "" {
+		problems = append(problems, fmt.Sprintf("orgUrl %q is not a valid URL", c.OrgURL))
+	} else {
+		local := u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1"
+		if u.Scheme != "https" && !(u.Scheme == "http" && local) {
+			problems = append(problems, fmt.Sprintf("orgUrl %q must use https", c.OrgURL))
+		}
+		if u.Path != "" && u.Path != "/" {
+			problems = append(problems, fmt.Sprintf("orgUrl %q should not have a path (it is just the org, e.g. https://example.okta.com)", c.OrgURL))
+		}
+		if strings.Contains(c.OrgURL, "{") {
+			problems = append(problems, fmt.Sprintf("orgUrl %q still contains a placeholder", c.OrgURL))
+		}
+	}
+
+	switch c.AuthorizationMode {
+	case AuthorizationModeSSWS:
+		if c.Token == "" {
+			problems = append(problems, "token is required for authorizationMode SSWS")
+		} else if strings.Contains(c.Token, "{") {
+			problems = append(problems, "token still contains a placeholder")
+		}
+		if c.DPoP {
+			problems = append(problems, "dpop requires authorizationMode PrivateKey")
+		}
+	case AuthorizationModePrivateKey:
+		if c.ClientID == "" {
+			problems = append(problems, "clientId is required for authorizationMode PrivateKey")
+		}
+		if len(c.Scopes) == 0 {
+			problems = append(problems, "scopes are required for authorizationMode PrivateKey")
+		}
+		if c.PrivateKey == "" {
+			problems = append(problems, "privateKey is required for authorizationMode PrivateKey")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("authorizationMode must be %v or %v but is %q", AuthorizationModeSSWS, AuthorizationModePrivateKey, c.AuthorizationMode))
+	}
+
+	if _, ok := safetyModes[c.Mode]; !ok {
+		problems = append(problems, fmt.Sprintf("mode must be %v, %v or %v but is %q", ModeReadWrite, ModeReadOnly, ModeDryRun, c.Mode))
+	}
+	if c.Proxy.Host != "" && (c.Proxy.Port < 1 || c.Proxy.Port > 65535) {
+		problems = append(problems, fmt.Sprintf("proxy.port %d is not a valid port", c.Proxy.Port))
+	}
+	if c.ConnectionTimeout < 0 {
+		problems = append(problems, "connectionTimeout can't be negative")
+	}
+	if c.RequestTimeout < 0 {
+		problems = append(problems, "requestTimeout can't be negative")
+	}
+	if c.RateLimit.MaxRetries < 0 {
+		problems = append(problems, "rateLimit.maxRetries can't be negative")
+	}
+	if c.RateLimit.MaxBackoff < 0 {
+		problems = append(problems, "rateLimit.maxBackoff can't be negative")
+	}
+	if c.RateLimit.RemainingFloor < 0 {
+		problems = append(problems, "rateLimit.remainingFloor can't be negative")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// NewClient loads the configuration and builds a client from it
+func NewClient(opt Options) (*okta.Client, error) {
+	cfg, err := Load(opt)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.NewClient()
+}
+
+// NewClient builds a client from the configuration
+func (c *Config) NewClient() (*okta.Client, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	httpClient := c.HTTPClient()
+	baseURL, _ := url.Parse(strings.TrimSuffix(c.OrgURL, "/") + "/api/v1/")
+
+	var client *okta.Client
+	if c.AuthorizationMode == AuthorizationModePrivateKey {
+		key, kid, err := c.signer()
+		if err != nil {
+			return nil, err
+		}
+		if c.PrivateKeyID != "" {
+			kid = c.PrivateKeyID
+		}
+		auth, err := okta.NewOAuthAuthenticator(c.OrgURL, c.ClientID, c.Scopes, key, kid)
+		if err != nil {
+			return nil, err
+		}
+		auth.HTTPClient = httpClient
+		if c.DPoP {
+			if auth.DPoP, err = okta.NewDPoPKey(); err != nil {
+				return nil, err
+			}
+		}
+		client = okta.NewClientWithAuthenticator(httpClient, baseURL, auth)
+	} else {
+		client = okta.NewClientWithBaseURL(httpClient, baseURL, c.Token)
+	}
+
+	client.Mode = safetyModes[c.Mode]
+	client.MaxRetries = c.RateLimit.MaxRetries
+	client.MaxRetryWait = time.Duration(c.RateLimit.MaxBackoff) * time.Second
+	if c.RateLimit.Pause != nil {
+		client.PauseOnRateLimit = *c.RateLimit.Pause
+	}
+	if c.RateLimit.RemainingFloor > 0 {
+		client.RateRemainingFloor = c.RateLimit.RemainingFloor
+	}
+	return client, nil
+}
+
+// HTTPClient returns the *http.Client with the configured proxy and timeouts
+func (c *Config) HTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.ConnectionTimeout > 0 {
+		transport.DialContext = (&net.Dialer{
+			Timeout:   time.Duration(c.ConnectionTimeout) * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+		transport.TLSHandshakeTimeout = time.Duration(c.ConnectionTimeout) * time.Second
+	}
+	if c.Proxy.Host != "" {
+		proxy := &url.URL{Scheme: "http", Host: net.JoinHostPort(c.Proxy.Host, strconv.Itoa(c.Proxy.Port))}
+		if c.Proxy.Username != "" {
+			proxy.User = url.UserPassword(c.Proxy.Username, c.Proxy.Password)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(c.RequestTimeout) * time.Second,
+	}
+}
+
+// signer parses PrivateKey, which is an inline PEM or JWK or the path of a file holding one
+func (c *Config) signer() (crypto.Signer, string, error) {
+	data := []byte(strings.TrimSpace(c.PrivateKey))
+	if !strings.HasPrefix(string(data), "-----BEGIN") && !strings.HasPrefix(string(data), "{") {
+		var err error
+		data, err = ioutil.ReadFile(c.PrivateKey)
+		if err != nil {
+			return nil, "", fmt.Errorf("privateKey: %v", err)
+		}
+		data = []byte(strings.TrimSpace(string(data)))
+	}
+
+	if strings.HasPrefix(string(data), "{") {
+		key, kid, err := okta.ParsePrivateKeyJWK(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("privateKey: %v", err)
+		}
+		return key, kid, nil
+	}
+	key, err := okta.ParsePrivateKeyPEM(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("privateKey: %v", err)
+	}
+	return key, "", nil
+}
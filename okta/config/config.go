@@ -0,0 +1,195 @@
+// Package config builds an okta.Client from environment variables, okta.yaml files and named
+// profiles so every tool configures the SDK the same way.
+//
+// Settings are applied in this order, later sources win:
+//
+//  1. defaults
+//  2. ~/.okta/okta.yaml
+//  3. ./okta.yaml (or only Options.File when it is set)
+//  4. the selected profile from those files (Options.Profile or OKTA_PROFILE)
+//  5. OKTA_CLIENT_* environment variables
+//
+// A minimal okta.yaml:
+//
+//	okta:
+//	  client:
+//	    orgUrl: https://example.okta.com
+//	    token: 00abc...
+//	profiles:
+//	  reporting:
+//	    authorizationMode: PrivateKey
+//	    clientId: 0oa1234
+//	    scopes: [okta.users.read, okta.groups.read]
+//	    privateKey: /etc/okta/reporting.pem
+//
+// Then:
+//
+//	client, err := config.NewClient(config.Options{Profile: "reporting"})
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// AuthorizationModeSSWS authenticates with an API token
+	AuthorizationModeSSWS = "SSWS"
+	// AuthorizationModePrivateKey uses OAuth 2.0 client credentials with a private_key_jwt client assertion
+	AuthorizationModePrivateKey = "PrivateKey"
+
+	// ProfileEnv selects a profile when Options.Profile is empty
+	ProfileEnv = "OKTA_PROFILE"
+)
+
+// Config is everything needed to build a client. The yaml keys match the okta.client section of
+// okta.yaml used by the other OKTA SDKs.
+type Config struct {
+	// OrgURL is the org address, e.g. https://example.okta.com
+	OrgURL string `yaml:"orgUrl"`
+	// AuthorizationMode is SSWS (default) or PrivateKey
+	AuthorizationMode string `yaml:"authorizationMode"`
+	// Token is the API token for SSWS mode
+	Token string `yaml:"token"`
+
+	// ClientID, Scopes, PrivateKey and PrivateKeyID configure PrivateKey mode. PrivateKey is a PEM or
+	// JWK, inline or as a path to a file.
+	ClientID     string   `yaml:"clientId"`
+	Scopes       []string `yaml:"scopes"`
+	PrivateKey   string   `yaml:"privateKey"`
+	PrivateKeyID string   `yaml:"privateKeyId"`
+	// DPoP binds access tokens to a generated key pair (PrivateKey mode only)
+	DPoP bool `yaml:"dpop"`
+
+	Proxy Proxy `yaml:"proxy"`
+
+	// ConnectionTimeout and RequestTimeout are in seconds. 0 means no timeout.
+	ConnectionTimeout int `yaml:"connectionTimeout"`
+	RequestTimeout    int `yaml:"requestTimeout"`
+
+	RateLimit RateLimit `yaml:"rateLimit"`
+
+	// Mode is readWrite (default), readOnly or dryRun. See okta.SafetyMode.
+	Mode string `yaml:"mode"`
+
+	// Sources lists the files and environment that were read, for error messages
+	Sources []string `yaml:"-"`
+}
+
+// Proxy sends API traffic through an HTTP proxy
+type Proxy struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// RateLimit configures the client rate limit handling
+type RateLimit struct {
+	// MaxRetries is how many times a 429 is retried after the limit resets
+	MaxRetries int `yaml:"maxRetries"`
+	// MaxBackoff caps the wait before a retry, in seconds. 0 means wait until the limit resets.
+	MaxBackoff int `yaml:"maxBackoff"`
+	// Pause waits for the limit to reset when remaining requests drop below RemainingFloor
+	// instead of returning okta.RateLimitError. Defaults to true.
+	Pause *bool `yaml:"pause"`
+	// RemainingFloor is okta.Client.RateRemainingFloor. 0 keeps the client default.
+	RemainingFloor int `yaml:"remainingFloor"`
+}
+
+// Options control where Load looks for configuration
+type Options struct {
+	// File is read instead of ~/.okta/okta.yaml and ./okta.yaml. It must exist.
+	File string
+	// Profile selects a named profile. Defaults to $OKTA_PROFILE.
+	Profile string
+	// Getenv reads environment variables. Defaults to os.Getenv, tests can replace it.
+	Getenv func(string) string
+	// SkipEnv ignores OKTA_CLIENT_* environment variables
+	SkipEnv bool
+}
+
+type file struct {
+	Okta struct {
+		Client yaml.Node `yaml:"client"`
+	} `yaml:"okta"`
+	Profiles map[string]yaml.Node `yaml:"profiles"`
+}
+
+// Load reads configuration from files, the selected profile and the environment, then validates it
+func Load(opt Options) (*Config, error) {
+	getenv := opt.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	profile := opt.Profile
+	if profile == "" {
+		profile = getenv(ProfileEnv)
+	}
+
+	cfg := &Config{AuthorizationMode: AuthorizationModeSSWS}
+
+	var paths []string
+	if opt.File != "" {
+		paths = []string{opt.File}
+	} else {
+		if home, err := os.UserHomeDir(); err == nil {
+			paths = append(paths, filepath.Join(home, ".okta", "okta.yaml"))
+		}
+		paths = append(paths, "okta.yaml")
+	}
+
+	profiles := map[string]yaml.Node{}
+	profileSource := map[string]string{}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) && opt.File == "" {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var f file
+		if err := yaml.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+		if !f.Okta.Client.IsZero() {
+			if err := f.Okta.Client.Decode(cfg); err != nil {
+				return nil, fmt.Errorf("%v: okta.client: %v", path, err)
+			}
+		}
+		for name, node := range f.Profiles {
+			profiles[name] = node
+			profileSource[name] = path
+		}
+		cfg.Sources = append(cfg.Sources, path)
+	}
+
+	if profile != "" {
+		node, ok := profiles[profile]
+		if !ok {
+			return nil, fmt.Errorf("profile %q not found in %v", profile, strings.Join(cfg.Sources, ", "))
+		}
+		if err := node.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("%v: profiles.%v: %v", profileSource[profile], profile, err)
+		}
+		cfg.Sources = append(cfg.Sources, "profile "+profile)
+	}
+
+	if !opt.SkipEnv {
+		if err := cfg.applyEnv(getenv); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+const testYAML = `
+okta:
+  client:
+    orgUrl: https://example.okta.com
+    token: base-token
+    requestTimeout: 30
+    rateLimit:
+      maxRetries: 2
+profiles:
+  reporting:
+    orgUrl: https://reporting.okta.com
+    token: reporting-token
+    rateLimit:
+      pause: false
+      remainingFloor: 20
+  service:
+    authorizationMode: PrivateKey
+    clientId: 0oa1client
+    scopes: [okta.users.read, okta.groups.read]
+`
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "okta.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfig(t, testYAML)
+
+	cfg, err := Load(Options{File: path, Getenv: env(nil)})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.OrgURL != "https://example.okta.com" || cfg.Token != "base-token" || cfg.RequestTimeout != 30 || cfg.RateLimit.MaxRetries != 2 {
+		t.Errorf("base config not loaded: %+v", cfg)
+	}
+
+	cfg, err = Load(Options{File: path, Profile: "reporting", Getenv: env(map[string]string{EnvToken: "env-token"})})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.OrgURL != "https://reporting.okta.com" {
+		t.Errorf("profile should override orgUrl but got %v", cfg.OrgURL)
+	}
+	if cfg.Token != "env-token" {
+		t.Errorf("environment should override the profile token but got %v", cfg.Token)
+	}
+	if cfg.RequestTimeout != 30 || cfg.RateLimit.MaxRetries != 2 {
+		t.Errorf("settings the profile doesn't set should come from okta.client: %+v", cfg)
+	}
+	if cfg.RateLimit.Pause == nil || *cfg.RateLimit.Pause || cfg.RateLimit.RemainingFloor != 20 {
+		t.Errorf("profile rateLimit not applied: %+v", cfg.RateLimit)
+	}
+	wantSources := []string{path, "profile reporting", "environment"}
+	if !reflect.DeepEqual(cfg.Sources, wantSources) {
+		t.Errorf("Sources should be %v but got %v", wantSources, cfg.Sources)
+	}
+
+	// OKTA_PROFILE selects the profile when Options.Profile is empty
+	cfg, err = Load(Options{File: path, Getenv: env(map[string]string{ProfileEnv: "reporting"})})
+	if err != nil || cfg.OrgURL != "https://reporting.okta.com" {
+		t.Errorf("OKTA_PROFILE should select the profile: %v %v", cfg, err)
+	}
+
+	if _, err := Load(Options{File: path, Profile: "missing", Getenv: env(nil)}); err == nil || !strings.Contains(err.Error(), `profile "missing" not found`) {
+		t.Errorf("missing profile should fail but got %v", err)
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	path := writeConfig(t, testYAML)
+
+	_, err := Load(Options{File: path, Profile: "service", Getenv: env(map[string]string{EnvOrgURL: "http://example.okta.com/api/v1", EnvRequestTimeout: "ten"})})
+	if _, ok := err.(*ValidationError); !ok {
+		t.Fatalf("error should be a *ValidationError but got %T %v", err, err)
+	}
+	if !strings.Contains(err.Error(), "OKTA_CLIENT_REQUESTTIMEOUT must be a number") {
+		t.Errorf("bad number should be reported: %v", err)
+	}
+
+	_, err = Load(Options{File: path, Profile: "service", Getenv: env(map[string]string{EnvOrgURL: "http://example.okta.com/api/v1"})})
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("error should be a *ValidationError but got %T %v", err, err)
+	}
+	want := []string{
+		`orgUrl "http://example.okta.com/api/v1" must use https`,
+		`orgUrl "http://example.okta.com/api/v1" should not have a path (it is just the org, e.g. https://example.okta.com)`,
+		"privateKey is required for authorizationMode PrivateKey",
+	}
+	if !reflect.DeepEqual(verr.Problems, want) {
+		t.Errorf("Problems should be %v but got %v", want, verr.Problems)
+	}
+}
+
+func TestConfigNewClient(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600)
+
+	path := writeConfig(t, testYAML)
+	cfg, err := Load(Options{File: path, Profile: "service", Getenv: env(map[string]string{EnvPrivateKey: keyPath, EnvPrivateKeyID: "key-1", EnvDPoP: "true", EnvMode: "readOnly"})})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	client, err := cfg.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	if client.BaseURL.String() != "https://example.okta.com/api/v1/" {
+		t.Errorf("BaseURL is %v", client.BaseURL)
+	}
+	auth, ok := client.Authenticator.(*okta.OAuthAuthenticator)
+	if !ok {
+		t.Fatalf("Authenticator should be *okta.OAuthAuthenticator but is %T", client.Authenticator)
+	}
+	if auth.ClientID != "0oa1client" || auth.KeyID != "key-1" || auth.TokenURL != "https://example.okta.com/oauth2/v1/token" || auth.DPoP == nil {
+		t.Errorf("unexpected authenticator %+v", auth)
+	}
+	if client.MaxRetries != 2 || client.MaxRetryWait != 0 {
+		t.Errorf("rate limit settings not applied: %v %v", client.MaxRetries, client.MaxRetryWait)
+	}
+	if client.Mode != okta.ModeReadOnly {
+		t.Errorf("mode should be read-only but is %v", client.Mode)
+	}
+	if cfg.HTTPClient().Timeout != 30*time.Second {
+		t.Errorf("requestTimeout not applied")
+	}
+}
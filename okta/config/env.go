@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Environment variables read by Load. Scopes are comma or space separated.
+const (
+	EnvOrgURL            = "OKTA_CLIENT_ORGURL"
+	EnvAuthorizationMode = "OKTA_CLIENT_AUTHORIZATIONMODE"
+	EnvToken             = "OKTA_CLIENT_TOKEN"
+	EnvClientID          = "OKTA_CLIENT_CLIENTID"
+	EnvScopes            = "OKTA_CLIENT_SCOPES"
+	EnvPrivateKey        = "OKTA_CLIENT_PRIVATEKEY"
+	EnvPrivateKeyID      = "OKTA_CLIENT_PRIVATEKEYID"
+	EnvDPoP              = "OKTA_CLIENT_DPOP"
+	EnvProxyHost         = "OKTA_CLIENT_PROXY_HOST"
+	EnvProxyPort         = "OKTA_CLIENT_PROXY_PORT"
+	EnvProxyUsername     = "OKTA_CLIENT_PROXY_USERNAME"
+	EnvProxyPassword     = "OKTA_CLIENT_PROXY_PASSWORD"
+	EnvConnectionTimeout = "OKTA_CLIENT_CONNECTIONTIMEOUT"
+	EnvRequestTimeout    = "OKTA_CLIENT_REQUESTTIMEOUT"
+	EnvMaxRetries        = "OKTA_CLIENT_RATELIMIT_MAXRETRIES"
+	EnvMaxBackoff        = "OKTA_CLIENT_RATELIMIT_MAXBACKOFF"
+	EnvPause             = "OKTA_CLIENT_RATELIMIT_PAUSE"
+	EnvRemainingFloor    = "OKTA_CLIENT_RATELIMIT_REMAININGFLOOR"
+	EnvMode              = "OKTA_CLIENT_MODE"
+)
+
+func (c *Config) applyEnv(getenv func(string) string) error {
+	used := false
+	str := func(name string, dst *string) {
+		if v := getenv(name); v != "" {
+			*dst = v
+			used = true
+		}
+	}
+	var errs []string
+	num := func(name string, dst *int) {
+		if v := getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%v must be a number but is %q", name, v))
+				return
+			}
+			*dst = n
+			used = true
+		}
+	}
+	boolean := func(name string, dst **bool) {
+		if v := getenv(name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%v must be true or false but is %q", name, v))
+				return
+			}
+			*dst = &b
+			used = true
+		}
+	}
+
+	str(EnvOrgURL, &c.OrgURL)
+	str(EnvAuthorizationMode, &c.AuthorizationMode)
+	str(EnvToken, &c.Token)
+	str(EnvClientID, &c.ClientID)
+	if v := getenv(EnvScopes); v != "" {
+		c.Scopes = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+		used = true
+	}
+	str(EnvPrivateKey, &c.PrivateKey)
+	str(EnvPrivateKeyID, &c.PrivateKeyID)
+	var dpop *bool
+	boolean(EnvDPoP, &dpop)
+	if dpop != nil {
+		c.DPoP = *dpop
+	}
+	str(EnvProxyHost, &c.Proxy.Host)
+	num(EnvProxyPort, &c.Proxy.Port)
+	str(EnvProxyUsername, &c.Proxy.Username)
+	str(EnvProxyPassword, &c.Proxy.Password)
+	num(EnvConnectionTimeout, &c.ConnectionTimeout)
+	num(EnvRequestTimeout, &c.RequestTimeout)
+	num(EnvMaxRetries, &c.RateLimit.MaxRetries)
+	num(EnvMaxBackoff, &c.RateLimit.MaxBackoff)
+	boolean(EnvPause, &c.RateLimit.Pause)
+	num(EnvRemainingFloor, &c.RateLimit.RemainingFloor)
+	str(EnvMode, &c.Mode)
+
+	if len(errs) > 0 {
+		return &ValidationError{Problems: errs}
+	}
+	if used {
+		c.Sources = append(c.Sources, "environment")
+	}
+	return nil
+}
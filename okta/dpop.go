@@ -0,0 +1,133 @@
+package okta
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	headerDPoP      = "DPoP"
+	headerDPoPNonce = "DPoP-Nonce"
+	dpopNonceError  = "use_dpop_nonce"
+)
+
+// DPoPKey is the key pair that access tokens are bound to with Demonstrating Proof-of-Possession.
+// Orgs that require DPoP for the service app reject plain bearer tokens, set OAuthAuthenticator.DPoP
+// and every request gets a signed proof in the DPoP header.
+// https://developer.okta.com/docs/guides/dpop/nonoktaresourceserver/main/
+type DPoPKey struct {
+	key *ecdsa.PrivateKey
+
+	mu sync.Mutex
+	// last nonce each server (scheme://host) handed out
+	nonces map[string]string
+}
+
+// NewDPoPKey generates a new P-256 DPoP key pair. The key only lives in memory, a new one is
+// generated every time the program starts.
+func NewDPoPKey() (*DPoPKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewDPoPKeyFromECDSA(key)
+}
+
+// NewDPoPKeyFromECDSA uses an existing P-256, P-384 or P-521 key for DPoP proofs
+func NewDPoPKeyFromECDSA(key *ecdsa.PrivateKey) (*DPoPKey, error) {
+	if _, err := jwsAlgorithm(key); err != nil {
+		return nil, err
+	}
+	return &DPoPKey{key: key, nonces: make(map[string]string)}, nil
+}
+
+// PublicJWK returns the public key that is embedded in every proof
+func (k *DPoPKey) PublicJWK() *ClientJWK {
+	jwk, _ := NewClientJWK(&k.key.PublicKey, "")
+	return jwk
+}
+
+// Proof signs a DPoP proof JWT for a request. accessToken is empty for token requests, otherwise
+// its hash is included as the ath claim.
+func (k *DPoPKey) Proof(method string, u *url.URL, accessToken string) (string, error) {
+	jti, err := randomID()
+	if err != nil {
+		return "", err
+	}
+	jwk := k.PublicJWK()
+	header := map[string]interface{}{
+		"typ": "dpop+jwt",
+		"jwk": map[string]string{"kty": jwk.Kty, "crv": jwk.Crv, "x": jwk.X, "y": jwk.Y},
+	}
+	claims := map[string]interface{}{
+		"htm": method,
+		"htu": dpopHTU(u),
+		"iat": time.Now().Unix(),
+		"jti": jti,
+	}
+	if accessToken != "" {
+		sum := sha256.Sum256([]byte(accessToken))
+		claims["ath"] = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	if nonce := k.nonce(u); nonce != "" {
+		claims["nonce"] = nonce
+	}
+	return signJWT(k.key, header, claims)
+}
+
+// dpopHTU is the request URL without query and fragment
+func dpopHTU(u *url.URL) string {
+	htu := *u
+	htu.RawQuery = ""
+	htu.Fragment = ""
+	htu.User = nil
+	return htu.String()
+}
+
+func (k *DPoPKey) nonce(u *url.URL) string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.nonces[u.Scheme+"://"+u.Host]
+}
+
+// saveNonce remembers the DPoP-Nonce a server sent and reports whether it changed
+func (k *DPoPKey) saveNonce(u *url.URL, resp *http.Response) bool {
+	nonce := resp.Header.Get(headerDPoPNonce)
+	if nonce == "" {
+		return false
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	origin := u.Scheme + "://" + u.Host
+	changed := k.nonces[origin] != nonce
+	k.nonces[origin] = nonce
+	return changed
+}
+
+// dpopNonceRetry returns a copy of req with a new proof when the API answered with a DPoP nonce
+// challenge, or nil when the response should be returned as is. The challenge is a 401 with
+// WWW-Authenticate: DPoP error="use_dpop_nonce" and the nonce in the DPoP-Nonce header.
+func (c *Client) dpopNonceRetry(req *http.Request, resp *http.Response) *http.Request {
+	auth, ok := c.Authenticator.(*OAuthAuthenticator)
+	if !ok || auth.DPoP == nil || resp.StatusCode != http.StatusUnauthorized {
+		return nil
+	}
+	if !auth.DPoP.saveNonce(req.URL, resp) {
+		return nil
+	}
+	retry := rewindRequest(req)
+	if retry == nil {
+		return nil
+	}
+	if err := auth.Authorize(retry); err != nil {
+		return nil
+	}
+	return retry
+}
@@ -0,0 +1,140 @@
+package okta
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDPoPBoundRequests(t *testing.T) {
+	setup()
+	defer teardown()
+
+	dpopKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	dpop, err := NewDPoPKeyFromECDSA(dpopKey)
+	if err != nil {
+		t.Fatalf("NewDPoPKeyFromECDSA returned error: %v", err)
+	}
+
+	tokenCalls := 0
+	mux.HandleFunc("/oauth2/v1/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenCalls++
+		header, claims := verifyJWT(t, r.Header.Get("DPoP"), &dpopKey.PublicKey)
+		if header["typ"] != "dpop+jwt" || header["jwk"] == nil {
+			t.Errorf("DPoP proof header is %v", header)
+		}
+		if claims["htm"] != "POST" || claims["htu"] != server.URL+"/oauth2/v1/token" || claims["jti"] == nil || claims["iat"] == nil {
+			t.Errorf("token request DPoP proof claims are %v", claims)
+		}
+		if claims["ath"] != nil {
+			t.Errorf("token request DPoP proof should not have ath")
+		}
+		if claims["nonce"] != "token-nonce" {
+			w.Header().Set("DPoP-Nonce", "token-nonce")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"use_dpop_nonce","error_description":"Authorization server requires nonce in DPoP proof."}`)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"dpop-token","token_type":"DPoP","expires_in":3600}`)
+	})
+
+	apiCalls := 0
+	mux.HandleFunc("/groups/00g1/users/00u1", func(w http.ResponseWriter, r *http.Request) {
+		apiCalls++
+		if r.Header.Get("Authorization") != "DPoP dpop-token" {
+			t.Errorf("Authorization header should be DPoP dpop-token but got %v", r.Header.Get("Authorization"))
+		}
+		_, claims := verifyJWT(t, r.Header.Get("DPoP"), &dpopKey.PublicKey)
+		sum := sha256.Sum256([]byte("dpop-token"))
+		if claims["ath"] != base64.RawURLEncoding.EncodeToString(sum[:]) {
+			t.Errorf("DPoP proof ath should be the access token hash but got %v", claims["ath"])
+		}
+		if claims["htm"] != "PUT" || claims["htu"] != server.URL+"/groups/00g1/users/00u1" {
+			t.Errorf("DPoP proof claims are %v", claims)
+		}
+		if body, _ := ioutil.ReadAll(r.Body); string(body) != "{}\n" {
+			t.Errorf("request body should be sent with every attempt but got %q", body)
+		}
+		if claims["nonce"] != "api-nonce" {
+			w.Header().Set("DPoP-Nonce", "api-nonce")
+			w.Header().Set("WWW-Authenticate", `DPoP error="use_dpop_nonce"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	c, auth := newTestOAuthClient(t, key)
+	auth.Scopes = []string{"okta.groups.manage"}
+	auth.DPoP = dpop
+
+	req, err := c.NewRequest("PUT", "groups/00g1/users/00u1", struct{}{})
+	if err != nil {
+		t.Fatalf("NewRequest returned error: %v", err)
+	}
+	resp, err := c.Do(req, nil)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("status should be 204 but got %v", resp.StatusCode)
+	}
+	if tokenCalls != 2 || apiCalls != 2 {
+		t.Errorf("each nonce challenge should be retried once, got %v token and %v API calls", tokenCalls, apiCalls)
+	}
+}
+
+func TestDPoPProofRenewedOnRateLimitRetry(t *testing.T) {
+	setup()
+	defer teardown()
+
+	dpopKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	dpop, err := NewDPoPKeyFromECDSA(dpopKey)
+	if err != nil {
+		t.Fatalf("NewDPoPKeyFromECDSA returned error: %v", err)
+	}
+	mux.HandleFunc("/oauth2/v1/token", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"access_token":"dpop-token","token_type":"DPoP","expires_in":3600}`)
+	})
+
+	seen := map[interface{}]bool{}
+	mux.HandleFunc("/groups/00g1/users/00u1", func(w http.ResponseWriter, r *http.Request) {
+		_, claims := verifyJWT(t, r.Header.Get("DPoP"), &dpopKey.PublicKey)
+		if seen[claims["jti"]] {
+			t.Errorf("a retry should carry a new DPoP proof, jti %v was sent again", claims["jti"])
+		}
+		seen[claims["jti"]] = true
+		if len(seen) < 2 {
+			w.Header().Add(headerRateLimit, "600")
+			w.Header().Add(headerRateRemaining, "0")
+			w.Header().Add(headerRateReset, strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	c, auth := newTestOAuthClient(t, key)
+	auth.DPoP = dpop
+	c.MaxRetries = 1
+	c.MaxRetryWait = 10 * time.Millisecond
+
+	req, _ := c.NewRequest("PUT", "groups/00g1/users/00u1", nil)
+	if _, err := c.Do(req, nil); err != nil {
+		t.Fatalf("Do should succeed after retrying but got %v", err)
+	}
+	if len(seen) != 2 {
+		t.Errorf("the request should be sent twice, got %v", len(seen))
+	}
+}
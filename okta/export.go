@@ -0,0 +1,433 @@
+package okta
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats
+const (
+	ExportCSV       = "csv"
+	ExportJSONLines = "jsonl"
+)
+
+// Default export columns. Profile attributes are "profile." and the attribute name, nested values
+// are flattened with dots, e.g. "profile.costCenter".
+var (
+	DefaultUserExportColumns = []string{"id", "status", "created", "activated", "statusChanged", "lastLogin", "lastUpdated", "passwordChanged",
+		"profile.login", "profile.email", "profile.firstName", "profile.lastName"}
+	DefaultGroupExportColumns         = []string{"id", "type", "profile.name", "profile.description", "created", "lastUpdated", "lastMembershipUpdated"}
+	DefaultAppAssignmentExportColumns = []string{"app.id", "app.name", "app.label", "app.status", "type", "id", "userName", "scope", "status", "priority", "lastUpdated"}
+)
+
+// ExportService writes users, groups and app assignments to CSV or JSON Lines. Everything is read
+// one page at a time and written as it is read, so the org is never loaded into memory.
+type ExportService service
+
+// ExportOptions control an export
+type ExportOptions struct {
+	// Format is ExportCSV (the default) or ExportJSONLines
+	Format string
+	// Columns to write, in order. When empty CSV gets the Default*ExportColumns and JSON Lines every
+	// field.
+	Columns []string
+	// Filter is an OKTA filter expression for the list call, e.g. `status eq "ACTIVE"`
+	Filter string
+	// Limit is the page size. Defaults to 200.
+	Limit int
+	// UserGroups adds a "groups" column with the names of each user's groups (one call per user)
+	UserGroups bool
+	// UserFactors adds a "factors" column with each user's enrolled factors (one call per user)
+	UserFactors bool
+	// GroupMembers adds "memberCount" and a "members" column with the logins of each group's
+	// members. The members of a group are read page by page but kept until its row is written.
+	GroupMembers bool
+}
+
+func (opt *ExportOptions) listURL(path string) string {
+	limit := opt.Limit
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	q := url.Values{"limit": {strconv.Itoa(limit)}}
+	if opt.Filter != "" {
+		q.Set("filter", opt.Filter)
+	}
+	return path + "?" + q.Encode()
+}
+
+// Users writes every user and returns how many were written
+func (s *ExportService) Users(ctx context.Context, w io.Writer, opt *ExportOptions) (int, error) {
+	if opt == nil {
+		opt = new(ExportOptions)
+	}
+	defaults := DefaultUserExportColumns
+	if opt.UserGroups {
+		defaults = append(defaults[:len(defaults):len(defaults)], "groups")
+	}
+	if opt.UserFactors {
+		defaults = append(defaults[:len(defaults):len(defaults)], "factors")
+	}
+	out := newExportWriter(w, opt, defaults)
+
+	count := 0
+	err := s.listPages(ctx, opt.listURL("users"), func() (interface{}, func() error) {
+		var users []User
+		return &users, func() error {
+			for i := range users {
+				user := &users[i]
+				if opt.UserGroups {
+					if _, err := s.client.Users.PopulateGroups(user); err != nil {
+						return err
+					}
+				}
+				if opt.UserFactors {
+					if _, err := s.client.Users.PopulateEnrolledFactors(user); err != nil {
+						return err
+					}
+				}
+				if err := out.write(userRecord(user, opt)); err != nil {
+					return err
+				}
+				count++
+			}
+			return nil
+		}
+	})
+	if flushErr := out.flush(); err == nil {
+		err = flushErr
+	}
+	return count, err
+}
+
+// Groups writes every group, with the logins of its members when opt.GroupMembers is set, and
+// returns how many were written
+func (s *ExportService) Groups(ctx context.Context, w io.Writer, opt *ExportOptions) (int, error) {
+	if opt == nil {
+		opt = new(ExportOptions)
+	}
+	defaults := DefaultGroupExportColumns
+	if opt.GroupMembers {
+		defaults = append(defaults[:len(defaults):len(defaults)], "memberCount", "members")
+	}
+	out := newExportWriter(w, opt, defaults)
+
+	count := 0
+	err := s.listPages(ctx, opt.listURL("groups"), func() (interface{}, func() error) {
+		var groups []Group
+		return &groups, func() error {
+			for _, group := range groups {
+				record := map[string]interface{}{
+					"id":                    group.ID,
+					"type":                  group.Type,
+					"created":               exportTime(group.Created),
+					"lastUpdated":           exportTime(group.LastUpdated),
+					"lastMembershipUpdated": exportTime(group.LastMembershipUpdated),
+				}
+				flatten(record, "profile", group.Profile)
+				if opt.GroupMembers {
+					members := []interface{}{}
+					err := s.listPages(ctx, fmt.Sprintf("groups/%v/users?limit=%d", group.ID, defaultLimit), func() (interface{}, func() error) {
+						var users []User
+						return &users, func() error {
+							for _, user := range users {
+								members = append(members, user.Profile.Login)
+							}
+							return nil
+						}
+					})
+					if err != nil {
+						return err
+					}
+					record["memberCount"] = len(members)
+					record["members"] = members
+				}
+				if err := out.write(record); err != nil {
+					return err
+				}
+				count++
+			}
+			return nil
+		}
+	})
+	if flushErr := out.flush(); err == nil {
+		err = flushErr
+	}
+	return count, err
+}
+
+// AppAssignments writes one record per user and group assigned to each app and returns how many
+// were written. The "type" column is USER or GROUP and "id" is the user or group ID.
+func (s *ExportService) AppAssignments(ctx context.Context, w io.Writer, opt *ExportOptions) (int, error) {
+	if opt == nil {
+		opt = new(ExportOptions)
+	}
+	out := newExportWriter(w, opt, DefaultAppAssignmentExportColumns)
+
+	count := 0
+	err := s.listPages(ctx, opt.listURL("apps"), func() (interface{}, func() error) {
+		var apps []App
+		return &apps, func() error {
+			for _, app := range apps {
+				appFields := map[string]interface{}{"app.id": app.ID, "app.name": app.Name, "app.label": app.Label, "app.status": app.Status}
+				record := func(fields map[string]interface{}) error {
+					for k, v := range appFields {
+						fields[k] = v
+					}
+					if err := out.write(fields); err != nil {
+						return err
+					}
+					count++
+					return nil
+				}
+
+				err := s.listPages(ctx, fmt.Sprintf("apps/%v/users?limit=%d", app.ID, defaultLimit), func() (interface{}, func() error) {
+					var users []AppUser
+					return &users, func() error {
+						for _, user := range users {
+							fields := map[string]interface{}{
+								"type":        "USER",
+								"id":          user.ID,
+								"userName":    user.Credentials.UserName,
+								"scope":       user.Scope,
+								"status":      user.Status,
+								"lastUpdated": exportTime(user.LastUpdated),
+							}
+							flatten(fields, "profile", user.Profile)
+							if err := record(fields); err != nil {
+								return err
+							}
+						}
+						return nil
+					}
+				})
+				if err != nil {
+					return err
+				}
+
+				err = s.listPages(ctx, fmt.Sprintf("apps/%v/groups?limit=%d", app.ID, defaultLimit), func() (interface{}, func() error) {
+					var groups []AppGroups
+					return &groups, func() error {
+						for _, group := range groups {
+							fields := map[string]interface{}{
+								"type":        "GROUP",
+								"id":          group.ID,
+								"priority":    group.Priority,
+								"lastUpdated": exportTime(group.LastUpdated),
+							}
+							if err := record(fields); err != nil {
+								return err
+							}
+						}
+						return nil
+					}
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	})
+	if flushErr := out.flush(); err == nil {
+		err = flushErr
+	}
+	return count, err
+}
+
+// listPages GETs u and every following page. page is called before each request and returns where
+// to decode the page and what to do with it once it is decoded.
+func (s *ExportService) listPages(ctx context.Context, u string, page func() (v interface{}, done func() error)) error {
+	for u != "" {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		req, err := s.client.NewRequest("GET", u, nil)
+		if err != nil {
+			return err
+		}
+		v, done := page()
+		resp, err := s.client.Do(req.WithContext(ctx), v)
+		if err != nil {
+			return err
+		}
+		if err := done(); err != nil {
+			return err
+		}
+		u = ""
+		if resp.NextURL != nil {
+			u = resp.NextURL.String()
+		}
+	}
+	return nil
+}
+
+func userRecord(user *User, opt *ExportOptions) map[string]interface{} {
+	record := map[string]interface{}{
+		"id":              user.ID,
+		"status":          user.Status,
+		"created":         user.Created,
+		"activated":       user.Activated,
+		"statusChanged":   user.StatusChanged,
+		"lastLogin":       user.LastLogin,
+		"lastUpdated":     user.LastUpdated,
+		"passwordChanged": user.PasswordChanged,
+	}
+	flatten(record, "profile", user.Profile)
+	if opt.UserGroups {
+		groups := []interface{}{}
+		for _, group := range user.Groups {
+			groups = append(groups, group.Profile.Name)
+		}
+		record["groups"] = groups
+	}
+	if opt.UserFactors {
+		factors := []interface{}{}
+		for _, factor := range user.MFAFactors {
+			factors = append(factors, factor.FactorType+":"+factor.Provider)
+		}
+		record["factors"] = factors
+	}
+	return record
+}
+
+// flatten adds the JSON fields of v to record as prefix.field, with nested objects flattened too.
+// Empty values are left out.
+func flatten(record map[string]interface{}, prefix string, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return
+	}
+	flattenMap(record, prefix, fields)
+}
+
+func flattenMap(record map[string]interface{}, prefix string, fields map[string]interface{}) {
+	for name, value := range fields {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			if v != "" {
+				record[prefix+"."+name] = v
+			}
+		case map[string]interface{}:
+			flattenMap(record, prefix+"."+name, v)
+		default:
+			record[prefix+"."+name] = v
+		}
+	}
+}
+
+func exportTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// exportWriter writes records in the export format
+type exportWriter struct {
+	columns []string
+	csv     *csv.Writer
+	json    *json.Encoder
+	err     error
+}
+
+func newExportWriter(w io.Writer, opt *ExportOptions, defaults []string) *exportWriter {
+	out := &exportWriter{columns: opt.Columns}
+	switch opt.Format {
+	case ExportJSONLines:
+		out.json = json.NewEncoder(w)
+	case "", ExportCSV:
+		if len(out.columns) == 0 {
+			out.columns = defaults
+		}
+		out.csv = csv.NewWriter(w)
+		out.err = out.csv.Write(out.columns)
+	default:
+		out.err = fmt.Errorf("unknown export format %q", opt.Format)
+	}
+	return out
+}
+
+func (out *exportWriter) write(record map[string]interface{}) error {
+	if out.err != nil {
+		return out.err
+	}
+	if out.json != nil {
+		if len(out.columns) > 0 {
+			selected := make(map[string]interface{}, len(out.columns))
+			for _, column := range out.columns {
+				selected[column] = record[column]
+			}
+			record = selected
+		}
+		out.err = out.json.Encode(record)
+		return out.err
+	}
+
+	row := make([]string, len(out.columns))
+	for i, column := range out.columns {
+		row[i] = exportCell(record[column])
+	}
+	out.err = out.csv.Write(row)
+	return out.err
+}
+
+func (out *exportWriter) flush() error {
+	if out.csv != nil {
+		out.csv.Flush()
+		if out.err == nil {
+			out.err = out.csv.Error()
+		}
+	}
+	return out.err
+}
+
+// exportCell formats a value for CSV. Lists are joined with ";".
+func exportCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return CSVText(v)
+	case []interface{}:
+		cells := make([]string, len(v))
+		for i, item := range v {
+			cells[i] = exportCell(item)
+		}
+		return strings.Join(cells, ";")
+	case []string:
+		cells := make([]string, len(v))
+		for i, item := range v {
+			cells[i] = CSVText(item)
+		}
+		return strings.Join(cells, ";")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// CSVText keeps spreadsheets from running a CSV cell as a formula by prefixing values that
+// start like one (=, +, -, @, tab or carriage return) with '
+func CSVText(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
@@ -0,0 +1,109 @@
+package okta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExportUsers(t *testing.T) {
+	setup()
+	defer teardown()
+
+	filter := `status eq "ACTIVE"`
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if r.URL.Query().Get("after") == "" {
+			if got := r.URL.Query().Get("filter"); got != filter {
+				t.Errorf("filter should be %q, got %q", filter, got)
+			}
+			w.Header().Add("Link", fmt.Sprintf(`<%v/users?after=00u1>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"id":"00u1","status":"ACTIVE","profile":{"login":"anna@example.com","firstName":"Anna","costCenter":"10","badges":["a","b"],"address":{"city":"Oslo"}}}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"id":"00u2","status":"ACTIVE","profile":{"login":"bob@example.com","firstName":"Bob, Jr.","costCenter":"=HYPERLINK(\"http://x\")"}}]`)
+	})
+	mux.HandleFunc("/users/00u1/groups", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":"00g1","profile":{"name":"Everyone"}},{"id":"00g2","profile":{"name":"Engineering"}}]`)
+	})
+	mux.HandleFunc("/users/00u2/groups", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+
+	var buf bytes.Buffer
+	count, err := client.Export.Users(context.Background(), &buf, &ExportOptions{
+		Filter:     filter,
+		Columns:    []string{"id", "profile.login", "profile.firstName", "profile.costCenter", "profile.badges", "profile.address.city", "groups"},
+		UserGroups: true,
+	})
+	if err != nil || count != 2 {
+		t.Fatalf("Export.Users returned %v, %v", count, err)
+	}
+	want := `id,profile.login,profile.firstName,profile.costCenter,profile.badges,profile.address.city,groups
+00u1,anna@example.com,Anna,10,a;b,Oslo,Everyone;Engineering
+00u2,bob@example.com,"Bob, Jr.","'=HYPERLINK(""http://x"")",,,
+`
+	if buf.String() != want {
+		t.Errorf("CSV should be\n%v\nbut was\n%v", want, buf.String())
+	}
+
+	buf.Reset()
+	filter = ""
+	client.Export.Users(context.Background(), &buf, &ExportOptions{Format: ExportJSONLines})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var first map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || len(lines) != 2 {
+		t.Fatalf("should write 2 JSON lines, got %v (%v)", buf.String(), err)
+	}
+	if first["profile.costCenter"] != "10" || first["profile.address.city"] != "Oslo" || first["id"] != "00u1" {
+		t.Errorf("JSON lines should have every flattened field, got %v", first)
+	}
+}
+
+func TestExportGroupsAndAppAssignments(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/groups", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":"00g1","type":"OKTA_GROUP","profile":{"name":"Engineering"}}]`)
+	})
+	mux.HandleFunc("/groups/00g1/users", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":"00u1","profile":{"login":"anna@example.com"}},{"id":"00u2","profile":{"login":"bob@example.com"}}]`)
+	})
+	mux.HandleFunc("/apps", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":"0oa1","name":"salesforce","label":"Salesforce","status":"ACTIVE"}]`)
+	})
+	mux.HandleFunc("/apps/0oa1/users", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":"00u1","scope":"GROUP","status":"PROVISIONED","credentials":{"userName":"anna@example.com"}}]`)
+	})
+	mux.HandleFunc("/apps/0oa1/groups", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("after") == "" {
+			w.Header().Add("Link", fmt.Sprintf(`<%v/apps/0oa1/groups?after=00g1>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"id":"00g1","priority":0}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"id":"00g2","priority":1}]`)
+	})
+
+	var buf bytes.Buffer
+	if _, err := client.Export.Groups(context.Background(), &buf, &ExportOptions{Columns: []string{"id", "profile.name", "memberCount", "members"}, GroupMembers: true}); err != nil {
+		t.Fatalf("Export.Groups returned error: %v", err)
+	}
+	if want := "id,profile.name,memberCount,members\n00g1,Engineering,2,anna@example.com;bob@example.com\n"; buf.String() != want {
+		t.Errorf("groups CSV should be\n%v\nbut was\n%v", want, buf.String())
+	}
+
+	buf.Reset()
+	count, err := client.Export.AppAssignments(context.Background(), &buf, &ExportOptions{Columns: []string{"app.label", "type", "id", "userName", "priority"}})
+	if err != nil || count != 3 {
+		t.Fatalf("Export.AppAssignments returned %v, %v", count, err)
+	}
+	want := "app.label,type,id,userName,priority\nSalesforce,USER,00u1,anna@example.com,\nSalesforce,GROUP,00g1,,0\nSalesforce,GROUP,00g2,,1\n"
+	if buf.String() != want {
+		t.Errorf("app assignments CSV should be\n%v\nbut was\n%v", want, buf.String())
+	}
+}
@@ -0,0 +1,287 @@
+package okta
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	// GroupRuleStatusActive is the status of a group rule that is assigning users
+	GroupRuleStatusActive = "ACTIVE"
+	// GroupRuleStatusInactive is the status of a new or deactivated group rule. Only inactive rules can be updated
+	GroupRuleStatusInactive = "INACTIVE"
+	// GroupRuleStatusInvalid is the status of a rule whose expression or groups are no longer valid
+	GroupRuleStatusInvalid = "INVALID"
+
+	groupRuleType       = "group_rule"
+	groupRuleExpression = "urn:okta:expression:1.0"
+)
+
+// GroupRule is the model for a group rule. Users matching the expression are added to the groups
+// while the rule is ACTIVE.
+// https://developer.okta.com/docs/reference/api/groups/#group-rule-object
+type GroupRule struct {
+	ID          string     `json:"id,omitempty"`
+	Type        string     `json:"type"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status,omitempty"`
+	Created     *time.Time `json:"created,omitempty"`
+	LastUpdated *time.Time `json:"lastUpdated,omitempty"`
+	Conditions  struct {
+		People struct {
+			Users struct {
+				Exclude []string `json:"exclude"`
+			} `json:"users"`
+			Groups struct {
+				Exclude []string `json:"exclude"`
+			} `json:"groups"`
+		} `json:"people"`
+		Expression struct {
+			Value string `json:"value"`
+			Type  string `json:"type"`
+		} `json:"expression"`
+	} `json:"conditions"`
+	Actions struct {
+		AssignUserToGroups struct {
+			GroupIDs []string `json:"groupIds"`
+		} `json:"assignUserToGroups"`
+	} `json:"actions"`
+}
+
+// NewGroupRule returns a rule that assigns users matching an OKTA expression, e.g.
+// `user.department=="Engineering"`, to the groups
+func NewGroupRule(name string, expression string, groupIDs ...string) GroupRule {
+	rule := GroupRule{Type: groupRuleType, Name: name}
+	rule.Conditions.Expression.Type = groupRuleExpression
+	rule.Conditions.Expression.Value = expression
+	rule.Actions.AssignUserToGroups.GroupIDs = groupIDs
+	return rule
+}
+
+// Expression returns the rule's expression
+func (r GroupRule) Expression() string {
+	return r.Conditions.Expression.Value
+}
+
+// GroupIDs returns the groups the rule assigns users to
+func (r GroupRule) GroupIDs() []string {
+	return r.Actions.AssignUserToGroups.GroupIDs
+}
+
+func (r GroupRule) String() string {
+	return fmt.Sprintf("GroupRule:(ID: {%v} - Name: {%v} - Status: {%v})\n", r.ID, r.Name, r.Status)
+}
+
+// GroupRulesService handles the group rules of the OKTA API
+// https://developer.okta.com/docs/reference/api/groups/#group-rule-operations
+type GroupRulesService service
+
+// GroupRulesAPI is the method set of GroupRulesService.
+type GroupRulesAPI interface {
+	ListWithFilter(opt *GroupRuleFilterOptions) ([]GroupRule, *Response, error)
+	GetByID(ruleID string) (*GroupRule, *Response, error)
+	Create(rule GroupRule) (*GroupRule, *Response, error)
+	Update(rule GroupRule) (*GroupRule, *Response, error)
+	Activate(ruleID string) (*Response, error)
+	Deactivate(ruleID string) (*Response, error)
+	Delete(ruleID string) (*Response, error)
+}
+
+var _ GroupRulesAPI = (*GroupRulesService)(nil)
+
+// GroupRuleFilterOptions limits and pages the rules returned by ListWithFilter
+type GroupRuleFilterOptions struct {
+	// Search matches rule names that start with the value
+	Search        string   `url:"search,omitempty"`
+	Limit         int      `url:"limit,omitempty"`
+	NextURL       *url.URL `url:"-"`
+	GetAllPages   bool     `url:"-"`
+	NumberOfPages int      `url:"-"`
+}
+
+// ListWithFilter - Lists the group rules in the org. Pass in a GroupRuleFilterOptions to search and page the results
+func (g *GroupRulesService) ListWithFilter(opt *GroupRuleFilterOptions) ([]GroupRule, *Response, error) {
+
+	var u string
+	var err error
+
+	pagesRetreived := 0
+	if opt.NextURL != nil {
+		u = opt.NextURL.String()
+	} else {
+		if opt.Limit == 0 {
+			opt.Limit = defaultLimit
+		}
+		u, err = addOptions("groups/rules", opt)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	req, err := g.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var rules []GroupRule
+	resp, err := g.client.Do(req, &rules)
+	if err != nil {
+		return nil, resp, err
+	}
+	pagesRetreived++
+
+	if (opt.NumberOfPages > 0 && pagesRetreived < opt.NumberOfPages) || opt.GetAllPages {
+
+		for {
+
+			if pagesRetreived == opt.NumberOfPages {
+				break
+			}
+			if resp.NextURL != nil {
+				var rulePage []GroupRule
+				pageOption := new(GroupRuleFilterOptions)
+				pageOption.NextURL = resp.NextURL
+				pageOption.NumberOfPages = 1
+				pageOption.Limit = opt.Limit
+
+				rulePage, resp, err = g.ListWithFilter(pageOption)
+				if err != nil {
+					return rules, resp, err
+				}
+				rules = append(rules, rulePage...)
+				pagesRetreived++
+
+			} else {
+				break
+			}
+		}
+	}
+	return rules, resp, err
+}
+
+// GetByID - Returns a group rule by ID
+func (g *GroupRulesService) GetByID(ruleID string) (*GroupRule, *Response, error) {
+
+	if ruleID == "" {
+		return nil, nil, errors.New("ruleID parameter is required for GetByID")
+	}
+	u := fmt.Sprintf("groups/rules/%v", ruleID)
+	req, err := g.client.NewRequest("GET", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rule := new(GroupRule)
+	resp, err := g.client.Do(req, rule)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return rule, resp, err
+}
+
+// Create - Creates a group rule. OKTA creates rules INACTIVE, call Activate to start assigning users.
+func (g *GroupRulesService) Create(rule GroupRule) (*GroupRule, *Response, error) {
+
+	if rule.Name == "" {
+		return nil, nil, errors.New("rule name is required for Create")
+	}
+	if rule.Type == "" {
+		rule.Type = groupRuleType
+	}
+	if rule.Conditions.Expression.Type == "" {
+		rule.Conditions.Expression.Type = groupRuleExpression
+	}
+
+	req, err := g.client.NewRequest("POST", "groups/rules", rule)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	created := new(GroupRule)
+	resp, err := g.client.Do(req, created)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return created, resp, err
+}
+
+// Update - Replaces the name, expression and groups of a rule. OKTA only updates INACTIVE rules,
+// so an active rule has to be deactivated first.
+func (g *GroupRulesService) Update(rule GroupRule) (*GroupRule, *Response, error) {
+
+	if rule.ID == "" {
+		return nil, nil, errors.New("rule ID is required for Update")
+	}
+	if rule.Type == "" {
+		rule.Type = groupRuleType
+	}
+	if rule.Conditions.Expression.Type == "" {
+		rule.Conditions.Expression.Type = groupRuleExpression
+	}
+	// status is read only
+	rule.Status = ""
+
+	u := fmt.Sprintf("groups/rules/%v", rule.ID)
+	req, err := g.client.NewRequest("PUT", u, rule)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updated := new(GroupRule)
+	resp, err := g.client.Do(req, updated)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return updated, resp, err
+}
+
+// Activate - Activates a group rule
+func (g *GroupRulesService) Activate(ruleID string) (*Response, error) {
+	return g.ruleLifecycle(ruleID, "activate")
+}
+
+// Deactivate - Deactivates a group rule. Users it assigned stay in the groups.
+func (g *GroupRulesService) Deactivate(ruleID string) (*Response, error) {
+	return g.ruleLifecycle(ruleID, "deactivate")
+}
+
+func (g *GroupRulesService) ruleLifecycle(ruleID string, action string) (*Response, error) {
+
+	if ruleID == "" {
+		return nil, fmt.Errorf("ruleID parameter is required to %v a rule", action)
+	}
+	u := fmt.Sprintf("groups/rules/%v/lifecycle/%v", ruleID, action)
+	req, err := g.client.NewRequest("POST", u, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return g.client.Do(req, nil)
+}
+
+// Delete - Deletes a group rule. Users it assigned stay in the groups.
+func (g *GroupRulesService) Delete(ruleID string) (*Response, error) {
+
+	if ruleID == "" {
+		return nil, errors.New("ruleID parameter is required for Delete")
+	}
+	u := fmt.Sprintf("groups/rules/%v", ruleID)
+	req, err := g.client.NewRequest("DELETE", u, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return g.client.Do(req, nil)
+}
@@ -0,0 +1,44 @@
+package okta
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGroupRuleUpdate(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/groups/rules/0pr1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		testAuthHeader(t, r)
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if _, ok := body["status"]; ok || body["type"] != "group_rule" {
+			t.Errorf("Update should send the type and leave out the status, sent %v", body)
+		}
+		fmt.Fprint(w, `{"id":"0pr1","type":"group_rule","name":"Engineering","status":"INACTIVE",
+			"conditions":{"expression":{"value":"user.department==\"Eng\"","type":"urn:okta:expression:1.0"}},
+			"actions":{"assignUserToGroups":{"groupIds":["00g1","00g2"]}}}`)
+	})
+	mux.HandleFunc("/groups/rules/0pr1/lifecycle/activate", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rule := NewGroupRule("Engineering", `user.department=="Eng"`, "00g1", "00g2")
+	rule.ID = "0pr1"
+	rule.Status = GroupRuleStatusActive
+	updated, _, err := client.GroupRules.Update(rule)
+	if err != nil {
+		t.Fatalf("GroupRules.Update returned error: %v", err)
+	}
+	if updated.Expression() != `user.department=="Eng"` || len(updated.GroupIDs()) != 2 {
+		t.Errorf("GroupRules.Update returned %+v", updated)
+	}
+	if _, err := client.GroupRules.Activate("0pr1"); err != nil {
+		t.Errorf("GroupRules.Activate returned error: %v", err)
+	}
+}
@@ -25,6 +25,20 @@ const (
 // methods of the OKTA API.
 type GroupsService service
 
+// GroupsAPI is the method set of GroupsService. Group rules are on GroupRulesAPI.
+type GroupsAPI interface {
+	ListWithFilter(opt *GroupFilterOptions) ([]Group, *Response, error)
+	GetByID(groupID string) (*Group, *Response, error)
+	GetUsers(groupID string, opt *GroupUserFilterOptions) ([]User, *Response, error)
+	Add(groupName string, groupDescription string) (*Group, *Response, error)
+	Update(groupID string, groupName string, groupDescription string) (*Group, *Response, error)
+	Delete(groupID string) (*Response, error)
+	AddUserToGroup(groupID string, userID string) (*Response, error)
+	RemoveUserFromGroup(groupID string, userID string) (*Response, error)
+}
+
+var _ GroupsAPI = (*GroupsService)(nil)
+
 // Group represents the Group Object from the OKTA API
 type Group struct {
 	ID                    string    `json:"id"`
@@ -265,6 +279,39 @@ func (g *GroupsService) Add(groupName string, groupDescription string) (*Group,
 	return group, resp, err
 }
 
+// Update - Replaces the name and description of an OKTA Mastered Group
+func (g *GroupsService) Update(groupID string, groupName string, groupDescription string) (*Group, *Response, error) {
+
+	if groupID == "" {
+		return nil, nil, errors.New("groupID parameter is required for Update")
+	}
+	if groupName == "" {
+		return nil, nil, errors.New("groupName parameter is required for Update")
+	}
+
+	update := newGroup{}
+	update.Profile.Name = groupName
+	update.Profile.Description = groupDescription
+
+	u := fmt.Sprintf("groups/%v", groupID)
+
+	req, err := g.client.NewRequest("PUT", u, update)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	group := new(Group)
+
+	resp, err := g.client.Do(req, group)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return group, resp, err
+}
+
 // Delete - Deletes an OKTA Mastered Group with ID
 func (g *GroupsService) Delete(groupID string) (*Response, error) {
 
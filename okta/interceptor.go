@@ -0,0 +1,64 @@
+package okta
+
+import (
+	"net/http"
+)
+
+// Handler sends a request built by NewRequest and decodes the response into v, like Client.Do
+type Handler func(req *http.Request, v interface{}) (*Response, error)
+
+// Interceptor wraps every call made by Client.Do. It can change the request before calling next,
+// inspect the decoded Response (including Rate and OKTARequestID) after it returns, or return its
+// own Response or error without calling next at all.
+//
+//	client.Interceptors = append(client.Interceptors, func(req *http.Request, v interface{}, next okta.Handler) (*okta.Response, error) {
+//		resp, err := next(req, v)
+//		if resp != nil { // nil when the request failed before a response, e.g. read-only or a network error
+//			audit.Record(req.Method, req.URL.Path, resp.OKTARequestID)
+//		}
+//		return resp, err
+//	})
+//
+// Interceptors run in the order they are in Client.Interceptors, the first one is the outermost.
+// Authorization headers are already set when an interceptor sees the request.
+type Interceptor func(req *http.Request, v interface{}, next Handler) (*Response, error)
+
+// chain wraps handler with the client interceptors
+func (c *Client) chain(handler Handler) Handler {
+	for i := len(c.Interceptors) - 1; i >= 0; i-- {
+		interceptor, next := c.Interceptors[i], handler
+		handler = func(req *http.Request, v interface{}) (*Response, error) {
+			return interceptor(req, v, next)
+		}
+	}
+	return handler
+}
+
+// RequestMutator returns an Interceptor that calls fn on every request before it is sent.
+// The call fails with fn's error without being sent if fn returns one.
+func RequestMutator(fn func(req *http.Request) error) Interceptor {
+	return func(req *http.Request, v interface{}, next Handler) (*Response, error) {
+		if err := fn(req); err != nil {
+			return nil, err
+		}
+		return next(req, v)
+	}
+}
+
+// ResponseInspector returns an Interceptor that calls fn with every request and its result.
+// resp may be nil when err is set, e.g. for network errors.
+func ResponseInspector(fn func(req *http.Request, resp *Response, err error)) Interceptor {
+	return func(req *http.Request, v interface{}, next Handler) (*Response, error) {
+		resp, err := next(req, v)
+		fn(req, resp, err)
+		return resp, err
+	}
+}
+
+// SetHeader returns an Interceptor that sets a header on every request
+func SetHeader(name string, value string) Interceptor {
+	return RequestMutator(func(req *http.Request) error {
+		req.Header.Set(name, value)
+		return nil
+	})
+}
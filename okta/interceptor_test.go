@@ -0,0 +1,88 @@
+package okta
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestInterceptorChain(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/groups/00g1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Change-Ticket") != "CHG-42" {
+			t.Errorf("request mutator header missing")
+		}
+		w.Header().Set(headerOKTARequestID, "req-123")
+		w.Header().Set(headerRateLimit, "600")
+		w.Header().Set(headerRateRemaining, "599")
+		w.Header().Set(headerRateReset, "1700000000")
+		fmt.Fprint(w, `{"id":"00g1"}`)
+	})
+
+	var order []string
+	trace := func(name string) Interceptor {
+		return func(req *http.Request, v interface{}, next Handler) (*Response, error) {
+			order = append(order, name+" before")
+			resp, err := next(req, v)
+			order = append(order, name+" after")
+			return resp, err
+		}
+	}
+	var seenID string
+	var seenRemaining int
+	client.Interceptors = []Interceptor{
+		trace("outer"),
+		SetHeader("X-Change-Ticket", "CHG-42"),
+		ResponseInspector(func(req *http.Request, resp *Response, err error) {
+			seenID, seenRemaining = resp.OKTARequestID, resp.Rate.Remaining
+		}),
+		trace("inner"),
+	}
+
+	group, _, err := client.Groups.GetByID("00g1")
+	if err != nil {
+		t.Fatalf("Groups.GetByID returned error: %v", err)
+	}
+	if group.ID != "00g1" {
+		t.Errorf("response should still be decoded, got %+v", group)
+	}
+	want := []string{"outer before", "inner before", "inner after", "outer after"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("interceptors should run in order %v but ran %v", want, order)
+	}
+	if seenID != "req-123" || seenRemaining != 599 {
+		t.Errorf("ResponseInspector should see the decoded Response, got %v %v", seenID, seenRemaining)
+	}
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/groups/00g1/users/00u1", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request should not reach the server")
+	})
+
+	denied := errors.New("membership changes need a change ticket")
+	client.Interceptors = []Interceptor{
+		func(req *http.Request, v interface{}, next Handler) (*Response, error) {
+			if req.Method != "GET" {
+				return nil, denied
+			}
+			return next(req, v)
+		},
+	}
+
+	if _, err := client.Groups.AddUserToGroup("00g1", "00u1"); err != denied {
+		t.Errorf("AddUserToGroup should fail with the interceptor error but got %v", err)
+	}
+
+	client.Interceptors = []Interceptor{RequestMutator(func(req *http.Request) error { return denied })}
+	if _, _, err := client.Groups.GetByID("00g1"); err != denied {
+		t.Errorf("RequestMutator error should stop the call but got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package okta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const redactedValue = "REDACTED"
+
+// redactedHeaders never show up in logs
+var redactedHeaders = []string{headerAuthorization, headerDPoP, "Cookie", "Set-Cookie"}
+
+// secretFields are JSON keys whose values are always redacted from logged bodies, wherever they are
+var secretFields = map[string]bool{
+	"password":          true,
+	"answer":            true,
+	"recovery_question": true,
+	"client_secret":     true,
+	"access_token":      true,
+	"client_assertion":  true,
+	"passCode":          true,
+	"sharedSecret":      true,
+	"activationToken":   true,
+	"resetPasswordUrl":  true,
+	"activationUrl":     true,
+}
+
+// DefaultLogRedactProfileFields are the user profile attributes redacted from logged bodies
+// unless Client.LogRedactProfileFields is changed
+var DefaultLogRedactProfileFields = []string{
+	"email", "secondEmail", "mobilePhone", "primaryPhone", "streetAddress", "postalAddress", "zipCode",
+}
+
+// send makes one HTTP round trip and logs it when Client.Logger is set
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if c.Logger == nil {
+		return c.client.Do(req)
+	}
+
+	var reqBody []byte
+	if c.LogBodies && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = ioutil.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	duration := time.Since(start)
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", redactPath(req.URL.Path)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Duration("duration", duration), slog.String("error", err.Error()))
+		c.Logger.LogAttrs(req.Context(), slog.LevelError, "okta request failed", attrs...)
+		return resp, err
+	}
+
+	rate := parseRate(resp)
+	attrs = append(attrs,
+		slog.Int("status", resp.StatusCode),
+		slog.Duration("duration", duration),
+		slog.String("request_id", resp.Header.Get(headerOKTARequestID)),
+	)
+	if !rate.ResetTime.IsZero() {
+		attrs = append(attrs,
+			slog.Int("rate_limit", rate.RatePerMinuteLimit),
+			slog.Int("rate_remaining", rate.Remaining),
+			slog.Time("rate_reset", rate.ResetTime),
+		)
+	}
+
+	level := slog.LevelInfo
+	if resp.StatusCode >= 400 {
+		level = slog.LevelWarn
+	}
+
+	if c.LogBodies {
+		respBody, readErr := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+		if readErr != nil {
+			return resp, readErr
+		}
+		attrs = append(attrs,
+			slog.String("query", c.redactQuery(req.URL.RawQuery)),
+			slog.Any("request_headers", redactHeaders(req.Header)),
+			slog.String("request_body", c.redactBody(reqBody)),
+			slog.Any("response_headers", redactHeaders(resp.Header)),
+			slog.String("response_body", c.redactBody(respBody)),
+		)
+	}
+	c.Logger.LogAttrs(req.Context(), level, "okta request", attrs...)
+	return resp, nil
+}
+
+// logRateLimitPause records that Do is waiting for the rate limit to reset
+func (c *Client) logRateLimitPause(ctx context.Context, rate Rate, wait time.Duration) {
+	if c.Logger == nil {
+		return
+	}
+	c.Logger.LogAttrs(ctx, slog.LevelWarn, "okta rate limit pause",
+		slog.Int("rate_limit", rate.RatePerMinuteLimit),
+		slog.Int("rate_remaining", rate.Remaining),
+		slog.Time("rate_reset", rate.ResetTime),
+		slog.Duration("pause", wait),
+	)
+}
+
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		out[k] = strings.Join(v, ", ")
+	}
+	for _, name := range redactedHeaders {
+		if h.Get(name) != "" {
+			out[http.CanonicalHeaderKey(name)] = redactedValue
+		}
+	}
+	return out
+}
+
+// redactPath hides the path segments that name something other than by OKTA ID, like the login
+// in users/anna@example.com. Fixed segments (lifecycle, credentials, ...) and IDs are kept.
+func redactPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		switch {
+		case strings.IndexFunc(segment, func(r rune) bool { return !isPathRune(r) }) >= 0:
+			segments[i] = redactedValue
+		case i > 0 && segments[i-1] == "users" && segment != "" && segment != "me" && !strings.HasPrefix(segment, "0"):
+			// users/{id}: OKTA IDs start with 0 (00u...), anything else is a login
+			segments[i] = redactedValue
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// redactURL is the URL with redactPath and redactQuery applied
+func (c *Client) redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.Path = redactPath(u.Path)
+	redacted.RawPath = ""
+	redacted.RawQuery = c.redactQuery(u.RawQuery)
+	return redacted.String()
+}
+
+// isPathRune reports whether r can be part of a fixed path segment or an OKTA ID
+func isPathRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_'
+}
+
+// redactQuery hides the search terms (q, search and filter), which can hold names, logins or
+// other profile values, unless LogRedactProfileFields is empty
+func (c *Client) redactQuery(query string) string {
+	if query == "" || len(c.LogRedactProfileFields) == 0 {
+		return query
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return redactedValue
+	}
+	for _, name := range []string{"q", "search", "filter"} {
+		if _, ok := values[name]; ok {
+			values.Set(name, redactedValue)
+		}
+	}
+	return values.Encode()
+}
+
+// redactBody redacts secrets and the configured profile fields from a JSON body.
+// Bodies that aren't JSON are left out entirely.
+func (c *Client) redactBody(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return redactedValue
+	}
+	out, err := json.Marshal(c.redactValue(v, false))
+	if err != nil {
+		return redactedValue
+	}
+	return string(out)
+}
+
+func (c *Client) redactValue(v interface{}, inProfile bool) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, child := range value {
+			if secretFields[k] || (inProfile && c.redactProfileField(k)) {
+				value[k] = redactedValue
+				continue
+			}
+			value[k] = c.redactValue(child, k == "profile")
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = c.redactValue(child, inProfile)
+		}
+	}
+	return v
+}
+
+func (c *Client) redactProfileField(name string) bool {
+	for _, f := range c.LogRedactProfileFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package okta
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClientLogging(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Okta-Request-Id", "req-123")
+		w.Header().Set(headerRateLimit, "600")
+		w.Header().Set(headerRateRemaining, "599")
+		w.Header().Set(headerRateReset, "1700000000")
+		fmt.Fprint(w, `{"id":"00u1","status":"ACTIVE","profile":{"login":"isaac.brock@example.com","email":"isaac.brock@example.com","mobilePhone":"555-0100"}}`)
+	})
+
+	var buf bytes.Buffer
+	client.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+
+	newUser := client.Users.NewUser()
+	newUser.Profile.Login = "isaac.brock@example.com"
+	newUser.Profile.Email = "isaac.brock@example.com"
+	newUser.SetPassword("Sup3rS3cret!")
+	newUser.SetRecoveryQuestion("Best band?", "Modest Mouse")
+	if _, _, err := client.Users.Create(newUser, true); err != nil {
+		t.Fatalf("Users.Create returned error: %v", err)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("log record is not JSON: %v %s", err, buf.Bytes())
+	}
+	want := map[string]interface{}{
+		"msg":            "okta request",
+		"method":         "POST",
+		"path":           "/users",
+		"status":         float64(200),
+		"request_id":     "req-123",
+		"rate_limit":     float64(600),
+		"rate_remaining": float64(599),
+	}
+	for k, v := range want {
+		if record[k] != v {
+			t.Errorf("log %v should be %v but got %v", k, v, record[k])
+		}
+	}
+	if _, ok := record["request_body"]; ok {
+		t.Errorf("bodies should only be logged with LogBodies")
+	}
+
+	buf.Reset()
+	client.LogBodies = true
+	if _, _, err := client.Users.Create(newUser, true); err != nil {
+		t.Fatalf("Users.Create returned error: %v", err)
+	}
+	out := buf.String()
+	for _, secret := range []string{testToken, "Sup3rS3cret!", "Modest Mouse", "555-0100"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log should not contain %q: %v", secret, out)
+		}
+	}
+	for _, kept := range []string{`\"login\":\"isaac.brock@example.com\"`, `\"status\":\"ACTIVE\"`, `\"email\":\"REDACTED\"`, `"Authorization":"REDACTED"`} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("log should contain %v: %v", kept, out)
+		}
+	}
+}
+
+func TestClientLoggingRedactsPathAndSearch(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"00u1"}`)
+	})
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+
+	var buf bytes.Buffer
+	client.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	client.LogBodies = true
+
+	client.Users.GetByID("isaac.brock@example.com")
+	client.Users.GetByID("ibrock")
+	client.Users.GetByID("00u1")
+	client.Users.ListWithFilter(&UserListFilterOptions{Q: "Isaac", Search: `profile.lastName eq "Brock"`, Limit: 10})
+
+	out := buf.String()
+	for _, secret := range []string{"isaac.brock", "ibrock", "Isaac", "Brock"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log should not contain %q: %v", secret, out)
+		}
+	}
+	for _, kept := range []string{`"path":"/users/REDACTED"`, `"path":"/users/00u1"`, "limit=10", "q=REDACTED", "search=REDACTED"} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("log should contain %v: %v", kept, out)
+		}
+	}
+
+	buf.Reset()
+	client.LogRedactProfileFields = nil
+	client.Users.ListWithFilter(&UserListFilterOptions{Q: "Isaac"})
+	if !strings.Contains(buf.String(), "q=Isaac") {
+		t.Errorf("search terms should only be redacted while LogRedactProfileFields is set: %v", buf.String())
+	}
+}
@@ -0,0 +1,245 @@
+package okta
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	// LogSortOrderAscending - sort order for oldest System Log events first
+	LogSortOrderAscending = "ASCENDING"
+	// LogSortOrderDescending - sort order for newest System Log events first
+	LogSortOrderDescending = "DESCENDING"
+
+	// LogOutcomeSuccess - LogEvent Outcome.Result for a successful action
+	LogOutcomeSuccess = "SUCCESS"
+	// LogOutcomeFailure - LogEvent Outcome.Result for a failed action
+	LogOutcomeFailure = "FAILURE"
+)
+
+// LogsService handles communication with the System Log
+// methods of the OKTA API.
+// https://developer.okta.com/docs/reference/api/system-log/
+type LogsService service
+
+// LogsAPI is the method set of LogsService.
+type LogsAPI interface {
+	ListWithFilter(opt *LogFilterOptions) ([]LogEvent, *Response, error)
+	TailFunc(ctx context.Context, opt *LogTailOptions, fn func(LogEvent) error) error
+	Tail(ctx context.Context, opt *LogTailOptions) *LogTail
+}
+
+var _ LogsAPI = (*LogsService)(nil)
+
+// LogFilterOptions is used to query the System Log. The values here coorelate to
+// the query parameters of the /logs API
+type LogFilterOptions struct {
+	// Since and Until bound the published time of the events. When Since is set and Until is not
+	// OKTA treats the request as a polling request and always returns a "next" link.
+	Since time.Time `url:"since,omitempty"`
+	Until time.Time `url:"until,omitempty"`
+
+	// Filter is a SCIM filter expression such as: eventType eq "user.session.start"
+	Filter string `url:"filter,omitempty"`
+	// Q is a keyword search across the event
+	Q         string `url:"q,omitempty"`
+	SortOrder string `url:"sortOrder,omitempty"`
+	Limit     int    `url:"limit,omitempty"`
+
+	NextURL       *url.URL `url:"-"`
+	GetAllPages   bool     `url:"-"`
+	NumberOfPages int      `url:"-"`
+}
+
+// LogEvent is the model for a System Log event
+// https://developer.okta.com/docs/reference/api/system-log/#logevent-object
+type LogEvent struct {
+	UUID                  string                   `json:"uuid"`
+	Published             time.Time                `json:"published"`
+	EventType             string                   `json:"eventType"`
+	Version               string                   `json:"version"`
+	Severity              string                   `json:"severity"`
+	LegacyEventType       string                   `json:"legacyEventType"`
+	DisplayMessage        string                   `json:"displayMessage"`
+	Actor                 LogActor                 `json:"actor"`
+	Client                LogClient                `json:"client"`
+	Outcome               LogOutcome               `json:"outcome"`
+	Target                []LogTarget              `json:"target"`
+	Transaction           LogTransaction           `json:"transaction"`
+	DebugContext          LogDebugContext          `json:"debugContext"`
+	AuthenticationContext LogAuthenticationContext `json:"authenticationContext"`
+	SecurityContext       LogSecurityContext       `json:"securityContext"`
+	Request               LogRequest               `json:"request"`
+}
+
+func (e LogEvent) String() string {
+	return fmt.Sprintf("LogEvent:(UUID: {%v} - EventType: {%v} - Published: {%v})\n", e.UUID, e.EventType, e.Published)
+}
+
+// LogActor describes the user, app, client or other entity that performed an action
+type LogActor struct {
+	ID          string                 `json:"id"`
+	Type        string                 `json:"type"`
+	AlternateID string                 `json:"alternateId"`
+	DisplayName string                 `json:"displayName"`
+	DetailEntry map[string]interface{} `json:"detailEntry"`
+}
+
+// LogTarget describes an entity an action was performed on
+type LogTarget struct {
+	ID          string                 `json:"id"`
+	Type        string                 `json:"type"`
+	AlternateID string                 `json:"alternateId"`
+	DisplayName string                 `json:"displayName"`
+	DetailEntry map[string]interface{} `json:"detailEntry"`
+}
+
+// LogClient describes the client that made the request which caused the event
+type LogClient struct {
+	ID                  string                 `json:"id"`
+	UserAgent           LogUserAgent           `json:"userAgent"`
+	Zone                string                 `json:"zone"`
+	Device              string                 `json:"device"`
+	IPAddress           string                 `json:"ipAddress"`
+	GeographicalContext LogGeographicalContext `json:"geographicalContext"`
+}
+
+// LogUserAgent is the parsed user agent of the client
+type LogUserAgent struct {
+	RawUserAgent string `json:"rawUserAgent"`
+	OS           string `json:"os"`
+	Browser      string `json:"browser"`
+}
+
+// LogGeographicalContext is the location OKTA derived from an IP address
+type LogGeographicalContext struct {
+	City        string `json:"city"`
+	State       string `json:"state"`
+	Country     string `json:"country"`
+	PostalCode  string `json:"postalCode"`
+	Geolocation struct {
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	} `json:"geolocation"`
+}
+
+// LogOutcome is the result of the action. Result is one of SUCCESS, FAILURE, SKIPPED, ALLOW, DENY, CHALLENGE or UNKNOWN
+type LogOutcome struct {
+	Result string `json:"result"`
+	Reason string `json:"reason"`
+}
+
+// LogTransaction groups events that happened in the same request or job
+type LogTransaction struct {
+	ID     string                 `json:"id"`
+	Type   string                 `json:"type"`
+	Detail map[string]interface{} `json:"detail"`
+}
+
+// LogDebugContext holds free form debug data OKTA attaches to an event
+type LogDebugContext struct {
+	DebugData map[string]interface{} `json:"debugData"`
+}
+
+// LogAuthenticationContext describes how the actor authenticated
+type LogAuthenticationContext struct {
+	AuthenticationProvider string `json:"authenticationProvider"`
+	CredentialProvider     string `json:"credentialProvider"`
+	CredentialType         string `json:"credentialType"`
+	Issuer                 struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	} `json:"issuer"`
+	Interface          string `json:"interface"`
+	AuthenticationStep int    `json:"authenticationStep"`
+	ExternalSessionID  string `json:"externalSessionId"`
+}
+
+// LogSecurityContext describes the network the request came from
+type LogSecurityContext struct {
+	AsNumber int    `json:"asNumber"`
+	AsOrg    string `json:"asOrg"`
+	ISP      string `json:"isp"`
+	Domain   string `json:"domain"`
+	IsProxy  bool   `json:"isProxy"`
+}
+
+// LogRequest holds the chain of IP addresses the request passed through
+type LogRequest struct {
+	IPChain []LogIPAddress `json:"ipChain"`
+}
+
+// LogIPAddress is one hop in LogRequest.IPChain
+type LogIPAddress struct {
+	IP                  string                 `json:"ip"`
+	GeographicalContext LogGeographicalContext `json:"geographicalContext"`
+	Version             string                 `json:"version"`
+	Source              string                 `json:"source"`
+}
+
+// ListWithFilter - Method to query the System Log.
+//
+//	Pass in a LogFilterOptions to specify filters. Values in that struct will turn into Query parameters.
+//	In polling mode (Since set, Until not set) paging stops at the first empty page instead of following the "next" link forever.
+func (l *LogsService) ListWithFilter(opt *LogFilterOptions) ([]LogEvent, *Response, error) {
+
+	var u string
+	var err error
+
+	pagesRetreived := 0
+	if opt.NextURL != nil {
+		u = opt.NextURL.String()
+	} else {
+		if opt.Limit == 0 {
+			opt.Limit = defaultLimit
+		}
+		u, err = addOptions("logs", opt)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	req, err := l.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var events []LogEvent
+	resp, err := l.client.Do(req, &events)
+	if err != nil {
+		return nil, resp, err
+	}
+	pagesRetreived++
+
+	if (opt.NumberOfPages > 0 && pagesRetreived < opt.NumberOfPages) || opt.GetAllPages {
+
+		for {
+
+			if pagesRetreived == opt.NumberOfPages {
+				break
+			}
+			if resp.NextURL != nil {
+				var eventPage []LogEvent
+				pageOption := new(LogFilterOptions)
+				pageOption.NextURL = resp.NextURL
+				pageOption.NumberOfPages = 1
+				pageOption.Limit = opt.Limit
+
+				eventPage, resp, err = l.ListWithFilter(pageOption)
+				if err != nil {
+					return events, resp, err
+				}
+				if len(eventPage) == 0 {
+					break
+				}
+				events = append(events, eventPage...)
+				pagesRetreived++
+
+			} else {
+				break
+			}
+		}
+	}
+	return events, resp, err
+}
@@ -0,0 +1,101 @@
+package okta
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var logEventTestJSONString = `
+{
+  "uuid": "dc9fd3c0-598c-11ef-8478-2b7584bf8d5a",
+  "published": "2024-08-13T15:58:20.353Z",
+  "eventType": "user.session.start",
+  "version": "0",
+  "severity": "INFO",
+  "legacyEventType": "core.user_auth.login_success",
+  "displayMessage": "User login to Okta",
+  "actor": {"id": "00u1", "type": "User", "alternateId": "isaac.brock@example.com", "displayName": "Isaac Brock"},
+  "client": {
+    "userAgent": {"rawUserAgent": "Mozilla/5.0", "os": "Mac OS X", "browser": "CHROME"},
+    "zone": "null",
+    "device": "Computer",
+    "ipAddress": "10.0.0.1",
+    "geographicalContext": {"city": "San Francisco", "state": "California", "country": "United States", "postalCode": "94107", "geolocation": {"lat": 37.7, "lon": -122.4}}
+  },
+  "outcome": {"result": "SUCCESS"},
+  "target": [{"id": "0oa1", "type": "AppInstance", "alternateId": "Salesforce", "displayName": "Salesforce.com"}],
+  "transaction": {"type": "WEB", "id": "Zrt", "detail": {}},
+  "debugContext": {"debugData": {"requestUri": "/api/v1/authn"}},
+  "authenticationContext": {"authenticationStep": 0, "externalSessionId": "102abc", "credentialType": "PASSWORD"},
+  "securityContext": {"asNumber": 7922, "asOrg": "comcast", "isp": "comcast", "domain": "comcast.net", "isProxy": false},
+  "request": {"ipChain": [{"ip": "10.0.0.1", "version": "V4"}]}
+}`
+
+func TestLogsListWithFilter(t *testing.T) {
+	setup()
+	defer teardown()
+
+	since := time.Date(2024, 8, 13, 0, 0, 0, 0, time.UTC)
+
+	mux.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testAuthHeader(t, r)
+		q := r.URL.Query()
+		if q.Get("after") == "" {
+			if got := q.Get("since"); got != "2024-08-13T00:00:00Z" {
+				t.Errorf("since %v, want 2024-08-13T00:00:00Z", got)
+			}
+			if got := q.Get("until"); got != "" {
+				t.Errorf("until should not be sent, got %v", got)
+			}
+			if got := q.Get("filter"); got != `eventType eq "user.session.start"` {
+				t.Errorf("filter %v", got)
+			}
+			if got := q.Get("sortOrder"); got != LogSortOrderAscending {
+				t.Errorf("sortOrder %v", got)
+			}
+			w.Header().Add("Link", fmt.Sprintf(`<%v/logs?after=1>; rel="next"`, server.URL))
+			fmt.Fprintf(w, "[%v]", logEventTestJSONString)
+			return
+		}
+		// Polling mode always returns a next link
+		w.Header().Add("Link", fmt.Sprintf(`<%v/logs?after=2>; rel="next"`, server.URL))
+		fmt.Fprint(w, "[]")
+	})
+
+	opt := &LogFilterOptions{
+		Since:       since,
+		Filter:      `eventType eq "user.session.start"`,
+		SortOrder:   LogSortOrderAscending,
+		GetAllPages: true,
+	}
+	events, resp, err := client.Logs.ListWithFilter(opt)
+	if err != nil {
+		t.Fatalf("Logs.ListWithFilter returned error: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Logs.ListWithFilter returned %v events, want 1", len(events))
+	}
+	if resp.NextURL == nil || resp.NextURL.Query().Get("after") != "2" {
+		t.Errorf("Expected the last polling cursor to be returned, got %v", resp.NextURL)
+	}
+
+	e := events[0]
+	if e.Actor.AlternateID != "isaac.brock@example.com" || e.Outcome.Result != LogOutcomeSuccess {
+		t.Errorf("Unexpected actor/outcome %+v %+v", e.Actor, e.Outcome)
+	}
+	if e.Client.GeographicalContext.Geolocation.Lat != 37.7 || e.SecurityContext.AsNumber != 7922 {
+		t.Errorf("Unexpected client/security context %+v %+v", e.Client, e.SecurityContext)
+	}
+	if len(e.Target) != 1 || e.Target[0].Type != "AppInstance" {
+		t.Errorf("Unexpected targets %+v", e.Target)
+	}
+	if e.DebugContext.DebugData["requestUri"] != "/api/v1/authn" {
+		t.Errorf("Unexpected debug context %+v", e.DebugContext)
+	}
+	if e.AuthenticationContext.ExternalSessionID != "102abc" || len(e.Request.IPChain) != 1 {
+		t.Errorf("Unexpected authentication context or request %+v %+v", e.AuthenticationContext, e.Request)
+	}
+}
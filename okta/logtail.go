@@ -0,0 +1,280 @@
+package okta
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLogPollInterval = 10 * time.Second
+)
+
+// LogCursorStore persists the polling cursor of a System Log tail so a restarted process
+// resumes right after the last event it handled. The cursor is the "next" link OKTA returned and
+// delivered the uuids of the events of that page already handled.
+type LogCursorStore interface {
+	// LoadCursor returns the saved cursor or "" when there is none
+	LoadCursor() (cursor string, delivered []string, err error)
+	SaveCursor(cursor string, delivered []string) error
+}
+
+// MemoryLogCursorStore keeps the cursor in memory. It is useful for tests and for
+// processes that tail the log without needing to survive a restart.
+type MemoryLogCursorStore struct {
+	mu        sync.Mutex
+	cursor    string
+	delivered []string
+}
+
+// LoadCursor returns the cursor saved in memory
+func (m *MemoryLogCursorStore) LoadCursor() (string, []string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cursor, append([]string(nil), m.delivered...), nil
+}
+
+// SaveCursor keeps the cursor in memory
+func (m *MemoryLogCursorStore) SaveCursor(cursor string, delivered []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cursor = cursor
+	m.delivered = append([]string(nil), delivered...)
+	return nil
+}
+
+// FileLogCursorStore keeps the cursor in a file, on the first line, followed by the delivered
+// uuids one per line. The file is replaced atomically on every save so a crash never leaves a half
+// written cursor behind.
+type FileLogCursorStore struct {
+	Path string
+}
+
+// LoadCursor reads the cursor from the file. A missing file is not an error.
+func (f *FileLogCursorStore) LoadCursor() (string, []string, error) {
+	data, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	lines := strings.Fields(string(data))
+	if len(lines) == 0 {
+		return "", nil, nil
+	}
+	return lines[0], lines[1:], nil
+}
+
+// SaveCursor writes the cursor to a temporary file and renames it over Path
+func (f *FileLogCursorStore) SaveCursor(cursor string, delivered []string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.WriteString(strings.Join(append([]string{cursor}, delivered...), "\n") + "\n"); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// LogTailOptions controls a continuous System Log tail
+type LogTailOptions struct {
+	// Since is where the tail starts when the cursor store is empty. Defaults to now.
+	Since time.Time
+	// Filter and Q are passed through to the /logs API. They are ignored when resuming from a cursor
+	// because the cursor already carries them.
+	Filter string
+	Q      string
+	Limit  int
+
+	// PollInterval is how long to wait before polling again after a page that was not full. Defaults to 10 seconds.
+	PollInterval time.Duration
+
+	// Cursors stores the polling cursor between pages. Defaults to an in memory store.
+	Cursors LogCursorStore
+}
+
+// TailFunc polls the System Log forever, calling fn for every event in published order.
+// After fn returns nil for an event its uuid is saved with the cursor of its page, so a restart
+// with the same LogCursorStore resumes without gaps or duplicates, even in the middle of a page.
+// Only an event whose fn call didn't return nil is delivered again.
+//
+// A page without a next link is polled again and only its new events, by uuid, are passed to fn.
+//
+// The client retries 429s itself (Client.MaxRetries). A RateLimitError that still comes back pauses
+// the tail until the rate limit resets. TailFunc returns when ctx is done, fn returns an error, or
+// any other API error occurs. opt isn't changed.
+func (l *LogsService) TailFunc(ctx context.Context, opt *LogTailOptions, fn func(LogEvent) error) error {
+
+	var o LogTailOptions
+	if opt != nil {
+		o = *opt
+	}
+	if o.Cursors == nil {
+		o.Cursors = new(MemoryLogCursorStore)
+	}
+	if o.PollInterval == 0 {
+		o.PollInterval = defaultLogPollInterval
+	}
+	if o.Limit == 0 {
+		o.Limit = defaultLimit
+	}
+
+	next, handled, err := l.tailStart(&o)
+	if err != nil {
+		return err
+	}
+	// uuids of the events of next already passed to fn, in order for the cursor store
+	delivered := map[string]bool{}
+	for _, uuid := range handled {
+		delivered[uuid] = true
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		req, err := l.client.NewRequest("GET", next, nil)
+		if err != nil {
+			return err
+		}
+		req = req.WithContext(ctx)
+
+		var events []LogEvent
+		resp, err := l.client.Do(req, &events)
+		if err != nil {
+			if rateErr, ok := err.(*RateLimitError); ok {
+				if err := sleepContext(ctx, l.client.retryWait(rateErr.Rate)); err != nil {
+					return err
+				}
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		for _, event := range events {
+			if delivered[event.UUID] {
+				continue
+			}
+			if err := fn(event); err != nil {
+				return err
+			}
+			delivered[event.UUID] = true
+			handled = append(handled, event.UUID)
+			if err := o.Cursors.SaveCursor(next, handled); err != nil {
+				return err
+			}
+		}
+
+		if resp.NextURL != nil && resp.NextURL.String() != next {
+			next = resp.NextURL.String()
+			delivered, handled = map[string]bool{}, nil
+			if err := o.Cursors.SaveCursor(next, nil); err != nil {
+				return err
+			}
+		}
+
+		if len(events) < o.Limit {
+			if err := sleepContext(ctx, o.PollInterval); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// LogTail is a running System Log tail started by LogsService.Tail
+type LogTail struct {
+	events chan LogEvent
+	done   chan struct{}
+	err    error
+}
+
+// Events returns the channel of events. It is closed when the tail stops.
+func (t *LogTail) Events() <-chan LogEvent {
+	return t.events
+}
+
+// Err returns the reason the tail stopped. It blocks until the Events channel is closed.
+// The error is context.Canceled when the tail was stopped by cancelling its context.
+func (t *LogTail) Err() error {
+	<-t.done
+	return t.err
+}
+
+// Tail starts TailFunc in a goroutine and delivers the events on a channel.
+// An event is saved as delivered once it has been received from the channel.
+// Cancel ctx to stop the tail.
+func (l *LogsService) Tail(ctx context.Context, opt *LogTailOptions) *LogTail {
+	t := &LogTail{
+		events: make(chan LogEvent),
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(t.done)
+		defer close(t.events)
+		t.err = l.TailFunc(ctx, opt, func(event LogEvent) error {
+			select {
+			case t.events <- event:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	return t
+}
+
+// tailStart returns the URL to poll first and the uuids of its events already delivered
+func (l *LogsService) tailStart(opt *LogTailOptions) (string, []string, error) {
+	cursor, delivered, err := opt.Cursors.LoadCursor()
+	if err != nil {
+		return "", nil, err
+	}
+	if cursor != "" {
+		if _, err := url.Parse(cursor); err != nil {
+			return "", nil, err
+		}
+		return cursor, delivered, nil
+	}
+
+	since := opt.Since
+	if since.IsZero() {
+		since = time.Now()
+	}
+	start, err := addOptions("logs", &LogFilterOptions{
+		Since:  since.UTC(),
+		Filter: opt.Filter,
+		Q:      opt.Q,
+		Limit:  opt.Limit,
+	})
+	return start, nil, err
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,235 @@
+package okta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// logPollingHandler serves pages of one event each, then empty polling pages.
+// The cursor in the "after" parameter is the number of events already served.
+func logPollingHandler(t *testing.T, total int, requests *[]string, mu *sync.Mutex) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		mu.Lock()
+		*requests = append(*requests, r.URL.RawQuery)
+		mu.Unlock()
+
+		after, _ := strconv.Atoi(r.URL.Query().Get("after"))
+		next := after
+		body := "[]"
+		if after < total {
+			next = after + 1
+			body = fmt.Sprintf(`[{"uuid":"event-%v","eventType":"user.session.start"}]`, after)
+		}
+		w.Header().Add("Link", fmt.Sprintf(`<%v/logs?after=%v&limit=1>; rel="next"`, server.URL, next))
+		fmt.Fprint(w, body)
+	}
+}
+
+func TestLogsTailResumesFromCursor(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var requests []string
+	var mu sync.Mutex
+	mux.HandleFunc("/logs", logPollingHandler(t, 3, &requests, &mu))
+
+	cursors := new(MemoryLogCursorStore)
+	opt := &LogTailOptions{
+		Since:        time.Date(2024, 8, 13, 0, 0, 0, 0, time.UTC),
+		Limit:        1,
+		PollInterval: 10 * time.Millisecond,
+		Cursors:      cursors,
+	}
+
+	// Handle two events then stop
+	ctx, cancel := context.WithCancel(context.Background())
+	var seen []string
+	err := client.Logs.TailFunc(ctx, opt, func(e LogEvent) error {
+		seen = append(seen, e.UUID)
+		if len(seen) == 2 {
+			cancel()
+		}
+		return nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("Logs.TailFunc returned %v, want context.Canceled", err)
+	}
+
+	cursor, _, _ := cursors.LoadCursor()
+	if cursor != server.URL+"/logs?after=2&limit=1" {
+		t.Fatalf("Saved cursor %v", cursor)
+	}
+
+	// Restart with the same cursor store using the channel API
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	tail := client.Logs.Tail(ctx, opt)
+	event := <-tail.Events()
+	seen = append(seen, event.UUID)
+	cancel()
+	for range tail.Events() {
+	}
+	if tail.Err() != context.Canceled {
+		t.Errorf("LogTail.Err returned %v, want context.Canceled", tail.Err())
+	}
+
+	want := []string{"event-0", "event-1", "event-2"}
+	if fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Errorf("Tail delivered %v, want %v", seen, want)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests[0] != "limit=1&since=2024-08-13T00%3A00%3A00Z" {
+		t.Errorf("First request query %v", requests[0])
+	}
+}
+
+func TestLogsTailWaitsForRateLimitReset(t *testing.T) {
+	setup()
+	defer teardown()
+
+	calls := 0
+	mux.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Add(headerRateReset, strconv.FormatInt(time.Now().Unix(), 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"errorCode":"E0000047","errorSummary":"API call exceeded rate limit due to too many requests."}`)
+			return
+		}
+		fmt.Fprint(w, `[{"uuid":"event-0"}]`)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := client.Logs.TailFunc(ctx, &LogTailOptions{Limit: 1}, func(e LogEvent) error {
+		cancel()
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("Logs.TailFunc returned %v, want context.Canceled", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected the tail to retry after the rate limit, got %v calls", calls)
+	}
+}
+
+func TestLogsTailWithoutNextLink(t *testing.T) {
+	setup()
+	defer teardown()
+
+	calls := 0
+	mux.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			fmt.Fprint(w, `[{"uuid":"event-0"}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"uuid":"event-0"},{"uuid":"event-1"}]`)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	opt := &LogTailOptions{PollInterval: time.Millisecond}
+	var seen []string
+	err := client.Logs.TailFunc(ctx, opt, func(e LogEvent) error {
+		seen = append(seen, e.UUID)
+		if e.UUID == "event-1" {
+			cancel()
+		}
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("Logs.TailFunc returned %v, want context.Canceled", err)
+	}
+	if fmt.Sprint(seen) != "[event-0 event-1]" {
+		t.Errorf("a page polled again should only deliver its new events, got %v", seen)
+	}
+	if opt.Cursors != nil || opt.Limit != 0 {
+		t.Errorf("TailFunc shouldn't change the options, got %+v", opt)
+	}
+}
+
+func TestFileLogCursorStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oktalogcursor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := &FileLogCursorStore{Path: filepath.Join(dir, "cursor")}
+	if cursor, delivered, err := store.LoadCursor(); cursor != "" || delivered != nil || err != nil {
+		t.Errorf("LoadCursor on a missing file returned %q, %v, %v", cursor, delivered, err)
+	}
+	if err := store.SaveCursor("https://test-org.okta.com/api/v1/logs?after=1", []string{"event-1", "event-2"}); err != nil {
+		t.Fatalf("SaveCursor returned error: %v", err)
+	}
+	cursor, delivered, _ := store.LoadCursor()
+	if cursor != "https://test-org.okta.com/api/v1/logs?after=1" || fmt.Sprint(delivered) != "[event-1 event-2]" {
+		t.Errorf("LoadCursor returned %q, %v", cursor, delivered)
+	}
+}
+
+func TestLogsTailRestartsMidPage(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("after") == "" {
+			w.Header().Add("Link", fmt.Sprintf(`<%v/logs?after=3>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"uuid":"event-0"},{"uuid":"event-1"},{"uuid":"event-2"}]`)
+			return
+		}
+		w.Header().Add("Link", fmt.Sprintf(`<%v/logs?after=3>; rel="next"`, server.URL))
+		fmt.Fprint(w, `[]`)
+	})
+
+	dir, err := ioutil.TempDir("", "oktalogcursor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	opt := &LogTailOptions{Limit: 3, PollInterval: time.Millisecond, Cursors: &FileLogCursorStore{Path: filepath.Join(dir, "cursor")}}
+
+	// the handler fails on the second event of the page
+	var seen []string
+	failed := errors.New("handler failed")
+	err = client.Logs.TailFunc(context.Background(), opt, func(e LogEvent) error {
+		seen = append(seen, e.UUID)
+		if e.UUID == "event-1" {
+			return failed
+		}
+		return nil
+	})
+	if err != failed {
+		t.Fatalf("Logs.TailFunc returned %v, want the handler error", err)
+	}
+
+	// a new process with the same cursor file carries on with the event that failed
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = client.Logs.TailFunc(ctx, opt, func(e LogEvent) error {
+		seen = append(seen, e.UUID)
+		if e.UUID == "event-2" {
+			cancel()
+		}
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("Logs.TailFunc returned %v, want context.Canceled", err)
+	}
+	if want := "[event-0 event-1 event-1 event-2]"; fmt.Sprint(seen) != want {
+		t.Errorf("the restart should skip the delivered event, delivered %v want %v", seen, want)
+	}
+}
@@ -0,0 +1,111 @@
+package okta
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Observer is told about every API call made by Client.Do so it can record traces and metrics.
+// The okta/otelokta package has an OpenTelemetry implementation, keeping that dependency out of
+// programs that don't use it.
+type Observer interface {
+	// StartCall is called before the call is made. The returned context is used for the request
+	// and the returned func is called once with the result when Do returns.
+	StartCall(ctx context.Context, call Call) (context.Context, func(CallResult))
+}
+
+// Call describes an API call that is about to be made
+type Call struct {
+	Method string
+	// Endpoint is the path with IDs replaced, e.g. /api/v1/groups/{id}/users, so it can be used as a
+	// low cardinality span name or metric attribute
+	Endpoint string
+	URL      *url.URL
+	// Header of the request. Trace context can be injected here.
+	Header http.Header
+}
+
+// CallResult is the outcome of an API call
+type CallResult struct {
+	// StatusCode is 0 when no response was received
+	StatusCode    int
+	OKTARequestID string
+	// Rate is the rate limit reported by the last response
+	Rate     Rate
+	Duration time.Duration
+	// Retries counts requests sent again after a 429 or a DPoP nonce challenge
+	Retries int
+	// RateLimitPause is the total time spent waiting for the rate limit to reset
+	RateLimitPause time.Duration
+	Err            error
+}
+
+// callStats collects what happened during one call to Do
+type callStats struct {
+	retries int
+	pause   time.Duration
+}
+
+func (c *Client) observe(req *http.Request, v interface{}, handler Handler, stats *callStats) (*Response, error) {
+	start := time.Now()
+	ctx, finish := c.Observer.StartCall(req.Context(), Call{
+		Method:   req.Method,
+		Endpoint: EndpointTemplate(req.URL.Path),
+		URL:      req.URL,
+		Header:   req.Header,
+	})
+	req = req.WithContext(ctx)
+
+	response, err := handler(req, v)
+
+	result := CallResult{
+		Duration:       time.Since(start),
+		Retries:        stats.retries,
+		RateLimitPause: stats.pause,
+		Err:            err,
+	}
+	if response != nil && response.Response != nil {
+		result.StatusCode = response.StatusCode
+		result.OKTARequestID = response.OKTARequestID
+		result.Rate = response.Rate
+	}
+	finish(result)
+	return response, err
+}
+
+// EndpointTemplate replaces the IDs in an API path with {id}, e.g. /api/v1/users/00u1abcd2EFGH3ijkl4m5/lifecycle/activate
+// becomes /api/v1/users/{id}/lifecycle/activate. Logins and emails are replaced too.
+func EndpointTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if looksLikeID(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// looksLikeID reports whether a path segment is an OKTA ID, key ID or login rather than a fixed part of the path.
+// IDs are at least 12 characters and have a digit, fixed segments like "reset_password" don't.
+func looksLikeID(segment string) bool {
+	if strings.Contains(segment, "@") {
+		return true
+	}
+	if len(segment) < 12 {
+		return false
+	}
+	digit := false
+	for _, r := range segment {
+		switch {
+		case r >= '0' && r <= '9':
+			digit = true
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return digit
+}
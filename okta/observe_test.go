@@ -0,0 +1,70 @@
+package okta
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestEndpointTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/users/00u1abcd2EFGH3ijkl4m5":                                                             "/api/v1/users/{id}",
+		"/api/v1/users/me":                                                                                "/api/v1/users/me",
+		"/api/v1/users/isaac.brock@example.com":                                                           "/api/v1/users/{id}",
+		"/api/v1/users/00u1abcd2EFGH3ijkl4m5/lifecycle/reset_password":                                    "/api/v1/users/{id}/lifecycle/reset_password",
+		"/api/v1/groups/00g1abcd2EFGH3ijkl4m5/users/00u1abcd2EFGH3ijkl4m5":                                "/api/v1/groups/{id}/users/{id}",
+		"/api/v1/apps/0oa1abcd2EFGH3ijkl4m5/credentials/keys/SIMcCQNY3uwXoW3y0vf6VxiBb5n9pf8L2fK8d-F1bm4": "/api/v1/apps/{id}/credentials/keys/{id}",
+	}
+	for path, want := range tests {
+		if got := EndpointTemplate(path); got != want {
+			t.Errorf("EndpointTemplate(%v) should be %v but got %v", path, want, got)
+		}
+	}
+}
+
+type recordingObserver struct {
+	calls   []Call
+	results []CallResult
+}
+
+func (o *recordingObserver) StartCall(ctx context.Context, call Call) (context.Context, func(CallResult)) {
+	o.calls = append(o.calls, call)
+	return ctx, func(result CallResult) { o.results = append(o.results, result) }
+}
+
+func TestObserverSeesRetries(t *testing.T) {
+	setup()
+	defer teardown()
+
+	calls := 0
+	mux.HandleFunc("/groups/00g1abcd2EFGH3ijkl4m5", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set(headerOKTARequestID, "req-"+strconv.Itoa(calls))
+		if calls == 1 {
+			w.Header().Set(headerRateLimit, "600")
+			w.Header().Set(headerRateRemaining, "0")
+			w.Header().Set(headerRateReset, strconv.FormatInt(time.Now().Unix(), 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"id":"00g1abcd2EFGH3ijkl4m5"}`))
+	})
+
+	observer := new(recordingObserver)
+	client.Observer = observer
+	client.MaxRetries = 1
+	client.MaxRetryWait = 5 * time.Millisecond
+	if _, _, err := client.Groups.GetByID("00g1abcd2EFGH3ijkl4m5"); err != nil {
+		t.Fatalf("Groups.GetByID returned error: %v", err)
+	}
+
+	if len(observer.calls) != 1 || observer.calls[0].Endpoint != "/groups/{id}" || observer.calls[0].Method != "GET" {
+		t.Fatalf("unexpected calls %+v", observer.calls)
+	}
+	result := observer.results[0]
+	if result.StatusCode != 200 || result.OKTARequestID != "req-2" || result.Retries != 1 || result.RateLimitPause != 5*time.Millisecond {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
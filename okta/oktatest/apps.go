@@ -0,0 +1,280 @@
+package oktatest
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	appStatusActive = "ACTIVE"
+	appScopeUser    = "USER"
+	appScopeGroup   = "GROUP"
+)
+
+// App is an application stored in the Server. Status defaults to ACTIVE and SignOnMode to SAML_2_0.
+type App struct {
+	ID         string
+	Name       string
+	Label      string
+	Status     string
+	SignOnMode string
+
+	Created     time.Time
+	LastUpdated time.Time
+}
+
+// AddApp stores an application and returns its ID
+func (s *Server) AddApp(a App) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a.ID == "" {
+		a.ID = s.newID("0oa")
+	}
+	if a.Status == "" {
+		a.Status = appStatusActive
+	}
+	if a.SignOnMode == "" {
+		a.SignOnMode = "SAML_2_0"
+	}
+	if a.Created.IsZero() {
+		a.Created = time.Now()
+	}
+	if a.LastUpdated.IsZero() {
+		a.LastUpdated = a.Created
+	}
+	s.apps = append(s.apps, &a)
+	return a.ID
+}
+
+// AssignUserToApp directly assigns a user to an application. The AppUser scope is USER.
+func (s *Server) AssignUserToApp(appID string, userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if indexOf(s.appUsers[appID], userID) < 0 {
+		s.appUsers[appID] = append(s.appUsers[appID], userID)
+	}
+}
+
+// AssignGroupToApp assigns a group to an application. Members that are not directly
+// assigned show up as app users with scope GROUP.
+func (s *Server) AssignGroupToApp(appID string, groupID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if indexOf(s.appGroup[appID], groupID) < 0 {
+		s.appGroup[appID] = append(s.appGroup[appID], groupID)
+	}
+}
+
+// AppGroups returns the IDs of the groups assigned to an application
+func (s *Server) AppGroups(appID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups := make([]string, len(s.appGroup[appID]))
+	copy(groups, s.appGroup[appID])
+	return groups
+}
+
+func (s *Server) findApp(id string) *App {
+	for _, a := range s.apps {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+func (s *Server) appJSON(a *App) map[string]interface{} {
+	base := s.URL + apiPrefix + "apps/" + a.ID
+	return map[string]interface{}{
+		"id":          a.ID,
+		"name":        a.Name,
+		"label":       a.Label,
+		"status":      a.Status,
+		"created":     formatTime(a.Created),
+		"lastUpdated": formatTime(a.LastUpdated),
+		"signOnMode":  a.SignOnMode,
+		"features":    []string{},
+		"credentials": map[string]interface{}{
+			"userNameTemplate": map[string]string{"template": "${source.login}", "type": "BUILT_IN"},
+			"signing":          map[string]string{},
+		},
+		"settings": map[string]interface{}{"app": map[string]string{}},
+		"_links": map[string]interface{}{
+			"users":  map[string]string{"href": base + "/users"},
+			"groups": map[string]string{"href": base + "/groups"},
+		},
+	}
+}
+
+// appUserScope returns USER for direct assignments, GROUP for assignments through a group and "" otherwise
+func (s *Server) appUserScope(appID string, userID string) string {
+	if indexOf(s.appUsers[appID], userID) >= 0 {
+		return appScopeUser
+	}
+	for _, groupID := range s.appGroup[appID] {
+		if indexOf(s.members[groupID], userID) >= 0 {
+			return appScopeGroup
+		}
+	}
+	return ""
+}
+
+func (s *Server) appUserJSON(a *App, u *User, scope string) map[string]interface{} {
+	login, _ := u.Profile["login"].(string)
+	return map[string]interface{}{
+		"id":          u.ID,
+		"externalId":  nil,
+		"created":     formatTime(u.Created),
+		"lastUpdated": formatTime(u.LastUpdated),
+		"scope":       scope,
+		"status":      appStatusActive,
+		"syncState":   "DISABLED",
+		"credentials": map[string]string{"userName": login},
+		"profile":     map[string]interface{}{},
+		"_links": map[string]interface{}{
+			"app":  map[string]string{"href": s.URL + apiPrefix + "apps/" + a.ID},
+			"user": map[string]string{"href": s.URL + apiPrefix + "users/" + u.ID},
+		},
+	}
+}
+
+func (s *Server) serveApps(w http.ResponseWriter, r *http.Request, segments []string) {
+	if len(segments) == 0 || segments[0] == "" {
+		if r.Method != "GET" {
+			writeMethodNotAllowed(w)
+			return
+		}
+		s.listApps(w, r)
+		return
+	}
+
+	a := s.findApp(segments[0])
+	if a == nil {
+		writeNotFound(w, segments[0]+" (AppInstance)")
+		return
+	}
+
+	switch {
+	case len(segments) == 1 && r.Method == "GET":
+		writeJSON(w, http.StatusOK, s.appJSON(a))
+	case len(segments) == 2 && segments[1] == "users" && r.Method == "GET":
+		var ids []string
+		for _, u := range s.users {
+			if s.appUserScope(a.ID, u.ID) != "" {
+				ids = append(ids, u.ID)
+			}
+		}
+		page := paginate(w, r, ids)
+		users := make([]interface{}, 0, len(page))
+		for _, id := range page {
+			users = append(users, s.appUserJSON(a, s.findUser(id), s.appUserScope(a.ID, id)))
+		}
+		writeJSON(w, http.StatusOK, users)
+	case len(segments) == 3 && segments[1] == "users" && r.Method == "GET":
+		u := s.findUser(segments[2])
+		if u == nil || s.appUserScope(a.ID, u.ID) == "" {
+			writeNotFound(w, segments[2]+" (AppUser)")
+			return
+		}
+		writeJSON(w, http.StatusOK, s.appUserJSON(a, u, s.appUserScope(a.ID, u.ID)))
+	case len(segments) == 2 && segments[1] == "groups" && r.Method == "GET":
+		page := paginate(w, r, s.appGroup[a.ID])
+		groups := make([]interface{}, 0, len(page))
+		for _, id := range page {
+			groups = append(groups, s.appGroupJSON(a, id, indexOf(s.appGroup[a.ID], id)))
+		}
+		writeJSON(w, http.StatusOK, groups)
+	case len(segments) == 3 && segments[1] == "groups":
+		s.serveAppGroup(w, r, a, segments[2])
+	default:
+		writeNotFound(w, r.URL.Path)
+	}
+}
+
+// serveAppGroup assigns and unassigns groups. The position of a group in the app's list is its
+// priority, so assigning at priority p moves the groups at p and after down one like OKTA does.
+func (s *Server) serveAppGroup(w http.ResponseWriter, r *http.Request, a *App, groupID string) {
+	g := s.findGroup(groupID)
+	if g == nil {
+		writeNotFound(w, groupID+" (UserGroup)")
+		return
+	}
+	switch r.Method {
+	case "GET":
+		i := indexOf(s.appGroup[a.ID], g.ID)
+		if i < 0 {
+			writeNotFound(w, groupID+" (ApplicationGroupAssignment)")
+			return
+		}
+		writeJSON(w, http.StatusOK, s.appGroupJSON(a, g.ID, i))
+	case "PUT":
+		in := struct {
+			Priority *int `json:"priority"`
+		}{}
+		if err := decodeBody(r, &in); err != nil {
+			writeError(w, http.StatusBadRequest, ErrorCodeValidation, "The request body was not well-formed.")
+			return
+		}
+		groups := removeID(s.appGroup[a.ID], g.ID)
+		priority := len(groups)
+		if in.Priority != nil && *in.Priority >= 0 && *in.Priority < priority {
+			priority = *in.Priority
+		}
+		groups = append(groups[:priority:priority], append([]string{g.ID}, groups[priority:]...)...)
+		s.appGroup[a.ID] = groups
+		a.LastUpdated = time.Now()
+		writeJSON(w, http.StatusOK, s.appGroupJSON(a, g.ID, priority))
+	case "DELETE":
+		s.appGroup[a.ID] = removeID(s.appGroup[a.ID], g.ID)
+		a.LastUpdated = time.Now()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+func (s *Server) appGroupJSON(a *App, groupID string, priority int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          groupID,
+		"lastUpdated": formatTime(a.LastUpdated),
+		"priority":    priority,
+		"_links": map[string]interface{}{
+			"group": map[string]string{"href": s.URL + apiPrefix + "groups/" + groupID},
+		},
+	}
+}
+
+func (s *Server) listApps(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrorCodeValidation, err.Error())
+		return
+	}
+
+	var ids []string
+	for _, a := range s.apps {
+		if f.match(a.lookup) {
+			ids = append(ids, a.ID)
+		}
+	}
+	page := paginate(w, r, ids)
+	apps := make([]interface{}, 0, len(page))
+	for _, id := range page {
+		apps = append(apps, s.appJSON(s.findApp(id)))
+	}
+	writeJSON(w, http.StatusOK, apps)
+}
+
+func (a *App) lookup(attr string) (string, bool) {
+	switch attr {
+	case "id":
+		return a.ID, true
+	case "status":
+		return a.Status, true
+	case "name":
+		return a.Name, true
+	}
+	return "", false
+}
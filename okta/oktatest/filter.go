@@ -0,0 +1,81 @@
+package oktatest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var filterClauseRegex = regexp.MustCompile(`^\s*(\S+)\s+(eq|ne|sw|gt|ge|lt|le|co)\s+"((?:[^"\\]|\\.)*)"\s*$`)
+var filterAndRegex = regexp.MustCompile(`(?i)\s+and\s+`)
+
+type filterClause struct {
+	attr  string
+	op    string
+	value string
+}
+
+// filter is a parsed OKTA filter or search expression. Only clauses joined by "and" are supported.
+type filter []filterClause
+
+func parseFilter(expr string) (filter, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, nil
+	}
+	var f filter
+	for _, part := range filterAndRegex.Split(expr, -1) {
+		m := filterClauseRegex.FindStringSubmatch(part)
+		if m == nil {
+			return nil, fmt.Errorf("Invalid search criteria: %v", part)
+		}
+		f = append(f, filterClause{attr: m[1], op: m[2], value: strings.Replace(m[3], `\"`, `"`, -1)})
+	}
+	return f, nil
+}
+
+// match reports whether every clause matches. lookup returns the string value of an attribute.
+func (f filter) match(lookup func(attr string) (string, bool)) bool {
+	for _, c := range f {
+		v, ok := lookup(c.attr)
+		if !ok {
+			return false
+		}
+		if !compare(v, c.op, c.value) {
+			return false
+		}
+	}
+	return true
+}
+
+func compare(v string, op string, want string) bool {
+	switch op {
+	case "eq":
+		return v == want
+	case "ne":
+		return v != want
+	case "sw":
+		return strings.HasPrefix(strings.ToLower(v), strings.ToLower(want))
+	case "co":
+		return strings.Contains(strings.ToLower(v), strings.ToLower(want))
+	case "gt":
+		return v > want
+	case "ge":
+		return v >= want
+	case "lt":
+		return v < want
+	case "le":
+		return v <= want
+	}
+	return false
+}
+
+func stringValue(v interface{}) (string, bool) {
+	if v == nil {
+		return "", false
+	}
+	if s, ok := v.(string); ok {
+		return s, true
+	}
+	return fmt.Sprint(v), true
+}
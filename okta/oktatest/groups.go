@@ -0,0 +1,316 @@
+package oktatest
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+// Group is a group stored in the Server. Type defaults to OKTA_GROUP.
+type Group struct {
+	ID          string
+	Type        string
+	Name        string
+	Description string
+
+	Created               time.Time
+	LastUpdated           time.Time
+	LastMembershipUpdated time.Time
+}
+
+// AddGroup stores a group and returns its ID
+func (s *Server) AddGroup(g Group) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if g.ID == "" {
+		g.ID = s.newID("00g")
+	}
+	if g.Type == "" {
+		g.Type = okta.GroupTypeOKTA
+	}
+	if g.Created.IsZero() {
+		g.Created = time.Now()
+	}
+	if g.LastUpdated.IsZero() {
+		g.LastUpdated = g.Created
+	}
+	s.groups = append(s.groups, &g)
+	return g.ID
+}
+
+// GetGroup returns a copy of a stored group
+func (s *Server) GetGroup(id string) (Group, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g := s.findGroup(id)
+	if g == nil {
+		return Group{}, false
+	}
+	return *g, true
+}
+
+// AddGroupMember adds a user to a group
+func (s *Server) AddGroupMember(groupID string, userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addMember(groupID, userID)
+}
+
+// GroupMembers returns the IDs of the members of a group in the order they were added
+func (s *Server) GroupMembers(groupID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := make([]string, len(s.members[groupID]))
+	copy(members, s.members[groupID])
+	return members
+}
+
+func (s *Server) addMember(groupID string, userID string) {
+	if indexOf(s.members[groupID], userID) >= 0 {
+		return
+	}
+	s.members[groupID] = append(s.members[groupID], userID)
+	if g := s.findGroup(groupID); g != nil {
+		g.LastMembershipUpdated = time.Now()
+	}
+}
+
+func (s *Server) findGroup(id string) *Group {
+	for _, g := range s.groups {
+		if g.ID == id {
+			return g
+		}
+	}
+	return nil
+}
+
+func (s *Server) groupJSON(g *Group) map[string]interface{} {
+	objectClass := "okta:user_group"
+	if g.Type == okta.GroupTypeApp {
+		objectClass = "okta:windows_security_principal"
+	}
+	return map[string]interface{}{
+		"id":                    g.ID,
+		"created":               formatTime(g.Created),
+		"lastUpdated":           formatTime(g.LastUpdated),
+		"lastMembershipUpdated": formatTime(g.LastMembershipUpdated),
+		"objectClass":           []string{objectClass},
+		"type":                  g.Type,
+		"profile": map[string]string{
+			"name":        g.Name,
+			"description": g.Description,
+		},
+		"_links": map[string]interface{}{
+			"users": map[string]string{"href": s.URL + apiPrefix + "groups/" + g.ID + "/users"},
+			"apps":  map[string]string{"href": s.URL + apiPrefix + "groups/" + g.ID + "/apps"},
+		},
+	}
+}
+
+func (g *Group) lookup(attr string) (string, bool) {
+	switch attr {
+	case "id":
+		return g.ID, true
+	case "type":
+		return g.Type, true
+	case "profile.name":
+		return g.Name, true
+	case "profile.description":
+		return g.Description, true
+	case "lastUpdated":
+		return g.LastUpdated.UTC().Format(timeFormat), true
+	case "lastMembershipUpdated":
+		return g.LastMembershipUpdated.UTC().Format(timeFormat), true
+	}
+	return "", false
+}
+
+type groupInput struct {
+	Profile struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	} `json:"profile"`
+}
+
+func (s *Server) writeGroupPage(w http.ResponseWriter, r *http.Request, ids []string) {
+	page := paginate(w, r, ids)
+	groups := make([]interface{}, 0, len(page))
+	for _, id := range page {
+		groups = append(groups, s.groupJSON(s.findGroup(id)))
+	}
+	writeJSON(w, http.StatusOK, groups)
+}
+
+func (s *Server) serveGroups(w http.ResponseWriter, r *http.Request, segments []string) {
+	if len(segments) > 0 && segments[0] == "rules" {
+		s.serveGroupRules(w, r, segments[1:])
+		return
+	}
+	if len(segments) == 0 || segments[0] == "" {
+		switch r.Method {
+		case "GET":
+			s.listGroups(w, r)
+		case "POST":
+			s.createGroup(w, r)
+		default:
+			writeMethodNotAllowed(w)
+		}
+		return
+	}
+
+	g := s.findGroup(segments[0])
+	if g == nil {
+		writeNotFound(w, segments[0]+" (UserGroup)")
+		return
+	}
+
+	switch {
+	case len(segments) == 1:
+		switch r.Method {
+		case "GET":
+			writeJSON(w, http.StatusOK, s.groupJSON(g))
+		case "PUT":
+			s.updateGroup(w, r, g)
+		case "DELETE":
+			s.deleteGroup(w, g)
+		default:
+			writeMethodNotAllowed(w)
+		}
+	case len(segments) == 2 && segments[1] == "users" && r.Method == "GET":
+		page := paginate(w, r, s.members[g.ID])
+		users := make([]interface{}, 0, len(page))
+		for _, id := range page {
+			users = append(users, s.userJSON(s.findUser(id)))
+		}
+		writeJSON(w, http.StatusOK, users)
+	case len(segments) == 3 && segments[1] == "users":
+		u := s.findUser(segments[2])
+		if u == nil {
+			writeNotFound(w, segments[2]+" (User)")
+			return
+		}
+		if g.Type != okta.GroupTypeOKTA {
+			writeError(w, http.StatusForbidden, "E0000006", "You do not have permission to perform the requested action")
+			return
+		}
+		switch r.Method {
+		case "PUT":
+			s.addMember(g.ID, u.ID)
+		case "DELETE":
+			if indexOf(s.members[g.ID], u.ID) >= 0 {
+				s.members[g.ID] = removeID(s.members[g.ID], u.ID)
+				g.LastMembershipUpdated = time.Now()
+			}
+		default:
+			writeMethodNotAllowed(w)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeNotFound(w, r.URL.Path)
+	}
+}
+
+func (s *Server) listGroups(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f, err := parseFilter(q.Get("filter"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrorCodeValidation, err.Error())
+		return
+	}
+	prefix := strings.ToLower(q.Get("q"))
+
+	var ids []string
+	for _, g := range s.groups {
+		if !f.match(g.lookup) {
+			continue
+		}
+		if prefix != "" && !strings.HasPrefix(strings.ToLower(g.Name), prefix) {
+			continue
+		}
+		ids = append(ids, g.ID)
+	}
+	s.writeGroupPage(w, r, ids)
+}
+
+func (s *Server) validateGroupName(name string, self *Group) []string {
+	if name == "" {
+		return []string{"name: The field cannot be left blank"}
+	}
+	for _, g := range s.groups {
+		if g != self && g.Type == okta.GroupTypeOKTA && strings.EqualFold(g.Name, name) {
+			return []string{"name: An object with this field already exists in the current organization"}
+		}
+	}
+	return nil
+}
+
+func (s *Server) createGroup(w http.ResponseWriter, r *http.Request) {
+	var in groupInput
+	if err := decodeBody(r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, ErrorCodeValidation, "The request body was not well-formed.")
+		return
+	}
+	if causes := s.validateGroupName(in.Profile.Name, nil); len(causes) > 0 {
+		writeValidation(w, causes...)
+		return
+	}
+
+	now := time.Now()
+	g := &Group{
+		ID:          s.newID("00g"),
+		Type:        okta.GroupTypeOKTA,
+		Name:        in.Profile.Name,
+		Description: in.Profile.Description,
+		Created:     now,
+		LastUpdated: now,
+	}
+	s.groups = append(s.groups, g)
+	writeJSON(w, http.StatusOK, s.groupJSON(g))
+}
+
+func (s *Server) updateGroup(w http.ResponseWriter, r *http.Request, g *Group) {
+	var in groupInput
+	if err := decodeBody(r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, ErrorCodeValidation, "The request body was not well-formed.")
+		return
+	}
+	if g.Type != okta.GroupTypeOKTA {
+		writeError(w, http.StatusForbidden, "E0000006", "You do not have permission to perform the requested action")
+		return
+	}
+	if causes := s.validateGroupName(in.Profile.Name, g); len(causes) > 0 {
+		writeValidation(w, causes...)
+		return
+	}
+	g.Name = in.Profile.Name
+	g.Description = in.Profile.Description
+	g.LastUpdated = time.Now()
+	writeJSON(w, http.StatusOK, s.groupJSON(g))
+}
+
+func (s *Server) deleteGroup(w http.ResponseWriter, g *Group) {
+	if g.Type != okta.GroupTypeOKTA {
+		writeError(w, http.StatusForbidden, "E0000006", "You do not have permission to perform the requested action")
+		return
+	}
+	for i, existing := range s.groups {
+		if existing == g {
+			s.groups = append(s.groups[:i], s.groups[i+1:]...)
+			break
+		}
+	}
+	delete(s.members, g.ID)
+	for appID := range s.appGroup {
+		s.appGroup[appID] = removeID(s.appGroup[appID], g.ID)
+	}
+	for _, rule := range s.rules {
+		rule.GroupIDs = removeID(rule.GroupIDs, g.ID)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,257 @@
+package oktatest
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+// GroupRule is a group rule stored in the Server. Status defaults to INACTIVE. The Server stores
+// rules but doesn't evaluate their expressions, add members with AddGroupMember. ExcludeUsers
+// and ExcludeGroups are the people the rule skips.
+type GroupRule struct {
+	ID            string
+	Name          string
+	Status        string
+	Expression    string
+	GroupIDs      []string
+	ExcludeUsers  []string
+	ExcludeGroups []string
+
+	Created     time.Time
+	LastUpdated time.Time
+}
+
+// AddGroupRule stores a group rule and returns its ID
+func (s *Server) AddGroupRule(rule GroupRule) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rule.ID == "" {
+		rule.ID = s.newID("0pr")
+	}
+	if rule.Status == "" {
+		rule.Status = okta.GroupRuleStatusInactive
+	}
+	if rule.Created.IsZero() {
+		rule.Created = time.Now()
+	}
+	if rule.LastUpdated.IsZero() {
+		rule.LastUpdated = rule.Created
+	}
+	rule.GroupIDs = append([]string(nil), rule.GroupIDs...)
+	rule.ExcludeUsers = append([]string(nil), rule.ExcludeUsers...)
+	rule.ExcludeGroups = append([]string(nil), rule.ExcludeGroups...)
+	s.rules = append(s.rules, &rule)
+	return rule.ID
+}
+
+// GetGroupRule returns a copy of a stored group rule
+func (s *Server) GetGroupRule(id string) (GroupRule, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rule := s.findRule(id)
+	if rule == nil {
+		return GroupRule{}, false
+	}
+	copied := *rule
+	copied.GroupIDs = append([]string(nil), rule.GroupIDs...)
+	copied.ExcludeUsers = append([]string(nil), rule.ExcludeUsers...)
+	copied.ExcludeGroups = append([]string(nil), rule.ExcludeGroups...)
+	return copied, true
+}
+
+func (s *Server) findRule(id string) *GroupRule {
+	for _, rule := range s.rules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	return nil
+}
+
+func (s *Server) ruleJSON(rule *GroupRule) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          rule.ID,
+		"type":        "group_rule",
+		"name":        rule.Name,
+		"status":      rule.Status,
+		"created":     formatTime(rule.Created),
+		"lastUpdated": formatTime(rule.LastUpdated),
+		"conditions": map[string]interface{}{
+			"people": map[string]interface{}{
+				"users":  map[string][]string{"exclude": nonNil(rule.ExcludeUsers)},
+				"groups": map[string][]string{"exclude": nonNil(rule.ExcludeGroups)},
+			},
+			"expression": map[string]string{"value": rule.Expression, "type": "urn:okta:expression:1.0"},
+		},
+		"actions": map[string]interface{}{
+			"assignUserToGroups": map[string][]string{"groupIds": rule.GroupIDs},
+		},
+	}
+}
+
+// nonNil keeps empty lists as [] in JSON, like OKTA
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+type ruleInput struct {
+	Name       string `json:"name"`
+	Conditions struct {
+		People struct {
+			Users struct {
+				Exclude []string `json:"exclude"`
+			} `json:"users"`
+			Groups struct {
+				Exclude []string `json:"exclude"`
+			} `json:"groups"`
+		} `json:"people"`
+		Expression struct {
+			Value string `json:"value"`
+		} `json:"expression"`
+	} `json:"conditions"`
+	Actions struct {
+		AssignUserToGroups struct {
+			GroupIDs []string `json:"groupIds"`
+		} `json:"assignUserToGroups"`
+	} `json:"actions"`
+}
+
+func (s *Server) validateRule(in ruleInput, self *GroupRule) []string {
+	var causes []string
+	if in.Name == "" {
+		causes = append(causes, "name: The field cannot be left blank")
+	}
+	for _, rule := range s.rules {
+		if rule != self && in.Name != "" && strings.EqualFold(rule.Name, in.Name) {
+			causes = append(causes, "name: An object with this field already exists in the current organization")
+		}
+	}
+	if in.Conditions.Expression.Value == "" {
+		causes = append(causes, "conditions.expression.value: The field cannot be left blank")
+	}
+	if len(in.Actions.AssignUserToGroups.GroupIDs) == 0 {
+		causes = append(causes, "actions.assignUserToGroups.groupIds: The field cannot be left blank")
+	}
+	for _, id := range in.Actions.AssignUserToGroups.GroupIDs {
+		if g := s.findGroup(id); g == nil || g.Type != okta.GroupTypeOKTA {
+			causes = append(causes, "actions.assignUserToGroups.groupIds: "+id+" is not a valid OKTA_GROUP")
+		}
+	}
+	return causes
+}
+
+// serveGroupRules handles /groups/rules
+func (s *Server) serveGroupRules(w http.ResponseWriter, r *http.Request, segments []string) {
+	if len(segments) == 0 || segments[0] == "" {
+		switch r.Method {
+		case "GET":
+			search := strings.ToLower(r.URL.Query().Get("search"))
+			var ids []string
+			for _, rule := range s.rules {
+				if strings.HasPrefix(strings.ToLower(rule.Name), search) {
+					ids = append(ids, rule.ID)
+				}
+			}
+			page := paginate(w, r, ids)
+			rules := make([]interface{}, 0, len(page))
+			for _, id := range page {
+				rules = append(rules, s.ruleJSON(s.findRule(id)))
+			}
+			writeJSON(w, http.StatusOK, rules)
+		case "POST":
+			var in ruleInput
+			if err := decodeBody(r, &in); err != nil {
+				writeError(w, http.StatusBadRequest, ErrorCodeValidation, "The request body was not well-formed.")
+				return
+			}
+			if causes := s.validateRule(in, nil); len(causes) > 0 {
+				writeValidation(w, causes...)
+				return
+			}
+			now := time.Now()
+			rule := &GroupRule{
+				ID:            s.newID("0pr"),
+				Name:          in.Name,
+				Status:        okta.GroupRuleStatusInactive,
+				Expression:    in.Conditions.Expression.Value,
+				GroupIDs:      in.Actions.AssignUserToGroups.GroupIDs,
+				ExcludeUsers:  in.Conditions.People.Users.Exclude,
+				ExcludeGroups: in.Conditions.People.Groups.Exclude,
+				Created:       now,
+				LastUpdated:   now,
+			}
+			s.rules = append(s.rules, rule)
+			writeJSON(w, http.StatusOK, s.ruleJSON(rule))
+		default:
+			writeMethodNotAllowed(w)
+		}
+		return
+	}
+
+	rule := s.findRule(segments[0])
+	if rule == nil {
+		writeNotFound(w, segments[0]+" (GroupRule)")
+		return
+	}
+
+	switch {
+	case len(segments) == 1:
+		switch r.Method {
+		case "GET":
+			writeJSON(w, http.StatusOK, s.ruleJSON(rule))
+		case "PUT":
+			var in ruleInput
+			if err := decodeBody(r, &in); err != nil {
+				writeError(w, http.StatusBadRequest, ErrorCodeValidation, "The request body was not well-formed.")
+				return
+			}
+			if rule.Status == okta.GroupRuleStatusActive {
+				writeValidation(w, "status: An ACTIVE rule cannot be updated, deactivate it first")
+				return
+			}
+			if causes := s.validateRule(in, rule); len(causes) > 0 {
+				writeValidation(w, causes...)
+				return
+			}
+			rule.Name = in.Name
+			rule.Expression = in.Conditions.Expression.Value
+			rule.GroupIDs = in.Actions.AssignUserToGroups.GroupIDs
+			rule.ExcludeUsers = in.Conditions.People.Users.Exclude
+			rule.ExcludeGroups = in.Conditions.People.Groups.Exclude
+			rule.LastUpdated = time.Now()
+			writeJSON(w, http.StatusOK, s.ruleJSON(rule))
+		case "DELETE":
+			for i, existing := range s.rules {
+				if existing == rule {
+					s.rules = append(s.rules[:i], s.rules[i+1:]...)
+					break
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeMethodNotAllowed(w)
+		}
+	case len(segments) == 3 && segments[1] == "lifecycle" && r.Method == "POST":
+		switch segments[2] {
+		case "activate":
+			rule.Status = okta.GroupRuleStatusActive
+		case "deactivate":
+			rule.Status = okta.GroupRuleStatusInactive
+		default:
+			writeNotFound(w, r.URL.Path)
+			return
+		}
+		rule.LastUpdated = time.Now()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeNotFound(w, r.URL.Path)
+	}
+}
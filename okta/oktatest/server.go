@@ -0,0 +1,354 @@
+// Package oktatest provides an in-memory stand-in for the OKTA API so code built on the
+// okta package can be tested without a live org.
+//
+// The Server keeps users, groups, group memberships, group rules, apps and app assignments in memory,
+// enforces the user lifecycle transitions OKTA does, paginates with Link headers, sends
+// X-Rate-Limit headers and returns error payloads with real OKTA error codes.
+//
+//	server := oktatest.NewServer()
+//	defer server.Close()
+//
+//	userID := server.AddUser(oktatest.User{Profile: map[string]interface{}{"login": "isaac.brock@example.com"}})
+//	client := server.Client()
+//	user, _, err := client.Users.GetByID(userID)
+package oktatest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+const (
+	// Token is the API token the Server accepts. Client() is already configured with it.
+	Token = "oktatest-api-token"
+
+	apiPrefix        = "/api/v1/"
+	timeFormat       = "2006-01-02T15:04:05.000Z"
+	defaultPageLimit = 200
+	defaultRateLimit = 600
+)
+
+// OKTA error codes returned by the Server
+const (
+	ErrorCodeValidation        = "E0000001"
+	ErrorCodeNotFound          = "E0000007"
+	ErrorCodeInvalidToken      = "E0000011"
+	ErrorCodeAlreadyActive     = "E0000016"
+	ErrorCodeInvalidUserStatus = "E0000038"
+	ErrorCodeRateLimit         = "E0000047"
+)
+
+// Request is a request the Server received. Path is relative to /api/v1/
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+}
+
+type injectedError struct {
+	method  string
+	path    string
+	status  int
+	code    string
+	summary string
+}
+
+// Server is a stateful in-memory OKTA org. Create one with NewServer.
+type Server struct {
+	// URL is the root URL of the server, e.g. http://127.0.0.1:1234
+	URL string
+
+	server *httptest.Server
+
+	mu       sync.Mutex
+	ids      int
+	users    []*User
+	groups   []*Group
+	members  map[string][]string
+	rules    []*GroupRule
+	apps     []*App
+	appUsers map[string][]string
+	appGroup map[string][]string
+	factors  map[string][]*Factor
+	requests []Request
+	errors   []injectedError
+
+	rateLimit     int
+	rateRemaining int
+	rateReset     time.Time
+}
+
+// NewServer starts a new Server with an empty org
+func NewServer() *Server {
+	s := &Server{
+		members:       make(map[string][]string),
+		appUsers:      make(map[string][]string),
+		appGroup:      make(map[string][]string),
+		factors:       make(map[string][]*Factor),
+		rateLimit:     defaultRateLimit,
+		rateRemaining: defaultRateLimit,
+	}
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// BaseURL returns the /api/v1/ URL to pass to okta.NewClientWithBaseURL
+func (s *Server) BaseURL() *url.URL {
+	u, _ := url.Parse(s.URL + apiPrefix)
+	return u
+}
+
+// Client returns an okta.Client pointed at the server and configured with Token. Its
+// RateRemainingFloor is 0, so it only waits when the server's rate limit is used up rather than
+// 100 requests before, which the default limit would hit after 500 requests.
+func (s *Server) Client() *okta.Client {
+	client := okta.NewClientWithBaseURL(nil, s.BaseURL(), Token)
+	client.RateRemainingFloor = 0
+	return client
+}
+
+// SetRateLimit sets the X-Rate-Limit-* headers sent on every response. Every request takes one
+// from remaining, and once reset has passed a new minute starts with remaining back at limit.
+// While remaining is 0 every request is answered with a 429 and error E0000047.
+func (s *Server) SetRateLimit(limit int, remaining int, reset time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimit = limit
+	s.rateRemaining = remaining
+	s.rateReset = reset
+}
+
+// FailNext makes the next request matching method and path (relative to /api/v1/, e.g. "users/00u1")
+// fail with the HTTP status and OKTA error code and summary.
+func (s *Server) FailNext(method string, path string, status int, errorCode string, errorSummary string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = append(s.errors, injectedError{
+		method:  method,
+		path:    strings.Trim(path, "/"),
+		status:  status,
+		code:    errorCode,
+		summary: errorSummary,
+	})
+}
+
+// Requests returns every request the server has received, oldest first
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	requests := make([]Request, len(s.requests))
+	copy(requests, s.requests)
+	return requests
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix), "/")
+	s.requests = append(s.requests, Request{Method: r.Method, Path: path, Query: r.URL.Query()})
+
+	if now := time.Now(); !now.Before(s.rateReset) {
+		s.rateRemaining = s.rateLimit
+		s.rateReset = now.Add(time.Minute)
+	}
+	limited := s.rateRemaining <= 0
+	if !limited {
+		s.rateRemaining--
+	}
+	s.writeRateHeaders(w)
+	w.Header().Set("X-Okta-Request-Id", fmt.Sprintf("oktatest-%v", len(s.requests)))
+
+	if !strings.HasPrefix(r.URL.Path, apiPrefix) {
+		writeError(w, http.StatusNotFound, ErrorCodeNotFound, "Not found: Resource not found: "+r.URL.Path)
+		return
+	}
+	if r.Header.Get("Authorization") != "SSWS "+Token {
+		writeError(w, http.StatusUnauthorized, ErrorCodeInvalidToken, "Invalid token provided")
+		return
+	}
+	if limited {
+		writeError(w, http.StatusTooManyRequests, ErrorCodeRateLimit, "API call exceeded rate limit due to too many requests.")
+		return
+	}
+	for i, e := range s.errors {
+		if e.method == r.Method && e.path == path {
+			s.errors = append(s.errors[:i], s.errors[i+1:]...)
+			writeError(w, e.status, e.code, e.summary)
+			return
+		}
+	}
+
+	segments := strings.Split(path, "/")
+	switch segments[0] {
+	case "users":
+		s.serveUsers(w, r, segments[1:])
+	case "groups":
+		s.serveGroups(w, r, segments[1:])
+	case "apps":
+		s.serveApps(w, r, segments[1:])
+	default:
+		writeNotFound(w, path)
+	}
+}
+
+func (s *Server) writeRateHeaders(w http.ResponseWriter) {
+	w.Header().Set("X-Rate-Limit-Limit", strconv.Itoa(s.rateLimit))
+	w.Header().Set("X-Rate-Limit-Remaining", strconv.Itoa(s.rateRemaining))
+	w.Header().Set("X-Rate-Limit-Reset", strconv.FormatInt(s.rateReset.Unix(), 10))
+}
+
+func (s *Server) newID(prefix string) string {
+	s.ids++
+	return fmt.Sprintf("%v%017d", prefix, s.ids)
+}
+
+type apiError struct {
+	ErrorCode    string       `json:"errorCode"`
+	ErrorSummary string       `json:"errorSummary"`
+	ErrorLink    string       `json:"errorLink"`
+	ErrorID      string       `json:"errorId"`
+	ErrorCauses  []errorCause `json:"errorCauses"`
+}
+
+type errorCause struct {
+	ErrorSummary string `json:"errorSummary"`
+}
+
+func writeError(w http.ResponseWriter, status int, code string, summary string, causes ...string) {
+	e := apiError{
+		ErrorCode:    code,
+		ErrorSummary: summary,
+		ErrorLink:    code,
+		ErrorID:      "oktatest",
+		ErrorCauses:  []errorCause{},
+	}
+	for _, c := range causes {
+		e.ErrorCauses = append(e.ErrorCauses, errorCause{ErrorSummary: c})
+	}
+	writeJSON(w, status, e)
+}
+
+func writeNotFound(w http.ResponseWriter, resource string) {
+	writeError(w, http.StatusNotFound, ErrorCodeNotFound, "Not found: Resource not found: "+resource)
+}
+
+func writeValidation(w http.ResponseWriter, causes ...string) {
+	writeError(w, http.StatusBadRequest, ErrorCodeValidation, "Api validation failed", causes...)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter) {
+	writeError(w, http.StatusMethodNotAllowed, "E0000022", "The endpoint does not support the provided HTTP method")
+}
+
+// paginate slices items using the limit and after query parameters and writes the Link headers.
+// after is the ID of the last item of the previous page.
+func paginate(w http.ResponseWriter, r *http.Request, ids []string) []string {
+	q := r.URL.Query()
+	limit := defaultPageLimit
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	start := 0
+	if after := q.Get("after"); after != "" {
+		for i, id := range ids {
+			if id == after {
+				start = i + 1
+				break
+			}
+		}
+	}
+	if start > len(ids) {
+		start = len(ids)
+	}
+	end := start + limit
+	if end > len(ids) {
+		end = len(ids)
+	}
+
+	self := *r.URL
+	self.Scheme = "http"
+	self.Host = r.Host
+	w.Header().Add("Link", fmt.Sprintf(`<%v>; rel="self"`, self.String()))
+
+	if end < len(ids) {
+		next := self
+		nq := next.Query()
+		nq.Set("after", ids[end-1])
+		nq.Set("limit", strconv.Itoa(limit))
+		next.RawQuery = nq.Encode()
+		w.Header().Add("Link", fmt.Sprintf(`<%v>; rel="next"`, next.String()))
+	}
+	return ids[start:end]
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	if r.Body == nil {
+		return nil
+	}
+	err := json.NewDecoder(r.Body).Decode(v)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+func formatTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(timeFormat)
+}
+
+func boolParam(r *http.Request, name string, def bool) bool {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+func indexOf(ids []string, id string) int {
+	for i, v := range ids {
+		if v == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeID(ids []string, id string) []string {
+	if i := indexOf(ids, id); i >= 0 {
+		return append(ids[:i:i], ids[i+1:]...)
+	}
+	return ids
+}
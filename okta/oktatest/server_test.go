@@ -0,0 +1,216 @@
+package oktatest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+func testProfile(login string) map[string]interface{} {
+	return map[string]interface{}{
+		"login":     login,
+		"email":     login,
+		"firstName": "Isaac",
+		"lastName":  "Brock",
+	}
+}
+
+func TestUserLifecycle(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	newUser := client.Users.NewUser()
+	newUser.Profile.Login = "isaac.brock@example.com"
+	newUser.Profile.Email = "isaac.brock@example.com"
+	newUser.Profile.FirstName = "Isaac"
+	newUser.Profile.LastName = "Brock"
+
+	user, _, err := client.Users.Create(newUser, false)
+	if err != nil {
+		t.Fatalf("Users.Create returned error: %v", err)
+	}
+	if user.Status != okta.UserStatusStaged {
+		t.Errorf("Status %v, want %v", user.Status, okta.UserStatusStaged)
+	}
+
+	if _, _, err := client.Users.Create(newUser, false); err == nil || !strings.Contains(err.Error(), ErrorCodeValidation) {
+		t.Errorf("Expected duplicate login to fail with %v, got %v", ErrorCodeValidation, err)
+	}
+
+	activation, _, err := client.Users.Activate(user.ID, false)
+	if err != nil {
+		t.Fatalf("Users.Activate returned error: %v", err)
+	}
+	if activation.ActivationURL == "" {
+		t.Errorf("Expected an activation URL when sendEmail=false")
+	}
+
+	if _, _, err := client.Users.SetPassword(user.ID, "Abcd1234!"); err != nil {
+		t.Fatalf("Users.SetPassword returned error: %v", err)
+	}
+
+	if _, err := client.Users.Unsuspend(user.ID); err == nil || !strings.Contains(err.Error(), ErrorCodeInvalidUserStatus) {
+		t.Errorf("Expected Unsuspend of a non suspended user to fail with %v, got %v", ErrorCodeInvalidUserStatus, err)
+	}
+	if _, err := client.Users.Suspend(user.ID); err == nil || !strings.Contains(err.Error(), ErrorCodeInvalidUserStatus) {
+		t.Errorf("Expected Suspend of a PROVISIONED user to fail, got %v", err)
+	}
+
+	server.mu.Lock()
+	server.findUser(user.ID).setStatus(okta.UserStatusActive)
+	server.mu.Unlock()
+
+	if _, err := client.Users.Suspend(user.ID); err != nil {
+		t.Fatalf("Users.Suspend returned error: %v", err)
+	}
+	if _, err := client.Users.Deactivate(user.ID); err != nil {
+		t.Fatalf("Users.Deactivate returned error: %v", err)
+	}
+
+	got, _ := server.GetUser(user.ID)
+	if got.Status != okta.UserStatusDeprovisioned || got.Password != "Abcd1234!" {
+		t.Errorf("Stored user %+v", got)
+	}
+
+	byLogin, _, err := client.Users.GetByID("isaac.brock@example.com")
+	if err != nil || byLogin.ID != user.ID {
+		t.Errorf("Users.GetByID by login returned %v, %v", byLogin, err)
+	}
+}
+
+func TestGroupMembershipPagination(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	groupID := server.AddGroup(Group{Name: "Engineering"})
+	for i := 0; i < 7; i++ {
+		userID := server.AddUser(User{Profile: testProfile(string(rune('a'+i)) + "@example.com")})
+		if _, err := client.Groups.AddUserToGroup(groupID, userID); err != nil {
+			t.Fatalf("Groups.AddUserToGroup returned error: %v", err)
+		}
+	}
+
+	users, _, err := client.Groups.GetUsers(groupID, &okta.GroupUserFilterOptions{Limit: 3, GetAllPages: true})
+	if err != nil {
+		t.Fatalf("Groups.GetUsers returned error: %v", err)
+	}
+	if len(users) != 7 {
+		t.Errorf("Groups.GetUsers returned %v users, want 7", len(users))
+	}
+
+	pages := 0
+	for _, r := range server.Requests() {
+		if r.Method == "GET" && r.Path == "groups/"+groupID+"/users" {
+			pages++
+		}
+	}
+	if pages != 3 {
+		t.Errorf("Expected 3 pages to be requested, got %v", pages)
+	}
+
+	if _, err := client.Groups.RemoveUserFromGroup(groupID, users[0].ID); err != nil {
+		t.Fatalf("Groups.RemoveUserFromGroup returned error: %v", err)
+	}
+	if len(server.GroupMembers(groupID)) != 6 {
+		t.Errorf("Expected 6 members after removal, got %v", server.GroupMembers(groupID))
+	}
+
+	groups, _, err := client.Groups.ListWithFilter(&okta.GroupFilterOptions{GroupTypeEqual: okta.GroupTypeOKTA, NameStartsWith: "eng"})
+	if err != nil || len(groups) != 1 || groups[0].Profile.Name != "Engineering" {
+		t.Errorf("Groups.ListWithFilter returned %v, %v", groups, err)
+	}
+}
+
+func TestAppAssignmentScopes(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	appID := server.AddApp(App{Name: "salesforce", Label: "Salesforce"})
+	direct := server.AddUser(User{Profile: testProfile("direct@example.com")})
+	viaGroup := server.AddUser(User{Profile: testProfile("group@example.com")})
+	server.AddUser(User{Profile: testProfile("none@example.com")})
+	groupID := server.AddGroup(Group{Name: "Sales"})
+	server.AddGroupMember(groupID, viaGroup)
+	server.AddGroupMember(groupID, direct)
+	server.AssignGroupToApp(appID, groupID)
+	server.AssignUserToApp(appID, direct)
+
+	appUsers, _, err := client.Apps.GetUsers(appID, &okta.AppFilterOptions{GetAllPages: true})
+	if err != nil {
+		t.Fatalf("Apps.GetUsers returned error: %v", err)
+	}
+	scopes := make(map[string]string)
+	for _, au := range appUsers {
+		scopes[au.ID] = au.Scope
+	}
+	if len(scopes) != 2 || scopes[direct] != "USER" || scopes[viaGroup] != "GROUP" {
+		t.Errorf("Unexpected app user scopes %v", scopes)
+	}
+
+	appGroups, _, err := client.Apps.GetGroups(appID)
+	if err != nil || len(appGroups) != 1 || appGroups[0].ID != groupID {
+		t.Errorf("Apps.GetGroups returned %v, %v", appGroups, err)
+	}
+}
+
+func TestErrorsAndRateLimits(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := server.Client()
+	client.PauseOnRateLimit = false
+
+	_, resp, err := client.Users.GetByID("00umissing")
+	if err == nil || !strings.Contains(err.Error(), ErrorCodeNotFound) {
+		t.Errorf("Expected %v, got %v", ErrorCodeNotFound, err)
+	}
+	if resp.StatusCode != http.StatusNotFound || resp.OKTARequestID == "" || resp.Rate.RatePerMinuteLimit != defaultRateLimit {
+		t.Errorf("Unexpected response %v %+v", resp.StatusCode, resp.Rate)
+	}
+
+	userID := server.AddUser(User{Profile: testProfile("isaac.brock@example.com")})
+	server.FailNext("GET", "users/"+userID, http.StatusInternalServerError, "E0000009", "Internal Server Error")
+	if _, _, err := client.Users.GetByID(userID); err == nil || !strings.Contains(err.Error(), "E0000009") {
+		t.Errorf("Expected injected error, got %v", err)
+	}
+	if _, _, err := client.Users.GetByID(userID); err != nil {
+		t.Errorf("Injected error should only fire once, got %v", err)
+	}
+
+	// the client from Client() has no floor, so it isn't stopped 100 requests before the limit
+	for i := 0; i < defaultRateLimit-100; i++ {
+		if _, _, err := client.Users.GetByID(userID); err != nil {
+			t.Fatalf("request %v should not be stopped by the rate limit floor: %v", i, err)
+		}
+	}
+
+	// remaining goes down with every request until the client's floor stops it
+	server.SetRateLimit(600, 3, time.Now().Add(time.Minute))
+	client.RateRemainingFloor = 2
+	client.PauseOnRateLimit = false
+	for i := 0; i < 2; i++ {
+		if _, resp, err := client.Users.GetByID(userID); err != nil || resp.Rate.Remaining != 2-i {
+			t.Errorf("request %v should leave %v remaining, got %+v %v", i, 2-i, resp, err)
+		}
+	}
+	sent := len(server.Requests())
+	if _, _, err := client.Users.GetByID(userID); err == nil || len(server.Requests()) != sent {
+		t.Errorf("the client should stop under its floor without sending, got %v", err)
+	}
+
+	server.SetRateLimit(600, 0, time.Now().Add(time.Minute))
+	_, _, err = client.Users.GetByID(userID)
+	if _, ok := err.(*okta.RateLimitError); !ok {
+		t.Errorf("Expected *okta.RateLimitError, got %T %v", err, err)
+	}
+
+	bad := okta.NewClientWithBaseURL(nil, server.BaseURL(), "wrong-token")
+	if _, _, err := bad.Users.GetByID(userID); err == nil || !strings.Contains(err.Error(), ErrorCodeInvalidToken) {
+		t.Errorf("Expected %v, got %v", ErrorCodeInvalidToken, err)
+	}
+}
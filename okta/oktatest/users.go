@@ -0,0 +1,498 @@
+package oktatest
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+// User is a user stored in the Server. Profile holds every profile attribute, including custom ones.
+type User struct {
+	ID               string
+	Status           string
+	Profile          map[string]interface{}
+	Password         string
+	RecoveryQuestion string
+	RecoveryAnswer   string
+
+	Created         time.Time
+	Activated       time.Time
+	StatusChanged   time.Time
+	LastLogin       time.Time
+	LastUpdated     time.Time
+	PasswordChanged time.Time
+}
+
+// Factor is an enrolled MFA factor stored in the Server
+type Factor struct {
+	ID          string
+	FactorType  string
+	Provider    string
+	VendorName  string
+	Status      string
+	Created     time.Time
+	LastUpdated time.Time
+}
+
+// AddUser stores a user and returns its ID. Status defaults to ACTIVE and ID is generated when empty.
+func (s *Server) AddUser(u User) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u.ID == "" {
+		u.ID = s.newID("00u")
+	}
+	if u.Status == "" {
+		u.Status = okta.UserStatusActive
+	}
+	if u.Created.IsZero() {
+		u.Created = time.Now()
+	}
+	if u.LastUpdated.IsZero() {
+		u.LastUpdated = u.Created
+	}
+	u.Profile = copyProfile(u.Profile)
+	s.users = append(s.users, &u)
+	return u.ID
+}
+
+// GetUser returns a copy of a stored user
+func (s *Server) GetUser(id string) (User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.findUser(id)
+	if u == nil {
+		return User{}, false
+	}
+	c := *u
+	c.Profile = copyProfile(u.Profile)
+	return c, true
+}
+
+// Users returns copies of every stored user in creation order
+func (s *Server) Users() []User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := make([]User, 0, len(s.users))
+	for _, u := range s.users {
+		c := *u
+		c.Profile = copyProfile(u.Profile)
+		users = append(users, c)
+	}
+	return users
+}
+
+// AddFactor enrolls a factor for a user and returns the factor ID. Status defaults to ACTIVE.
+func (s *Server) AddFactor(userID string, f Factor) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f.ID == "" {
+		f.ID = s.newID("mfa")
+	}
+	if f.Status == "" {
+		f.Status = okta.MFAStatusActive
+	}
+	if f.Created.IsZero() {
+		f.Created = time.Now()
+	}
+	if f.LastUpdated.IsZero() {
+		f.LastUpdated = f.Created
+	}
+	s.factors[userID] = append(s.factors[userID], &f)
+	return f.ID
+}
+
+func copyProfile(p map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(p))
+	for k, v := range p {
+		c[k] = v
+	}
+	return c
+}
+
+// findUser looks a user up by ID or login like OKTA does
+func (s *Server) findUser(id string) *User {
+	for _, u := range s.users {
+		if u.ID == id {
+			return u
+		}
+	}
+	for _, u := range s.users {
+		if login, _ := u.Profile["login"].(string); login != "" && strings.EqualFold(login, id) {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *Server) userJSON(u *User) map[string]interface{} {
+	creds := map[string]interface{}{
+		"provider": map[string]string{"type": "OKTA", "name": "OKTA"},
+	}
+	if u.Password != "" {
+		creds["password"] = map[string]string{}
+	}
+	if u.RecoveryQuestion != "" {
+		creds["recovery_question"] = map[string]string{"question": u.RecoveryQuestion}
+	}
+	return map[string]interface{}{
+		"id":              u.ID,
+		"status":          u.Status,
+		"created":         formatTime(u.Created),
+		"activated":       formatTime(u.Activated),
+		"statusChanged":   formatTime(u.StatusChanged),
+		"lastLogin":       formatTime(u.LastLogin),
+		"lastUpdated":     formatTime(u.LastUpdated),
+		"passwordChanged": formatTime(u.PasswordChanged),
+		"profile":         u.Profile,
+		"credentials":     creds,
+		"_links": map[string]interface{}{
+			"self": map[string]string{"href": s.URL + apiPrefix + "users/" + u.ID},
+		},
+	}
+}
+
+func (u *User) lookup(attr string) (string, bool) {
+	switch attr {
+	case "id":
+		return u.ID, true
+	case "status":
+		return u.Status, true
+	case "created":
+		return u.Created.UTC().Format(timeFormat), true
+	case "lastUpdated":
+		return u.LastUpdated.UTC().Format(timeFormat), true
+	}
+	if strings.HasPrefix(attr, "profile.") {
+		return stringValue(u.Profile[strings.TrimPrefix(attr, "profile.")])
+	}
+	return "", false
+}
+
+func (u *User) setStatus(status string) {
+	now := time.Now()
+	u.Status = status
+	u.StatusChanged = now
+	u.LastUpdated = now
+	if status == okta.UserStatusActive && u.Activated.IsZero() {
+		u.Activated = now
+	}
+}
+
+type userCredentialsInput struct {
+	Password *struct {
+		Value string `json:"value"`
+	} `json:"password"`
+	RecoveryQuestion *struct {
+		Question string `json:"question"`
+		Answer   string `json:"answer"`
+	} `json:"recovery_question"`
+}
+
+type userInput struct {
+	Profile     map[string]interface{} `json:"profile"`
+	Credentials *userCredentialsInput  `json:"credentials"`
+}
+
+func (s *Server) serveUsers(w http.ResponseWriter, r *http.Request, segments []string) {
+	if len(segments) == 0 || segments[0] == "" {
+		switch r.Method {
+		case "GET":
+			s.listUsers(w, r)
+		case "POST":
+			s.createUser(w, r)
+		default:
+			writeMethodNotAllowed(w)
+		}
+		return
+	}
+
+	u := s.findUser(segments[0])
+	if u == nil {
+		writeNotFound(w, segments[0]+" (User)")
+		return
+	}
+
+	switch {
+	case len(segments) == 1:
+		switch r.Method {
+		case "GET":
+			writeJSON(w, http.StatusOK, s.userJSON(u))
+		case "POST", "PUT":
+			s.updateUser(w, r, u, r.Method == "PUT")
+		case "DELETE":
+			s.deleteUser(w, u)
+		default:
+			writeMethodNotAllowed(w)
+		}
+	case len(segments) == 2 && segments[1] == "groups" && r.Method == "GET":
+		var ids []string
+		for _, g := range s.groups {
+			if indexOf(s.members[g.ID], u.ID) >= 0 {
+				ids = append(ids, g.ID)
+			}
+		}
+		s.writeGroupPage(w, r, ids)
+	case len(segments) == 2 && segments[1] == "factors" && r.Method == "GET":
+		factors := []interface{}{}
+		for _, f := range s.factors[u.ID] {
+			factors = append(factors, factorJSON(f))
+		}
+		writeJSON(w, http.StatusOK, factors)
+	case len(segments) == 3 && segments[1] == "lifecycle" && r.Method == "POST":
+		s.userLifecycle(w, r, u, segments[2])
+	default:
+		writeNotFound(w, r.URL.Path)
+	}
+}
+
+func factorJSON(f *Factor) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          f.ID,
+		"factorType":  f.FactorType,
+		"provider":    f.Provider,
+		"vendorName":  f.VendorName,
+		"status":      f.Status,
+		"created":     formatTime(f.Created),
+		"lastUpdated": formatTime(f.LastUpdated),
+		"profile":     map[string]interface{}{},
+	}
+}
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	expr := q.Get("filter")
+	if search := q.Get("search"); search != "" {
+		expr = search
+	}
+	f, err := parseFilter(expr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrorCodeValidation, err.Error())
+		return
+	}
+	prefix := strings.ToLower(q.Get("q"))
+
+	var ids []string
+	for _, u := range s.users {
+		if !f.match(u.lookup) {
+			continue
+		}
+		// Without a filter or search OKTA does not return DEPROVISIONED users
+		if expr == "" && u.Status == okta.UserStatusDeprovisioned {
+			continue
+		}
+		if prefix != "" && !userMatchesQ(u, prefix) {
+			continue
+		}
+		ids = append(ids, u.ID)
+	}
+
+	page := paginate(w, r, ids)
+	users := make([]interface{}, 0, len(page))
+	for _, id := range page {
+		users = append(users, s.userJSON(s.findUser(id)))
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
+func userMatchesQ(u *User, prefix string) bool {
+	for _, attr := range []string{"firstName", "lastName", "email"} {
+		if v, ok := u.Profile[attr].(string); ok && strings.HasPrefix(strings.ToLower(v), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) validateProfile(profile map[string]interface{}, self *User) []string {
+	var causes []string
+	for _, attr := range []string{"login", "email", "firstName", "lastName"} {
+		if v, _ := profile[attr].(string); v == "" {
+			causes = append(causes, attr+": The field cannot be left blank")
+		}
+	}
+	if login, _ := profile["login"].(string); login != "" {
+		if existing := s.findUser(login); existing != nil && existing != self {
+			causes = append(causes, "login: An object with this field already exists in the current organization")
+		}
+	}
+	return causes
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	var in userInput
+	if err := decodeBody(r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, ErrorCodeValidation, "The request body was not well-formed.")
+		return
+	}
+	if in.Profile == nil {
+		in.Profile = make(map[string]interface{})
+	}
+	if causes := s.validateProfile(in.Profile, nil); len(causes) > 0 {
+		writeValidation(w, causes...)
+		return
+	}
+
+	now := time.Now()
+	u := &User{
+		ID:          s.newID("00u"),
+		Status:      okta.UserStatusStaged,
+		Profile:     in.Profile,
+		Created:     now,
+		LastUpdated: now,
+	}
+	applyCredentials(u, in.Credentials)
+
+	if boolParam(r, "activate", true) {
+		if u.Password != "" {
+			u.setStatus(okta.UserStatusActive)
+		} else {
+			u.setStatus(okta.UserStatusProvisioned)
+		}
+	}
+	s.users = append(s.users, u)
+	writeJSON(w, http.StatusOK, s.userJSON(u))
+}
+
+func applyCredentials(u *User, creds *userCredentialsInput) {
+	if creds == nil {
+		return
+	}
+	if creds.Password != nil && creds.Password.Value != "" {
+		u.Password = creds.Password.Value
+		u.PasswordChanged = time.Now()
+	}
+	if creds.RecoveryQuestion != nil && creds.RecoveryQuestion.Question != "" {
+		u.RecoveryQuestion = creds.RecoveryQuestion.Question
+		u.RecoveryAnswer = creds.RecoveryQuestion.Answer
+	}
+}
+
+// updateUser handles a partial (POST) or full (PUT) update of the profile and credentials
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request, u *User, replace bool) {
+	var in userInput
+	if err := decodeBody(r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, ErrorCodeValidation, "The request body was not well-formed.")
+		return
+	}
+
+	if in.Profile != nil {
+		profile := in.Profile
+		if !replace {
+			profile = copyProfile(u.Profile)
+			for k, v := range in.Profile {
+				profile[k] = v
+			}
+		}
+		if causes := s.validateProfile(profile, u); len(causes) > 0 {
+			writeValidation(w, causes...)
+			return
+		}
+		u.Profile = profile
+	}
+	applyCredentials(u, in.Credentials)
+	u.LastUpdated = time.Now()
+	writeJSON(w, http.StatusOK, s.userJSON(u))
+}
+
+// deleteUser deactivates an active user and deletes a DEPROVISIONED one, like OKTA does
+func (s *Server) deleteUser(w http.ResponseWriter, u *User) {
+	if u.Status != okta.UserStatusDeprovisioned {
+		u.setStatus(okta.UserStatusDeprovisioned)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	for i, existing := range s.users {
+		if existing == u {
+			s.users = append(s.users[:i], s.users[i+1:]...)
+			break
+		}
+	}
+	for groupID := range s.members {
+		s.members[groupID] = removeID(s.members[groupID], u.ID)
+	}
+	for appID := range s.appUsers {
+		s.appUsers[appID] = removeID(s.appUsers[appID], u.ID)
+	}
+	delete(s.factors, u.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeInvalidStatus(w http.ResponseWriter) {
+	writeError(w, http.StatusForbidden, ErrorCodeInvalidUserStatus, "This operation is not allowed in the user's current status.")
+}
+
+func (s *Server) userLifecycle(w http.ResponseWriter, r *http.Request, u *User, action string) {
+	sendEmail := boolParam(r, "sendEmail", true)
+
+	switch action {
+	case "activate":
+		if u.Status == okta.UserStatusActive {
+			writeError(w, http.StatusForbidden, ErrorCodeAlreadyActive, "Activation failed because the user is already active")
+			return
+		}
+		if u.Password != "" {
+			u.setStatus(okta.UserStatusActive)
+		} else {
+			u.setStatus(okta.UserStatusProvisioned)
+		}
+		if sendEmail {
+			writeJSON(w, http.StatusOK, map[string]string{})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{
+			"activationUrl":   s.URL + "/welcome/" + u.ID,
+			"activationToken": u.ID,
+		})
+	case "deactivate":
+		u.setStatus(okta.UserStatusDeprovisioned)
+		writeJSON(w, http.StatusOK, map[string]string{})
+	case "suspend":
+		if u.Status != okta.UserStatusActive {
+			writeInvalidStatus(w)
+			return
+		}
+		u.setStatus(okta.UserStatusSuspended)
+		writeJSON(w, http.StatusOK, map[string]string{})
+	case "unsuspend":
+		if u.Status != okta.UserStatusSuspended {
+			writeInvalidStatus(w)
+			return
+		}
+		u.setStatus(okta.UserStatusActive)
+		writeJSON(w, http.StatusOK, map[string]string{})
+	case "unlock":
+		if u.Status != okta.UserStatusLockedOut {
+			writeError(w, http.StatusForbidden, "E0000032", "Unlock is not allowed for this user.")
+			return
+		}
+		u.setStatus(okta.UserStatusActive)
+		writeJSON(w, http.StatusOK, map[string]string{})
+	case "reset_password":
+		if u.Status == okta.UserStatusStaged || u.Status == okta.UserStatusDeprovisioned {
+			writeInvalidStatus(w)
+			return
+		}
+		u.setStatus(okta.UserStatusRecovery)
+		if sendEmail {
+			writeJSON(w, http.StatusOK, map[string]string{})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"resetPasswordUrl": s.URL + "/reset_password/" + u.ID})
+	case "expire_password":
+		if u.Status != okta.UserStatusActive {
+			writeInvalidStatus(w)
+			return
+		}
+		u.setStatus(okta.UserStatusPasswordExpired)
+		writeJSON(w, http.StatusOK, s.userJSON(u))
+	default:
+		writeNotFound(w, r.URL.Path)
+	}
+}
@@ -0,0 +1,191 @@
+// Package otelokta records OpenTelemetry traces and metrics for every OKTA API call.
+// It lives in its own package so programs that don't import it don't depend on OpenTelemetry.
+//
+//	client.Observer = otelokta.New()
+//
+// Each call gets a client span named after the method and endpoint template (GET /api/v1/users/{id})
+// with the status, X-Okta-Request-Id, retries and rate limit pause time as attributes. The
+// metrics are:
+//
+//	okta.client.requests                 counter of calls by method, endpoint and status
+//	okta.client.request.duration         histogram of call duration in seconds
+//	okta.client.retries                  counter of requests sent again after a 429 or DPoP nonce challenge
+//	okta.client.rate_limit.remaining     gauge of X-Rate-Limit-Remaining by endpoint
+//	okta.client.rate_limit.pause         histogram of time spent waiting for the rate limit to reset, in seconds
+package otelokta
+
+import (
+	"context"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/chrismalek/oktasdk-go/okta/otelokta"
+
+// Attribute keys used on spans and metrics
+const (
+	AttrEndpoint       = attribute.Key("okta.endpoint")
+	AttrRequestID      = attribute.Key("okta.request_id")
+	AttrRetries        = attribute.Key("okta.retries")
+	AttrRateLimitPause = attribute.Key("okta.rate_limit.pause_ms")
+	AttrRateLimit      = attribute.Key("okta.rate_limit.limit")
+	AttrRateRemaining  = attribute.Key("okta.rate_limit.remaining")
+	attrMethod         = attribute.Key("http.request.method")
+	attrStatus         = attribute.Key("http.response.status_code")
+	attrURL            = attribute.Key("url.full")
+)
+
+// Option configures the Observer returned by New
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagator     propagation.TextMapPropagator
+}
+
+// WithTracerProvider uses tp instead of the global tracer provider
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) { c.tracerProvider = tp }
+}
+
+// WithMeterProvider uses mp instead of the global meter provider
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) { c.meterProvider = mp }
+}
+
+// WithPropagator injects trace context into requests with p instead of the global propagator
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(c *config) { c.propagator = p }
+}
+
+// Observer implements okta.Observer with OpenTelemetry
+type Observer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+
+	requests  metric.Int64Counter
+	duration  metric.Float64Histogram
+	retries   metric.Int64Counter
+	remaining metric.Int64Gauge
+	pause     metric.Float64Histogram
+}
+
+var _ okta.Observer = (*Observer)(nil)
+
+// New creates an Observer using the global OpenTelemetry providers unless options say otherwise
+func New(opts ...Option) (*Observer, error) {
+	cfg := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagator:     otel.GetTextMapPropagator(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	meter := cfg.meterProvider.Meter(instrumentationName)
+	o := &Observer{
+		tracer:     cfg.tracerProvider.Tracer(instrumentationName),
+		propagator: cfg.propagator,
+	}
+
+	var err error
+	if o.requests, err = meter.Int64Counter("okta.client.requests",
+		metric.WithDescription("OKTA API calls")); err != nil {
+		return nil, err
+	}
+	if o.duration, err = meter.Float64Histogram("okta.client.request.duration",
+		metric.WithDescription("OKTA API call duration including retries and rate limit pauses"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if o.retries, err = meter.Int64Counter("okta.client.retries",
+		metric.WithDescription("OKTA API requests sent again after a 429 or DPoP nonce challenge")); err != nil {
+		return nil, err
+	}
+	if o.remaining, err = meter.Int64Gauge("okta.client.rate_limit.remaining",
+		metric.WithDescription("X-Rate-Limit-Remaining of the last response")); err != nil {
+		return nil, err
+	}
+	if o.pause, err = meter.Float64Histogram("okta.client.rate_limit.pause",
+		metric.WithDescription("Time spent waiting for the OKTA rate limit to reset"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// StartCall implements okta.Observer
+func (o *Observer) StartCall(ctx context.Context, call okta.Call) (context.Context, func(okta.CallResult)) {
+	endpoint := call.Method + " " + call.Endpoint
+	ctx, span := o.tracer.Start(ctx, endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attrMethod.String(call.Method),
+			AttrEndpoint.String(call.Endpoint),
+			attrURL.String(redactQuery(call)),
+		),
+	)
+	if call.Header != nil {
+		o.propagator.Inject(ctx, propagation.HeaderCarrier(call.Header))
+	}
+
+	return ctx, func(result okta.CallResult) {
+		defer span.End()
+
+		attrs := []attribute.KeyValue{attrMethod.String(call.Method), AttrEndpoint.String(call.Endpoint)}
+		if result.StatusCode != 0 {
+			attrs = append(attrs, attrStatus.Int(result.StatusCode))
+		}
+		metricAttrs := metric.WithAttributes(attrs...)
+
+		o.requests.Add(ctx, 1, metricAttrs)
+		o.duration.Record(ctx, result.Duration.Seconds(), metricAttrs)
+		if result.Retries > 0 {
+			o.retries.Add(ctx, int64(result.Retries), metricAttrs)
+		}
+		if !result.Rate.ResetTime.IsZero() {
+			o.remaining.Record(ctx, int64(result.Rate.Remaining), metric.WithAttributes(AttrEndpoint.String(call.Endpoint)))
+		}
+		if result.RateLimitPause > 0 {
+			o.pause.Record(ctx, result.RateLimitPause.Seconds(), metric.WithAttributes(AttrEndpoint.String(call.Endpoint)))
+		}
+
+		span.SetAttributes(
+			AttrRetries.Int(result.Retries),
+			AttrRateLimitPause.Int64(result.RateLimitPause.Milliseconds()),
+		)
+		if result.StatusCode != 0 {
+			span.SetAttributes(attrStatus.Int(result.StatusCode))
+		}
+		if result.OKTARequestID != "" {
+			span.SetAttributes(AttrRequestID.String(result.OKTARequestID))
+		}
+		if !result.Rate.ResetTime.IsZero() {
+			span.SetAttributes(
+				AttrRateLimit.Int(result.Rate.RatePerMinuteLimit),
+				AttrRateRemaining.Int(result.Rate.Remaining),
+			)
+		}
+		if result.Err != nil {
+			span.RecordError(result.Err)
+			span.SetStatus(codes.Error, result.Err.Error())
+		}
+	}
+}
+
+// redactQuery drops the query because filters and searches often hold logins and names
+func redactQuery(call okta.Call) string {
+	if call.URL == nil {
+		return ""
+	}
+	u := *call.URL
+	u.RawQuery = ""
+	u.User = nil
+	return u.String()
+}
@@ -0,0 +1,89 @@
+package otelokta
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestObserver(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		w.Header().Set("X-Okta-Request-Id", "req-123")
+		w.Header().Set("X-Rate-Limit-Limit", "600")
+		w.Header().Set("X-Rate-Limit-Remaining", "598")
+		w.Header().Set("X-Rate-Limit-Reset", "1700000000")
+		w.Write([]byte(`{"id":"00u1abcd2EFGH3ijkl4m5"}`))
+	}))
+	defer server.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	observer, err := New(
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithPropagator(propagation.TraceContext{}),
+	)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	baseURL, _ := url.Parse(server.URL + "/api/v1/")
+	client := okta.NewClientWithBaseURL(nil, baseURL, "token")
+	client.Observer = observer
+	if _, _, err := client.Users.GetByID("00u1abcd2EFGH3ijkl4m5"); err != nil {
+		t.Fatalf("GetByID returned error: %v", err)
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("expected 1 span but got %v", len(ended))
+	}
+	span := ended[0]
+	if span.Name() != "GET /api/v1/users/{id}" {
+		t.Errorf("span name is %v", span.Name())
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs[AttrRequestID].AsString() != "req-123" || attrs[attrStatus].AsInt64() != 200 || attrs[AttrRateRemaining].AsInt64() != 598 {
+		t.Errorf("unexpected span attributes %v", attrs)
+	}
+	if traceparent == "" {
+		t.Errorf("trace context should be propagated to OKTA")
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			found[m.Name] = true
+			if m.Name == "okta.client.rate_limit.remaining" {
+				gauge := m.Data.(metricdata.Gauge[int64])
+				if gauge.DataPoints[0].Value != 598 {
+					t.Errorf("remaining gauge should be 598 but is %v", gauge.DataPoints[0].Value)
+				}
+			}
+		}
+	}
+	for _, name := range []string{"okta.client.requests", "okta.client.request.duration", "okta.client.rate_limit.remaining"} {
+		if !found[name] {
+			t.Errorf("metric %v was not recorded", name)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package okta
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// GroupMemberLister lists a group's members. GroupsAPI is one.
+type GroupMemberLister interface {
+	GetUsers(groupID string, opt *GroupUserFilterOptions) ([]User, *Response, error)
+}
+
+// ReconcileOptions control Reconcile
+type ReconcileOptions struct {
+	// DryRun only computes the plan
+	DryRun bool
+	// MaxRemovePercent aborts when more than this percentage of the current members would be
+	// removed. 0 means no limit.
+	MaxRemovePercent float64
+	// MaxRemove aborts when more than this many members would be removed. 0 means no limit.
+	MaxRemove int
+	// AllowEmpty lets a plan remove every current member. Without it such a plan aborts, so an
+	// empty or broken source of truth doesn't empty the group.
+	AllowEmpty bool
+	// Bulk configures the bulk runs that apply the changes
+	Bulk *BulkOptions
+}
+
+// ReconcilePlan is the difference between a group's members and the desired members
+type ReconcilePlan struct {
+	GroupID string
+	// Current and Desired are the number of members now and after the plan is applied
+	Current   int
+	Desired   int
+	Unchanged int
+	// Add and Remove are user IDs, sorted
+	Add    []string
+	Remove []string
+}
+
+// RemovePercent is the share of current members the plan removes
+func (p *ReconcilePlan) RemovePercent() float64 {
+	if p.Current == 0 {
+		return 0
+	}
+	return float64(len(p.Remove)) * 100 / float64(p.Current)
+}
+
+// ReconcileResult is what Reconcile did
+type ReconcileResult struct {
+	Plan *ReconcilePlan
+	// Applied is false for dry runs and plans over a safety threshold
+	Applied bool
+	Adds    *BulkReport
+	Removes *BulkReport
+}
+
+// ReconcileThresholdError is returned when a plan removes more members than ReconcileOptions allow.
+// Nothing has been changed.
+type ReconcileThresholdError struct {
+	Plan   *ReconcilePlan
+	Reason string
+}
+
+func (e *ReconcileThresholdError) Error() string {
+	return fmt.Sprintf("reconcile of group %v aborted: %v", e.Plan.GroupID, e.Reason)
+}
+
+// PlanReconcile compares the group's members with desiredUserIDs
+func PlanReconcile(groups GroupMemberLister, groupID string, desiredUserIDs []string) (*ReconcilePlan, error) {
+	members, _, err := groups.GetUsers(groupID, &GroupUserFilterOptions{GetAllPages: true})
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]bool, len(members))
+	for _, member := range members {
+		current[member.ID] = true
+	}
+	desired := make(map[string]bool, len(desiredUserIDs))
+	for _, id := range desiredUserIDs {
+		if id != "" {
+			desired[id] = true
+		}
+	}
+
+	plan := &ReconcilePlan{GroupID: groupID, Current: len(current), Desired: len(desired)}
+	for id := range desired {
+		if current[id] {
+			plan.Unchanged++
+		} else {
+			plan.Add = append(plan.Add, id)
+		}
+	}
+	for id := range current {
+		if !desired[id] {
+			plan.Remove = append(plan.Remove, id)
+		}
+	}
+	sort.Strings(plan.Add)
+	sort.Strings(plan.Remove)
+	return plan, nil
+}
+
+// Reconcile makes the group's members exactly desiredUserIDs. The members are read with
+// client.Groups and users are added before any are removed, both through client.Bulk. With
+// opt.DryRun, or when the plan is over a safety threshold (then with a *ReconcileThresholdError),
+// only the plan is returned.
+func Reconcile(ctx context.Context, client *Client, groupID string, desiredUserIDs []string, opt *ReconcileOptions) (*ReconcileResult, error) {
+	if opt == nil {
+		opt = new(ReconcileOptions)
+	}
+	plan, err := PlanReconcile(client.Groups, groupID, desiredUserIDs)
+	if err != nil {
+		return nil, err
+	}
+	result := &ReconcileResult{Plan: plan}
+
+	if !opt.AllowEmpty && plan.Current > 0 && len(plan.Remove) == plan.Current {
+		return result, &ReconcileThresholdError{Plan: plan, Reason: fmt.Sprintf("all %d members would be removed, set AllowEmpty to allow it", plan.Current)}
+	}
+	if opt.MaxRemove > 0 && len(plan.Remove) > opt.MaxRemove {
+		return result, &ReconcileThresholdError{Plan: plan, Reason: fmt.Sprintf("%d members would be removed, the limit is %d", len(plan.Remove), opt.MaxRemove)}
+	}
+	if opt.MaxRemovePercent > 0 && plan.RemovePercent() > opt.MaxRemovePercent {
+		return result, &ReconcileThresholdError{Plan: plan, Reason: fmt.Sprintf("%.1f%% of %d members would be removed, the limit is %.1f%%", plan.RemovePercent(), plan.Current, opt.MaxRemovePercent)}
+	}
+	if opt.DryRun {
+		return result, nil
+	}
+
+	bulk := client.Bulk
+	result.Applied = true
+	result.Adds = bulk.AddUsersToGroup(ctx, groupID, plan.Add, opt.Bulk)
+	result.Removes = bulk.RemoveUsersFromGroup(ctx, groupID, plan.Remove, opt.Bulk)
+	if failed := result.Adds.Failed + result.Removes.Failed; failed > 0 {
+		return result, fmt.Errorf("reconcile of group %v: %d of %d changes failed", groupID, failed, len(plan.Add)+len(plan.Remove))
+	}
+	return result, nil
+}
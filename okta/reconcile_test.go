@@ -0,0 +1,95 @@
+package okta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// setupGroupMembers serves a group with members and records membership changes
+func setupGroupMembers(t *testing.T, members ...string) (changes func() []string) {
+	var mu sync.Mutex
+	var changed []string
+	mux.HandleFunc("/groups/00g1/users", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		var users []string
+		for _, id := range members {
+			users = append(users, fmt.Sprintf(`{"id":%q}`, id))
+		}
+		fmt.Fprint(w, "["+strings.Join(users, ",")+"]")
+	})
+	mux.HandleFunc("/groups/00g1/users/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		changed = append(changed, r.Method+" "+strings.TrimPrefix(r.URL.Path, "/groups/00g1/users/"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		sort.Strings(changed)
+		return changed
+	}
+}
+
+func TestGroupReconcile(t *testing.T) {
+	setup()
+	defer teardown()
+	changes := setupGroupMembers(t, "00u1", "00u2", "00u3")
+
+	result, err := Reconcile(context.Background(), client, "00g1", []string{"00u2", "00u3", "00u4", "00u4", "00u5"}, nil)
+	if err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+	plan := result.Plan
+	if !reflect.DeepEqual(plan.Add, []string{"00u4", "00u5"}) || !reflect.DeepEqual(plan.Remove, []string{"00u1"}) || plan.Unchanged != 2 || plan.Desired != 4 {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+	if !result.Applied || result.Adds.Succeeded != 2 || result.Removes.Succeeded != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	want := []string{"DELETE 00u1", "PUT 00u4", "PUT 00u5"}
+	if !reflect.DeepEqual(changes(), want) {
+		t.Errorf("changes should be %v but were %v", want, changes())
+	}
+}
+
+func TestGroupReconcileSafety(t *testing.T) {
+	setup()
+	defer teardown()
+	changes := setupGroupMembers(t, "00u1", "00u2", "00u3", "00u4")
+
+	result, err := Reconcile(context.Background(), client, "00g1", []string{"00u1", "00u9"}, &ReconcileOptions{DryRun: true})
+	if err != nil || result.Applied || len(result.Plan.Remove) != 3 {
+		t.Errorf("dry run should only plan, got %+v %v", result, err)
+	}
+
+	_, err = Reconcile(context.Background(), client, "00g1", []string{"00u1", "00u9"}, &ReconcileOptions{MaxRemovePercent: 50})
+	thresholdErr, ok := err.(*ReconcileThresholdError)
+	if !ok {
+		t.Fatalf("error should be a *ReconcileThresholdError but got %T %v", err, err)
+	}
+	if thresholdErr.Plan.RemovePercent() != 75 {
+		t.Errorf("plan should remove 75%% but removes %v", thresholdErr.Plan.RemovePercent())
+	}
+
+	if _, err = Reconcile(context.Background(), client, "00g1", []string{"00u1", "00u9"}, &ReconcileOptions{MaxRemove: 2}); err == nil {
+		t.Errorf("MaxRemove should abort the reconcile")
+	}
+	if _, err = Reconcile(context.Background(), client, "00g1", nil, nil); err == nil {
+		t.Errorf("removing every member should abort without AllowEmpty")
+	}
+	if len(changes()) != 0 {
+		t.Errorf("nothing should change, got %v", changes())
+	}
+
+	result, err = Reconcile(context.Background(), client, "00g1", nil, &ReconcileOptions{AllowEmpty: true})
+	if err != nil || !result.Applied || result.Removes.Succeeded != 4 {
+		t.Errorf("AllowEmpty should remove every member, got %+v %v", result, err)
+	}
+}
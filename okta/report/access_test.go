@@ -0,0 +1,62 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/chrismalek/oktasdk-go/okta/oktatest"
+)
+
+func TestAccess(t *testing.T) {
+	server := oktatest.NewServer()
+	defer server.Close()
+
+	anna := server.AddUser(oktatest.User{ID: "00uanna", Profile: map[string]interface{}{"login": "anna@example.com"}})
+	bob := server.AddUser(oktatest.User{ID: "00ubob", Profile: map[string]interface{}{"login": "bob@example.com"}})
+	carol := server.AddUser(oktatest.User{ID: "00ucarol", Status: "SUSPENDED", Profile: map[string]interface{}{"login": "carol@example.com"}})
+	eng := server.AddGroup(oktatest.Group{ID: "00geng", Name: "Engineering"})
+	ops := server.AddGroup(oktatest.Group{ID: "00gops", Name: "Ops"})
+	server.AddGroupMember(eng, anna)
+	server.AddGroupMember(eng, bob)
+	server.AddGroupMember(ops, bob)
+	aws := server.AddApp(oktatest.App{ID: "0oaaws", Name: "amazon_aws", Label: "AWS"})
+	server.AssignGroupToApp(aws, eng)
+	server.AssignGroupToApp(aws, ops)
+	server.AssignUserToApp(aws, bob)
+	server.AssignUserToApp(aws, carol)
+	server.AddApp(oktatest.App{ID: "0oaold", Name: "old", Label: "Old", Status: "INACTIVE"})
+
+	review, err := Access(context.Background(), server.Client(), nil)
+	if err != nil {
+		t.Fatalf("Access returned error: %v", err)
+	}
+	if len(review.Apps) != 1 {
+		t.Fatalf("only ACTIVE apps should be reviewed, got %+v", review.Apps)
+	}
+
+	var buf bytes.Buffer
+	if err := review.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV returned error: %v", err)
+	}
+	want := "appId,appLabel,userId,login,userStatus,appUserName,status,scope,direct,groups,source\n" +
+		"0oaaws,AWS,00uanna,anna@example.com,ACTIVE,anna@example.com,ACTIVE,GROUP,false,Engineering,group: Engineering\n" +
+		"0oaaws,AWS,00ubob,bob@example.com,ACTIVE,bob@example.com,ACTIVE,USER,true,Engineering;Ops,\"direct, group: Engineering; Ops\"\n" +
+		"0oaaws,AWS,00ucarol,carol@example.com,SUSPENDED,carol@example.com,ACTIVE,USER,true,,direct\n"
+	if buf.String() != want {
+		t.Errorf("WriteCSV should write\n%v\nbut wrote\n%v", want, buf.String())
+	}
+
+	review, err = Access(context.Background(), server.Client(), &AccessOptions{IncludeInactive: true})
+	if err != nil || len(review.Apps) != 2 || review.Apps[1].Label != "Old" || len(review.Apps[1].Users) != 0 {
+		t.Errorf("IncludeInactive should review the inactive app, got %+v %v", review, err)
+	}
+}
+
+func TestWriteCSVGuardsFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	writeCSV(&buf, []string{"login", "name"}, [][]string{{"=HYPERLINK(1)", "-2"}, {"@sum", "Anna"}})
+	if want := "login,name\n'=HYPERLINK(1),'-2\n'@sum,Anna\n"; buf.String() != want {
+		t.Errorf("writeCSV should write\n%v\nbut wrote\n%v", want, buf.String())
+	}
+}
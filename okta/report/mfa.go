@@ -0,0 +1,237 @@
+package report
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+// MFA coverage of a user, from worst to best
+const (
+	// CoverageNone is a user with no factors at all
+	CoverageNone = "none"
+	// CoveragePending is a user whose only factors are waiting for activation
+	CoveragePending = "pending"
+	// CoverageWeak is a user whose active factors are all weak
+	CoverageWeak = "weak"
+	// CoverageStrong is a user with at least one active factor that isn't weak
+	CoverageStrong = "strong"
+)
+
+var coverageOrder = map[string]int{CoverageNone: 0, CoveragePending: 1, CoverageWeak: 2, CoverageStrong: 3}
+
+// DefaultWeakFactorTypes are the factor types that can be phished or intercepted
+var DefaultWeakFactorTypes = []string{"sms", "call", "question"}
+
+// MFAOptions control the MFA report
+type MFAOptions struct {
+	// Workers is how many users' factors are read at the same time. Defaults to the Bulk default.
+	Workers int
+	// WeakFactorTypes defaults to DefaultWeakFactorTypes
+	WeakFactorTypes []string
+	// Progress is called after each user's factors are read, from the worker goroutines
+	Progress func(okta.BulkResult)
+}
+
+// MFAReport is the MFA coverage of every ACTIVE user
+type MFAReport struct {
+	Generated time.Time  `json:"generated"`
+	Summary   MFASummary `json:"summary"`
+	Users     []UserMFA  `json:"users"`
+}
+
+// MFASummary counts users by coverage. FactorTypes counts the active factors of each type and
+// PendingActivations the users with at least one factor waiting for activation.
+type MFASummary struct {
+	Users              int            `json:"users"`
+	None               int            `json:"none"`
+	Pending            int            `json:"pending"`
+	Weak               int            `json:"weak"`
+	Strong             int            `json:"strong"`
+	PendingActivations int            `json:"pendingActivations"`
+	Errors             int            `json:"errors"`
+	FactorTypes        map[string]int `json:"factorTypes"`
+}
+
+// UserMFA is one user's factors. Factors are "factorType:provider". Error is set, and Coverage
+// empty, when the factors couldn't be read.
+type UserMFA struct {
+	UserID   string   `json:"userId"`
+	Login    string   `json:"login"`
+	Coverage string   `json:"coverage,omitempty"`
+	Active   []string `json:"active"`
+	Pending  []string `json:"pending"`
+	Weak     []string `json:"weak"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// MFA reads the factors of every ACTIVE user concurrently through client.Bulk, so they share the
+// client's rate limit handling. A user whose factors can't be read is reported with the error
+// instead of failing the report.
+func MFA(ctx context.Context, client *okta.Client, opt *MFAOptions) (*MFAReport, error) {
+	if opt == nil {
+		opt = &MFAOptions{}
+	}
+	weakTypes := opt.WeakFactorTypes
+	if weakTypes == nil {
+		weakTypes = DefaultWeakFactorTypes
+	}
+	weak := map[string]bool{}
+	for _, t := range weakTypes {
+		weak[strings.ToLower(t)] = true
+	}
+
+	users, _, err := client.Users.ListWithFilter(&okta.UserListFilterOptions{StatusEqualTo: okta.UserStatusActive, GetAllPages: true})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+
+	var mu sync.Mutex
+	factors := make(map[string]*okta.User, len(users))
+	result := client.Bulk.Run(ctx, "mfaReport", ids, func(ctx context.Context, id string) (string, *okta.Response, error) {
+		user := &okta.User{ID: id}
+		resp, err := client.Users.PopulateEnrolledFactors(user)
+		if err != nil {
+			return "", resp, err
+		}
+		mu.Lock()
+		factors[id] = user
+		mu.Unlock()
+		return "", resp, nil
+	}, &okta.BulkOptions{Workers: opt.Workers, RateLimitRetries: okta.DefaultBulkRateLimitRetries, Progress: opt.Progress})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	failed := map[string]string{}
+	for _, f := range result.Failures() {
+		failed[f.Key] = f.Error
+		if f.ErrorCode != "" {
+			failed[f.Key] = f.ErrorCode + ": " + f.ErrorSummary
+		}
+	}
+
+	report := &MFAReport{Generated: time.Now().UTC(), Users: make([]UserMFA, 0, len(users))}
+	report.Summary.FactorTypes = map[string]int{}
+	for _, u := range users {
+		entry := UserMFA{UserID: u.ID, Login: u.Profile.Login, Active: []string{}, Pending: []string{}, Weak: []string{}}
+		report.Summary.Users++
+		if msg, ok := failed[u.ID]; ok {
+			entry.Error = msg
+			report.Summary.Errors++
+			report.Users = append(report.Users, entry)
+			continue
+		}
+
+		strong := false
+		for _, f := range factors[u.ID].MFAFactors {
+			name := f.FactorType
+			if f.Provider != "" {
+				name += ":" + f.Provider
+			}
+			switch f.Status {
+			case okta.MFAStatusActive:
+				entry.Active = append(entry.Active, name)
+				report.Summary.FactorTypes[f.FactorType]++
+				if weak[strings.ToLower(f.FactorType)] {
+					entry.Weak = append(entry.Weak, name)
+				} else {
+					strong = true
+				}
+			case okta.MFAStatusPending:
+				entry.Pending = append(entry.Pending, name)
+			}
+		}
+
+		switch {
+		case strong:
+			entry.Coverage = CoverageStrong
+			report.Summary.Strong++
+		case len(entry.Active) > 0:
+			entry.Coverage = CoverageWeak
+			report.Summary.Weak++
+		case len(entry.Pending) > 0:
+			entry.Coverage = CoveragePending
+			report.Summary.Pending++
+		default:
+			entry.Coverage = CoverageNone
+			report.Summary.None++
+		}
+		if len(entry.Pending) > 0 {
+			report.Summary.PendingActivations++
+		}
+		report.Users = append(report.Users, entry)
+	}
+
+	// worst coverage first, that's what reviewers act on
+	sort.SliceStable(report.Users, func(i, j int) bool {
+		a, b := report.Users[i], report.Users[j]
+		if a.Coverage != b.Coverage {
+			return a.Coverage == "" || (b.Coverage != "" && coverageOrder[a.Coverage] < coverageOrder[b.Coverage])
+		}
+		return a.Login < b.Login
+	})
+	return report, nil
+}
+
+// MFAColumns are the CSV columns of an MFA report, one row per user
+var MFAColumns = []string{"userId", "login", "coverage", "active", "pending", "weak", "error"}
+
+// Rows returns the users as rows under MFAColumns
+func (r *MFAReport) Rows() [][]string {
+	rows := make([][]string, len(r.Users))
+	for i, u := range r.Users {
+		rows[i] = []string{u.UserID, u.Login, u.Coverage, strings.Join(u.Active, ";"), strings.Join(u.Pending, ";"), strings.Join(u.Weak, ";"), u.Error}
+	}
+	return rows
+}
+
+// SummaryColumns are the columns of SummaryRows
+var SummaryColumns = []string{"metric", "count"}
+
+// SummaryRows returns the summary as metric and count rows, factor types as "factorType:sms"
+func (r *MFAReport) SummaryRows() [][]string {
+	s := r.Summary
+	rows := [][]string{
+		{"users", strconv.Itoa(s.Users)},
+		{"coverage:" + CoverageNone, strconv.Itoa(s.None)},
+		{"coverage:" + CoveragePending, strconv.Itoa(s.Pending)},
+		{"coverage:" + CoverageWeak, strconv.Itoa(s.Weak)},
+		{"coverage:" + CoverageStrong, strconv.Itoa(s.Strong)},
+		{"pendingActivations", strconv.Itoa(s.PendingActivations)},
+		{"errors", strconv.Itoa(s.Errors)},
+	}
+	types := make([]string, 0, len(s.FactorTypes))
+	for t := range s.FactorTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		rows = append(rows, []string{"factorType:" + t, strconv.Itoa(s.FactorTypes[t])})
+	}
+	return rows
+}
+
+// WriteCSV writes one row per user, worst coverage first
+func (r *MFAReport) WriteCSV(w io.Writer) error {
+	return writeCSV(w, MFAColumns, r.Rows())
+}
+
+// WriteSummaryCSV writes the summary counts
+func (r *MFAReport) WriteSummaryCSV(w io.Writer) error {
+	return writeCSV(w, SummaryColumns, r.SummaryRows())
+}
+
+// WriteJSON writes the summary and the users
+func (r *MFAReport) WriteJSON(w io.Writer) error {
+	return writeJSON(w, r)
+}
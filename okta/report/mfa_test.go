@@ -0,0 +1,57 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+	"github.com/chrismalek/oktasdk-go/okta/oktatest"
+)
+
+func TestMFA(t *testing.T) {
+	server := oktatest.NewServer()
+	defer server.Close()
+
+	user := func(id string, status string) string {
+		return server.AddUser(oktatest.User{ID: id, Status: status, Profile: map[string]interface{}{"login": id[3:] + "@example.com"}})
+	}
+	anna := user("00uanna", "")
+	bob := user("00ubob", "")
+	user("00ucarol", "")
+	dave := user("00udave", "")
+	erin := user("00uerin", okta.UserStatusSuspended)
+	user("00ufrank", "")
+	server.AddFactor(anna, oktatest.Factor{FactorType: "push", Provider: "OKTA"})
+	server.AddFactor(anna, oktatest.Factor{FactorType: "sms", Provider: "OKTA"})
+	server.AddFactor(anna, oktatest.Factor{FactorType: "token:software:totp", Provider: "GOOGLE", Status: okta.MFAStatusPending})
+	server.AddFactor(bob, oktatest.Factor{FactorType: "sms", Provider: "OKTA"})
+	server.AddFactor(dave, oktatest.Factor{FactorType: "token:software:totp", Provider: "OKTA", Status: okta.MFAStatusPending})
+	server.AddFactor(erin, oktatest.Factor{FactorType: "sms", Provider: "OKTA"})
+	server.FailNext("GET", "users/00ufrank/factors", http.StatusInternalServerError, "E0000009", "Internal Server Error")
+
+	report, err := MFA(context.Background(), server.Client(), &MFAOptions{Workers: 3})
+	if err != nil {
+		t.Fatalf("MFA returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	report.WriteCSV(&buf)
+	want := "userId,login,coverage,active,pending,weak,error\n" +
+		"00ufrank,frank@example.com,,,,,E0000009: Internal Server Error\n" +
+		"00ucarol,carol@example.com,none,,,,\n" +
+		"00udave,dave@example.com,pending,,token:software:totp:OKTA,,\n" +
+		"00ubob,bob@example.com,weak,sms:OKTA,,sms:OKTA,\n" +
+		"00uanna,anna@example.com,strong,push:OKTA;sms:OKTA,token:software:totp:GOOGLE,sms:OKTA,\n"
+	if buf.String() != want {
+		t.Errorf("WriteCSV should write\n%v\nbut wrote\n%v", want, buf.String())
+	}
+
+	buf.Reset()
+	report.WriteSummaryCSV(&buf)
+	want = "metric,count\nusers,5\ncoverage:none,1\ncoverage:pending,1\ncoverage:weak,1\ncoverage:strong,1\npendingActivations,2\nerrors,1\nfactorType:push,1\nfactorType:sms,2\n"
+	if buf.String() != want {
+		t.Errorf("WriteSummaryCSV should write\n%v\nbut wrote\n%v", want, buf.String())
+	}
+}
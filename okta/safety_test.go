@@ -0,0 +1,92 @@
+package okta
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/groups/00g1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("%v should not reach the server", r.Method)
+		}
+		fmt.Fprint(w, `{"id":"00g1"}`)
+	})
+	mux.HandleFunc("/groups/00g1/users/00u1", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("%v should not reach the server", r.Method)
+	})
+
+	client.Mode = ModeReadOnly
+	if _, _, err := client.Groups.GetByID("00g1"); err != nil {
+		t.Errorf("reads should work in read-only mode but got %v", err)
+	}
+
+	_, err := client.Groups.AddUserToGroup("00g1", "00u1")
+	roErr, ok := err.(*ReadOnlyError)
+	if !ok {
+		t.Fatalf("error should be a *ReadOnlyError but got %T %v", err, err)
+	}
+	if roErr.Method != "PUT" || !strings.HasSuffix(roErr.URL, "/groups/00g1/users/00u1") {
+		t.Errorf("unexpected ReadOnlyError %+v", roErr)
+	}
+	if _, err := client.Groups.Delete("00g1"); err == nil {
+		t.Errorf("Delete should fail in read-only mode")
+	}
+}
+
+func TestDryRunMode(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("%v %v should not reach the server", r.Method, r.URL)
+	})
+
+	var buf bytes.Buffer
+	client.Logger = slog.New(slog.NewTextHandler(&buf, nil))
+	client.Mode = ModeDryRun
+
+	newUser := client.Users.NewUser()
+	newUser.Profile.Login = "isaac.brock@example.com"
+	newUser.Profile.FirstName = "Isaac"
+	newUser.SetPassword("Sup3rS3cret!")
+	user, resp, err := client.Users.Create(newUser, true)
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if !resp.IsDryRun() || resp.StatusCode != http.StatusOK {
+		t.Errorf("response should be a synthetic dry run response, got %v", resp.Status)
+	}
+	if user.Profile.FirstName != "Isaac" {
+		t.Errorf("dry run should return what would have been sent, got %+v", user.Profile)
+	}
+	if user.ID != "dryrun-1" {
+		t.Errorf("dry run create should have a synthetic ID, got %q", user.ID)
+	}
+	group, _, err := client.Groups.Add("Engineering", "")
+	if err != nil || group.ID != "dryrun-2" {
+		t.Errorf("dry run Groups.Add should have the next synthetic ID, got %+v %v", group, err)
+	}
+
+	resp, err = client.Groups.AddUserToGroup("00g1", "00u1")
+	if err != nil || !resp.IsDryRun() || resp.StatusCode != http.StatusNoContent {
+		t.Errorf("AddUserToGroup dry run should succeed with 204, got %v %v", resp, err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"method=POST", "users?activate=true", "method=PUT", "/groups/00g1/users/00u1", "Isaac"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry run log should contain %v: %v", want, out)
+		}
+	}
+	if strings.Contains(out, "Sup3rS3cret!") {
+		t.Errorf("dry run log should redact the password: %v", out)
+	}
+}
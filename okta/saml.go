@@ -0,0 +1,162 @@
+package okta
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	mediaTypeXML = "application/xml"
+
+	// SAMLBindingHTTPPost is the SAML 2.0 HTTP-POST binding URI
+	SAMLBindingHTTPPost = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	// SAMLBindingHTTPRedirect is the SAML 2.0 HTTP-Redirect binding URI
+	SAMLBindingHTTPRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+)
+
+// SAMLMetadata is the IdP metadata document OKTA publishes for a SAML 2.0 application
+// http://developer.okta.com/docs/api/resources/apps.html#preview-saml-metadata-for-application
+type SAMLMetadata struct {
+	XMLName          xml.Name             `xml:"urn:oasis:names:tc:SAML:2.0:metadata EntityDescriptor"`
+	EntityID         string               `xml:"entityID,attr"`
+	IDPSSODescriptor SAMLIDPSSODescriptor `xml:"IDPSSODescriptor"`
+
+	// Raw is the metadata document exactly as OKTA returned it
+	Raw []byte `xml:"-"`
+}
+
+// SAMLIDPSSODescriptor describes the IdP endpoints and keys in the metadata
+type SAMLIDPSSODescriptor struct {
+	WantAuthnRequestsSigned    bool                `xml:"WantAuthnRequestsSigned,attr"`
+	ProtocolSupportEnumeration string              `xml:"protocolSupportEnumeration,attr"`
+	KeyDescriptors             []SAMLKeyDescriptor `xml:"KeyDescriptor"`
+	NameIDFormats              []string            `xml:"NameIDFormat"`
+	SingleSignOnServices       []SAMLEndpoint      `xml:"SingleSignOnService"`
+	SingleLogoutServices       []SAMLEndpoint      `xml:"SingleLogoutService"`
+}
+
+// SAMLEndpoint is a binding and location for an SSO or SLO service
+type SAMLEndpoint struct {
+	Binding  string `xml:"Binding,attr"`
+	Location string `xml:"Location,attr"`
+}
+
+// SAMLKeyDescriptor is a certificate published in the metadata. Use is "signing", "encryption" or empty for both
+type SAMLKeyDescriptor struct {
+	Use         string `xml:"use,attr"`
+	Certificate string `xml:"http://www.w3.org/2000/09/xmldsig# KeyInfo>X509Data>X509Certificate"`
+}
+
+// ParseCertificate decodes the base64 certificate in the key descriptor
+func (k SAMLKeyDescriptor) ParseCertificate() (*x509.Certificate, error) {
+	cleaned := strings.Join(strings.Fields(k.Certificate), "")
+	der, err := base64.StdEncoding.DecodeString(cleaned)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+// SigningCertificates returns the parsed certificates that may be used to verify OKTA signatures
+func (m SAMLMetadata) SigningCertificates() ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, kd := range m.IDPSSODescriptor.KeyDescriptors {
+		if kd.Use != "" && kd.Use != "signing" {
+			continue
+		}
+		cert, err := kd.ParseCertificate()
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// SigningCertificatesPEM returns the signing certificates PEM encoded, ready to drop into an SP configuration
+func (m SAMLMetadata) SigningCertificatesPEM() ([]byte, error) {
+	certs, err := m.SigningCertificates()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, cert := range certs {
+		pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	return buf.Bytes(), nil
+}
+
+// SingleSignOnURL returns the SSO location for a binding (SAMLBindingHTTPPost, SAMLBindingHTTPRedirect)
+// or an empty string when OKTA does not publish one
+func (m SAMLMetadata) SingleSignOnURL(binding string) string {
+	for _, sso := range m.IDPSSODescriptor.SingleSignOnServices {
+		if sso.Binding == binding {
+			return sso.Location
+		}
+	}
+	return ""
+}
+
+// ParseSAMLMetadata parses an IdP metadata document
+func ParseSAMLMetadata(data []byte) (*SAMLMetadata, error) {
+	metadata := new(SAMLMetadata)
+	if err := xml.Unmarshal(data, metadata); err != nil {
+		return nil, err
+	}
+	metadata.Raw = data
+	return metadata, nil
+}
+
+// GetSAMLMetadata downloads and parses the SAML IdP metadata for an application.
+// Pass in a keyID to get the metadata for a key credential other than the active one (e.g. before a rotation)
+// or an empty string for the current signing key.
+func (a *AppsService) GetSAMLMetadata(appID string, keyID string) (*SAMLMetadata, *Response, error) {
+
+	u := fmt.Sprintf("apps/%v/sso/saml/metadata", appID)
+	if keyID != "" {
+		u = fmt.Sprintf("%v?kid=%v", u, keyID)
+	}
+
+	return a.getSAMLMetadata(u)
+}
+
+// GetSAMLMetadataForApp downloads and parses the current metadata of a SAML 2.0 app, which must have a _links.metadata href
+func GetSAMLMetadataForApp(apps AppsAPI, app *App) (*SAMLMetadata, *Response, error) {
+
+	if app.Links.Metadata.Href == "" {
+		return nil, nil, errors.New("app does not have a metadata link. Is it a SAML 2.0 app?")
+	}
+
+	return apps.GetSAMLMetadata(app.ID, "")
+}
+
+func (a *AppsService) getSAMLMetadata(u string) (*SAMLMetadata, *Response, error) {
+
+	req, err := a.client.NewRequest("GET", u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", mediaTypeXML)
+
+	var buf bytes.Buffer
+	resp, err := a.client.Do(req, &buf)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	metadata, err := ParseSAMLMetadata(buf.Bytes())
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return metadata, resp, err
+}
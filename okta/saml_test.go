@@ -0,0 +1,72 @@
+package okta
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var samlMetadataTestFormat = `<?xml version="1.0" encoding="UTF-8"?>
+<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="http://www.okta.com/exk1">
+  <md:IDPSSODescriptor WantAuthnRequestsSigned="false" protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="signing">
+      <ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+        <ds:X509Data>
+          <ds:X509Certificate>%v</ds:X509Certificate>
+        </ds:X509Data>
+      </ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:NameIDFormat>urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified</md:NameIDFormat>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://test-org.okta.com/app/sso/saml"/>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://test-org.okta.com/app/sso/saml/redirect"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`
+
+func TestAppGetSAMLMetadata(t *testing.T) {
+	setup()
+	defer teardown()
+
+	notAfter := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	der := testSelfSignedCert(t, notAfter)
+	encoded := base64.StdEncoding.EncodeToString(der)
+	// OKTA wraps the certificate over several lines
+	wrapped := encoded[:64] + "\n          " + encoded[64:]
+
+	mux.HandleFunc("/apps/0oa1/sso/saml/metadata", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testAuthHeader(t, r)
+		if accept := r.Header.Get("Accept"); accept != mediaTypeXML {
+			t.Errorf("Accept header %v, want %v", accept, mediaTypeXML)
+		}
+		if kid := r.URL.Query().Get("kid"); kid != "key2" {
+			t.Errorf("kid query parameter %v, want key2", kid)
+		}
+		w.Header().Set("Content-Type", mediaTypeXML)
+		fmt.Fprintf(w, samlMetadataTestFormat, wrapped)
+	})
+
+	metadata, _, err := client.Apps.GetSAMLMetadata("0oa1", "key2")
+	if err != nil {
+		t.Fatalf("Apps.GetSAMLMetadata returned error: %v", err)
+	}
+
+	if metadata.EntityID != "http://www.okta.com/exk1" {
+		t.Errorf("EntityID %v, want http://www.okta.com/exk1", metadata.EntityID)
+	}
+	if got := metadata.SingleSignOnURL(SAMLBindingHTTPRedirect); got != "https://test-org.okta.com/app/sso/saml/redirect" {
+		t.Errorf("Redirect SSO URL %v", got)
+	}
+	if len(metadata.IDPSSODescriptor.NameIDFormats) != 1 {
+		t.Errorf("NameIDFormats %v", metadata.IDPSSODescriptor.NameIDFormats)
+	}
+
+	certs, err := metadata.SigningCertificates()
+	if err != nil {
+		t.Fatalf("SigningCertificates returned error: %v", err)
+	}
+	if len(certs) != 1 || !certs[0].NotAfter.Equal(notAfter) {
+		t.Errorf("SigningCertificates returned %v certificates, want 1 expiring %v", len(certs), notAfter)
+	}
+}
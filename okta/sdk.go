@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -63,29 +64,81 @@ type Client struct {
 	authorizationHeaderValue string
 	PauseOnRateLimit         bool
 
+	// Authenticator adds credentials to each request. NewClient sets an SSWSAuthenticator for the
+	// API token, use NewClientWithAuthenticator or set it directly for OAuth 2.0
+	Authenticator Authenticator
+
 	// RateRemainingFloor - If the API returns a "X-Rate-Limit-Remaining" header less than this the SDK will either pause
 	//  Or throw  RateLimitError depending on the client.PauseOnRateLimit value. It defaults to 30
 	// One client doing too much work can lock out all API Access for every other client
 	// We are trying to be a "good API User Citizen"
 	RateRemainingFloor int
 
+	// MaxRetries is how many times a request that got a 429 "Too Many Requests" is sent again after
+	// waiting for the rate limit to reset. It defaults to 0 (no retries).
+	MaxRetries int
+	// MaxRetryWait caps how long a retry waits for the rate limit to reset. 0 means no cap.
+	MaxRetryWait time.Duration
+
+	// Logger records every API call (method, path, status, duration, X-Okta-Request-Id and rate limit
+	// headers) and rate limit pauses. Nil disables logging.
+	Logger *slog.Logger
+	// LogBodies adds the query, headers and JSON bodies to each log record. Authorization headers,
+	// passwords, recovery answers and other secrets are always redacted.
+	LogBodies bool
+	// LogRedactProfileFields are user profile attributes redacted from logged bodies. While it
+	// isn't empty the q, search and filter query parameters are redacted too.
+	// NewClient sets it to DefaultLogRedactProfileFields.
+	LogRedactProfileFields []string
+
+	// Mode makes the client read-only or dry-run. See SafetyMode. Defaults to ModeReadWrite.
+	Mode SafetyMode
+
+	// Cache answers repeated GETs without calling OKTA. Nil (the default) disables caching.
+	Cache *ResponseCache
+
+	// Interceptors run in order around every call made by Do. See Interceptor.
+	Interceptors []Interceptor
+
+	// Observer is told about every call made by Do, for tracing and metrics.
+	// See the okta/otelokta package for OpenTelemetry. Nil disables it.
+	Observer Observer
+
 	rateMu         sync.Mutex
 	mostRecentRate Rate
 
+	dryRunIDs int64 // last synthetic ID handed out in ModeDryRun, updated atomically
+
 	Limit int
 	// mostRecent rateLimitCategory
 
 	common service // Reuse a single struct instead of allocating one for each service on the heap.
 
 	// Services used for talking to different parts of the  API.
+	// They are interfaces so alternative implementations (mocks, decorators) can be plugged in.
+	// NewClient sets them to *UsersService, *GroupsService, *GroupRulesService, *AppsService and *LogsService.
+	// The interfaces only have API calls; workflows such as Reconcile or RotateSigningKey are package
+	// functions that take the interfaces or the Client. Read-only and dry-run are set with Mode, not here.
 	// Service for Working with Users
-	Users *UsersService
+	Users UsersAPI
 
 	// Service for Working with Groups
-	Groups *GroupsService
+	Groups GroupsAPI
+
+	// Service for Working with Group Rules
+	GroupRules GroupRulesAPI
 
 	// Service for Working with Apps
-	Apps *AppsService
+	Apps AppsAPI
+
+	// Service for Working with the System Log
+	Logs LogsAPI
+
+	// Service for running many calls concurrently
+	Bulk *BulkService
+
+	// Service for exporting users, groups and app assignments
+	Export *ExportService
 }
 
 type service struct {
@@ -130,13 +183,19 @@ func NewClientWithBaseURL(httpClient *http.Client, baseURL *url.URL, apiToken st
 	c.PauseOnRateLimit = true // If rate limit found it will block until that time. If false then Error will be returned
 	c.authorizationHeaderValue = fmt.Sprintf(headerAuthorizationFormat, apiToken)
 	c.apiKey = apiToken
+	c.Authenticator = &SSWSAuthenticator{Token: apiToken}
+	c.LogRedactProfileFields = append([]string(nil), DefaultLogRedactProfileFields...)
 	c.Limit = defaultLimit
 	c.RateRemainingFloor = defaultRateRemainingFloor
 	c.common.client = c
 
 	c.Users = (*UsersService)(&c.common)
 	c.Groups = (*GroupsService)(&c.common)
+	c.GroupRules = (*GroupRulesService)(&c.common)
 	c.Apps = (*AppsService)(&c.common)
+	c.Logs = (*LogsService)(&c.common)
+	c.Bulk = (*BulkService)(&c.common)
+	c.Export = (*ExportService)(&c.common)
 	return c
 }
 
@@ -239,17 +298,70 @@ func parseRate(r *http.Response) Rate {
 // first decode it.  If rate limit is exceeded and reset time is in the future,
 // Do returns rate immediately without making a network API call.
 func (c *Client) Do(req *http.Request, v interface{}) (*Response, error) {
+	stats := new(callStats)
+	handler := c.chain(func(req *http.Request, v interface{}) (*Response, error) {
+		return c.do(req, v, stats)
+	})
+	if c.Observer != nil {
+		return c.observe(req, v, handler, stats)
+	}
+	return handler(req, v)
+}
 
-	// If we've hit rate limit, don't make further requests before Reset time.
-	if err := c.checkRateLimitBeforeDo(req); err != nil {
-		return nil, err
+func (c *Client) do(req *http.Request, v interface{}, stats *callStats) (*Response, error) {
+
+	// Read-only and dry-run clients never send anything that would change OKTA
+	if response, handled, err := c.guardMutation(req, v); handled {
+		return response, err
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.cachedSend(req, stats)
 	if err != nil {
 		return nil, err
 	}
 
+	// A DPoP nonce challenge is answered once with a new proof carrying the nonce
+	if retry := c.dpopNonceRetry(req, resp); retry != nil {
+		resp.Body.Close()
+		req = retry
+		stats.retries++
+		resp, err = c.send(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A 429 is retried once the rate limit resets, up to MaxRetries times
+	for attempt := 0; resp.StatusCode == http.StatusTooManyRequests && attempt < c.MaxRetries; attempt++ {
+		retry := rewindRequest(req)
+		if retry == nil {
+			break
+		}
+		rate := parseRate(resp)
+		wait := c.retryWait(rate)
+		c.logRateLimitPause(req.Context(), rate, wait)
+		stats.retries++
+		stats.pause += wait
+		io.CopyN(ioutil.Discard, resp.Body, 512)
+		resp.Body.Close()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+		// a new DPoP proof, and a new access token if it expired while waiting
+		if c.Authenticator != nil {
+			if err := c.Authenticator.Authorize(retry); err != nil {
+				return nil, err
+			}
+		}
+		req = retry
+		resp, err = c.send(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	defer func() {
 		// Drain up to 512 bytes and close the body to let the Transport reuse the connection
 		io.CopyN(ioutil.Discard, resp.Body, 512)
@@ -258,21 +370,33 @@ func (c *Client) Do(req *http.Request, v interface{}) (*Response, error) {
 
 	response := newResponse(resp)
 
-	c.rateMu.Lock()
-	c.mostRecentRate.RatePerMinuteLimit = response.Rate.RatePerMinuteLimit
-	c.mostRecentRate.Remaining = response.Rate.Remaining
-	c.mostRecentRate.ResetTime = response.Rate.ResetTime
-	c.rateMu.Unlock()
+	// cached responses carry no rate limit headers and must not reset what we know
+	if !response.Rate.ResetTime.IsZero() {
+		c.rateMu.Lock()
+		c.mostRecentRate.RatePerMinuteLimit = response.Rate.RatePerMinuteLimit
+		c.mostRecentRate.Remaining = response.Rate.Remaining
+		c.mostRecentRate.ResetTime = response.Rate.ResetTime
+		c.rateMu.Unlock()
+	}
 
 	err = CheckResponse(resp)
 	if err != nil {
+		// a revoked or expired access token should not be reused by the next request
+		if resp.StatusCode == http.StatusUnauthorized {
+			if inv, ok := c.Authenticator.(interface{ Invalidate() }); ok {
+				inv.Invalidate()
+			}
+		}
 		// even though there was an error, we still return the response
 		// in case the caller wants to inspect it further
-		// fmt.Printf("Error after sdk.Do return\n")
 
 		return response, err
 	}
 
+	if !isSafeMethod(req.Method) {
+		c.invalidateCache(req.URL)
+	}
+
 	if v != nil {
 		if w, ok := v.(io.Writer); ok {
 			io.Copy(w, resp.Body)
@@ -287,27 +411,65 @@ func (c *Client) Do(req *http.Request, v interface{}) (*Response, error) {
 	return response, err
 }
 
+// retryWait is how long to wait before retrying a 429: until the rate limit resets, capped at MaxRetryWait
+func (c *Client) retryWait(rate Rate) time.Duration {
+	wait := rate.ResetTime.Sub(time.Now())
+	if wait <= 0 {
+		wait = time.Second
+	}
+	if c.MaxRetryWait > 0 && wait > c.MaxRetryWait {
+		wait = c.MaxRetryWait
+	}
+	return wait
+}
+
+// rewindRequest returns a copy of req that can be sent again, or nil if its body can't be replayed
+func rewindRequest(req *http.Request) *http.Request {
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil
+		}
+		retry.Body = body
+	}
+	return retry
+}
+
+// limitedSend is send behind the rate limit check, so answers from Client.Cache never wait for it
+func (c *Client) limitedSend(req *http.Request, stats *callStats) (*http.Response, error) {
+	// If we've hit rate limit, don't make further requests before Reset time.
+	if err := c.checkRateLimitBeforeDo(req, stats); err != nil {
+		return nil, err
+	}
+	return c.send(req)
+}
+
 // checkRateLimitBeforeDo does not make any network calls, but uses existing knowledge from
 // current client state in order to quickly check if *RateLimitError can be immediately returned
 // from Client.Do, and if so, returns it so that Client.Do can skip making a network API call unnecessarily.
 // Otherwise it returns nil, and Client.Do should proceed normally.
 // http://developer.okta.com/docs/api/getting_started/design_principles.html#rate-limiting
-func (c *Client) checkRateLimitBeforeDo(req *http.Request) error {
+func (c *Client) checkRateLimitBeforeDo(req *http.Request, stats *callStats) error {
 
 	c.rateMu.Lock()
 	mostRecentRate := c.mostRecentRate
 	c.rateMu.Unlock()
-	// fmt.Printf("checkRateLimitBeforeDo: \t Remaining = %d, \t ResetTime = %s\n", mostRecentRate.Remaining, mostRecentRate.ResetTime.String())
 	if !mostRecentRate.ResetTime.IsZero() && mostRecentRate.Remaining < c.RateRemainingFloor && time.Now().Before(mostRecentRate.ResetTime) {
 
 		if c.PauseOnRateLimit {
 			// If rate limit is hitting threshold then pause until the rate limit resets
 			//   This behavior is controlled by the client PauseOnRateLimit value
-			// fmt.Printf("checkRateLimitBeforeDo: \t ***pause**** \t Time Now = %s \tPause After = %s\n", time.Now().String(), mostRecentRate.ResetTime.Sub(time.Now().Add(2*time.Second)).String())
-			<-time.After(mostRecentRate.ResetTime.Sub(time.Now().Add(2 * time.Second)))
+			pause := mostRecentRate.ResetTime.Sub(time.Now().Add(2 * time.Second))
+			c.logRateLimitPause(req.Context(), mostRecentRate, pause)
+			if pause > 0 {
+				stats.pause += pause
+			}
+			<-time.After(pause)
 		} else {
-			// fmt.Printf("checkRateLimitBeforeDo: \t ***error****\n")
-
 			return &RateLimitError{
 				Rate: mostRecentRate,
 			}
@@ -482,8 +644,10 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 	if err != nil {
 		return nil, err
 	}
-	if c.apiKey != "" {
-		req.Header.Set(headerAuthorization, fmt.Sprintf(headerAuthorizationFormat, c.apiKey))
+	if c.Authenticator != nil {
+		if err := c.Authenticator.Authorize(req); err != nil {
+			return nil, err
+		}
 	}
 	if body != nil {
 		req.Header.Set("Content-Type", mediaTypeJSON)
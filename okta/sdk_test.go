@@ -163,3 +163,70 @@ func TestRateLimitRateExceededError(t *testing.T) {
 	}
 
 }
+
+func TestRetryOnTooManyRequests(t *testing.T) {
+	setup()
+	defer teardown()
+
+	calls := 0
+	mux.HandleFunc("/groups/00g1/users/00u1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		calls++
+		if calls < 3 {
+			w.Header().Add(headerRateLimit, "600")
+			w.Header().Add(headerRateRemaining, "0")
+			w.Header().Add(headerRateReset, strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"errorCode":"E0000047","errorSummary":"API call exceeded rate limit due to too many requests."}`)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	client.MaxRetries = 2
+	client.MaxRetryWait = 10 * time.Millisecond
+	if _, err := client.Groups.AddUserToGroup("00g1", "00u1"); err != nil {
+		t.Errorf("AddUserToGroup should succeed after retrying but got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("request should be sent 3 times but was sent %v times", calls)
+	}
+
+	calls = 0
+	client.MaxRetries = 1
+	_, err := client.Groups.AddUserToGroup("00g1", "00u1")
+	if _, ok := err.(*RateLimitError); !ok {
+		t.Errorf("error should be a *RateLimitError once retries run out but got %T %v", err, err)
+	}
+}
+
+// readOnlyGroups wraps a GroupsAPI and refuses membership changes
+type readOnlyGroups struct {
+	GroupsAPI
+}
+
+func (readOnlyGroups) AddUserToGroup(groupID string, userID string) (*Response, error) {
+	return nil, fmt.Errorf("read only")
+}
+
+func TestClientServicesCanBeReplaced(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/groups/00g1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id":"00g1"}`)
+	})
+	mux.HandleFunc("/groups/00g1/users/00u1", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("AddUserToGroup should not reach the server")
+	})
+
+	client.Groups = readOnlyGroups{client.Groups}
+
+	if group, _, err := client.Groups.GetByID("00g1"); err != nil || group.ID != "00g1" {
+		t.Errorf("Groups.GetByID through the wrapper returned %v, %v", group, err)
+	}
+	if _, err := client.Groups.AddUserToGroup("00g1", "00u1"); err == nil {
+		t.Errorf("Expected the wrapper to refuse AddUserToGroup")
+	}
+}
@@ -0,0 +1,248 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Change actions
+const (
+	Added    = "added"
+	Removed  = "removed"
+	Modified = "modified"
+)
+
+// Change types, in the order they are reported
+const (
+	TypeUser        = "user"
+	TypeGroup       = "group"
+	TypeGroupMember = "groupMember"
+	TypeApp         = "app"
+	TypeAppUser     = "appUser"
+	TypeAppGroup    = "appGroup"
+)
+
+var typeOrder = map[string]int{TypeUser: 0, TypeGroup: 1, TypeGroupMember: 2, TypeApp: 3, TypeAppUser: 4, TypeAppGroup: 5}
+
+// FieldChange is one field of a modified object. Old or New is nil when the field wasn't set.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// Change is an object that was added, removed or modified between two snapshots. For memberships
+// and app assignments ID is the group or app and Member the user or group.
+type Change struct {
+	Type       string        `json:"type"`
+	Action     string        `json:"action"`
+	ID         string        `json:"id"`
+	Name       string        `json:"name,omitempty"`
+	Member     string        `json:"member,omitempty"`
+	MemberName string        `json:"memberName,omitempty"`
+	Fields     []FieldChange `json:"fields,omitempty"`
+}
+
+// Report is the difference between two snapshots
+type Report struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Changes []Change  `json:"changes"`
+}
+
+// Count returns how many changes have the action
+func (r *Report) Count(action string) int {
+	n := 0
+	for _, change := range r.Changes {
+		if change.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// object is anything diffed: a name for the report and the fields compared
+type object struct {
+	name   string
+	fields map[string]interface{}
+}
+
+// Diff compares two snapshots, from the older to the newer one
+func Diff(from, to *Snapshot) *Report {
+	report := &Report{From: from.Taken, To: to.Taken, Changes: []Change{}}
+	before, after := index(from), index(to)
+
+	for _, typ := range []string{TypeUser, TypeGroup, TypeGroupMember, TypeApp, TypeAppUser, TypeAppGroup} {
+		for key, old := range before[typ] {
+			if _, ok := after[typ][key]; !ok {
+				report.Changes = append(report.Changes, newChange(typ, Removed, key, old, before, after))
+			}
+		}
+		for key, current := range after[typ] {
+			old, ok := before[typ][key]
+			if !ok {
+				report.Changes = append(report.Changes, newChange(typ, Added, key, current, after, before))
+				continue
+			}
+			if fields := diffFields(old.fields, current.fields); len(fields) > 0 {
+				change := newChange(typ, Modified, key, current, after, before)
+				change.Fields = fields
+				report.Changes = append(report.Changes, change)
+			}
+		}
+	}
+
+	sort.Slice(report.Changes, func(i, j int) bool {
+		a, b := report.Changes[i], report.Changes[j]
+		if a.Type != b.Type {
+			return typeOrder[a.Type] < typeOrder[b.Type]
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.Member < b.Member
+	})
+	return report
+}
+
+// key identifies an object of a type, with the member after a "/" for memberships and assignments
+type key struct {
+	id     string
+	member string
+}
+
+// index turns a snapshot into objects by type and key
+func index(s *Snapshot) map[string]map[key]object {
+	objects := map[string]map[key]object{}
+	for typ := range typeOrder {
+		objects[typ] = map[key]object{}
+	}
+
+	for _, user := range s.Users {
+		fields := prefixed("profile.", user.Profile)
+		fields["status"] = user.Status
+		login, _ := user.Profile["login"].(string)
+		objects[TypeUser][key{id: user.ID}] = object{name: login, fields: fields}
+	}
+	for _, group := range s.Groups {
+		fields := prefixed("profile.", group.Profile)
+		fields["type"] = group.Type
+		name, _ := group.Profile["name"].(string)
+		objects[TypeGroup][key{id: group.ID}] = object{name: name, fields: fields}
+		for _, member := range group.Members {
+			objects[TypeGroupMember][key{group.ID, member}] = object{name: name}
+		}
+	}
+	for _, app := range s.Apps {
+		objects[TypeApp][key{id: app.ID}] = object{name: app.Label, fields: map[string]interface{}{
+			"name": app.Name, "label": app.Label, "status": app.Status, "signOnMode": app.SignOnMode,
+		}}
+		for _, user := range app.Users {
+			objects[TypeAppUser][key{app.ID, user.ID}] = object{name: app.Label, fields: map[string]interface{}{
+				"scope": user.Scope, "status": user.Status, "userName": user.UserName,
+			}}
+		}
+		for _, group := range app.Groups {
+			objects[TypeAppGroup][key{app.ID, group.ID}] = object{name: app.Label, fields: map[string]interface{}{
+				"priority": float64(group.Priority),
+			}}
+		}
+	}
+	return objects
+}
+
+func prefixed(prefix string, values map[string]interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, len(values)+1)
+	for name, value := range values {
+		fields[prefix+name] = value
+	}
+	return fields
+}
+
+func newChange(typ string, action string, k key, obj object, objects, other map[string]map[key]object) Change {
+	change := Change{Type: typ, Action: action, ID: k.id, Name: obj.name, Member: k.member}
+	if k.member == "" {
+		return change
+	}
+	// name the member from either snapshot, it may only be in one of them
+	memberType := TypeUser
+	if typ == TypeAppGroup {
+		memberType = TypeGroup
+	}
+	if member, ok := objects[memberType][key{id: k.member}]; ok {
+		change.MemberName = member.name
+	} else {
+		change.MemberName = other[memberType][key{id: k.member}].name
+	}
+	return change
+}
+
+func diffFields(old, current map[string]interface{}) []FieldChange {
+	var changes []FieldChange
+	for field, value := range old {
+		if !reflect.DeepEqual(value, current[field]) {
+			changes = append(changes, FieldChange{Field: field, Old: value, New: current[field]})
+		}
+	}
+	for field, value := range current {
+		if _, ok := old[field]; !ok {
+			changes = append(changes, FieldChange{Field: field, New: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// WriteJSON writes the report for other tools
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteText writes the report for people: one line per change, "+" added, "-" removed and "~"
+// modified, with the modified fields below.
+func (r *Report) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Changes from %v to %v: %d added, %d removed, %d modified\n",
+		r.From.Format(time.RFC3339), r.To.Format(time.RFC3339), r.Count(Added), r.Count(Removed), r.Count(Modified))
+	if err != nil {
+		return err
+	}
+	marks := map[string]string{Added: "+", Removed: "-", Modified: "~"}
+	for _, change := range r.Changes {
+		line := fmt.Sprintf("%v %v %v", marks[change.Action], change.Type, change.ID)
+		if change.Name != "" {
+			line += " " + change.Name
+		}
+		if change.Member != "" {
+			line += ": " + change.Member
+			if change.MemberName != "" {
+				line += " " + change.MemberName
+			}
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		for _, field := range change.Fields {
+			if _, err := fmt.Fprintf(w, "    %v: %v -> %v\n", field.Field, textValue(field.Old), textValue(field.New)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func textValue(v interface{}) string {
+	if v == nil {
+		return "(unset)"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
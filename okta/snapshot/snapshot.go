@@ -0,0 +1,154 @@
+// Package snapshot saves the users, groups, group memberships, apps and app assignments of an
+// OKTA org to a file and diffs two snapshots, to show what changed between audits.
+//
+//	snap, err := snapshot.Take(client)
+//	snap.WriteJSON(f)
+//	...
+//	before, err := snapshot.Read(f)
+//	report := snapshot.Diff(before, snap)
+//	report.WriteText(os.Stdout)
+package snapshot
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/chrismalek/oktasdk-go/okta"
+)
+
+// Snapshot is the state of an org at one point in time
+type Snapshot struct {
+	Taken  time.Time `json:"taken"`
+	Users  []User    `json:"users"`
+	Groups []Group   `json:"groups"`
+	Apps   []App     `json:"apps"`
+}
+
+// User is a user in a snapshot. Profile has every attribute that is set, custom ones included.
+type User struct {
+	ID      string                 `json:"id"`
+	Status  string                 `json:"status"`
+	Profile map[string]interface{} `json:"profile"`
+}
+
+// Group is a group in a snapshot with the IDs of its members
+type Group struct {
+	ID      string                 `json:"id"`
+	Type    string                 `json:"type"`
+	Profile map[string]interface{} `json:"profile"`
+	Members []string               `json:"members"`
+}
+
+// App is an application in a snapshot with its assigned users and groups
+type App struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Label      string     `json:"label"`
+	Status     string     `json:"status"`
+	SignOnMode string     `json:"signOnMode"`
+	Users      []AppUser  `json:"users"`
+	Groups     []AppGroup `json:"groups"`
+}
+
+// AppUser is a user assigned to an app, directly (scope USER) or through a group (scope GROUP)
+type AppUser struct {
+	ID       string `json:"id"`
+	Scope    string `json:"scope"`
+	Status   string `json:"status"`
+	UserName string `json:"userName"`
+}
+
+// AppGroup is a group assigned to an app
+type AppGroup struct {
+	ID       string `json:"id"`
+	Priority int    `json:"priority"`
+}
+
+// Take reads the org through the client's list calls. Users are listed without a filter, so like
+// in OKTA's own list DEPROVISIONED users are left out.
+func Take(client *okta.Client) (*Snapshot, error) {
+	snap := &Snapshot{Taken: time.Now().UTC()}
+
+	users, _, err := client.Users.ListWithFilter(&okta.UserListFilterOptions{GetAllPages: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		snap.Users = append(snap.Users, User{ID: user.ID, Status: user.Status, Profile: profileMap(user.Profile)})
+	}
+
+	groups, _, err := client.Groups.ListWithFilter(&okta.GroupFilterOptions{GetAllPages: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		members, _, err := client.Groups.GetUsers(group.ID, &okta.GroupUserFilterOptions{GetAllPages: true})
+		if err != nil {
+			return nil, err
+		}
+		g := Group{ID: group.ID, Type: group.Type, Profile: profileMap(group.Profile), Members: []string{}}
+		for _, member := range members {
+			g.Members = append(g.Members, member.ID)
+		}
+		sort.Strings(g.Members)
+		snap.Groups = append(snap.Groups, g)
+	}
+
+	apps, _, err := client.Apps.ListWithFilter(&okta.AppFilterOptions{GetAllPages: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, app := range apps {
+		a := App{ID: app.ID, Name: app.Name, Label: app.Label, Status: app.Status, SignOnMode: app.SignOnMode, Users: []AppUser{}, Groups: []AppGroup{}}
+		appUsers, _, err := client.Apps.GetUsers(app.ID, &okta.AppFilterOptions{GetAllPages: true})
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range appUsers {
+			a.Users = append(a.Users, AppUser{ID: user.ID, Scope: user.Scope, Status: user.Status, UserName: user.Credentials.UserName})
+		}
+		appGroups, _, err := client.Apps.GetGroups(app.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range appGroups {
+			a.Groups = append(a.Groups, AppGroup{ID: group.ID, Priority: group.Priority})
+		}
+		snap.Apps = append(snap.Apps, a)
+	}
+	return snap, nil
+}
+
+// profileMap turns a profile struct into a map of the attributes that are set
+func profileMap(profile interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	b, err := json.Marshal(profile)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(b, &fields)
+	for name, value := range fields {
+		if value == nil || value == "" {
+			delete(fields, name)
+		}
+	}
+	return fields
+}
+
+// WriteJSON writes the snapshot so it can be read back with Read
+func (s *Snapshot) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// Read reads a snapshot written by WriteJSON
+func Read(r io.Reader) (*Snapshot, error) {
+	snap := new(Snapshot)
+	if err := json.NewDecoder(r).Decode(snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
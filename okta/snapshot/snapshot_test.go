@@ -0,0 +1,89 @@
+package snapshot
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chrismalek/oktasdk-go/okta/oktatest"
+)
+
+func TestSnapshotDiff(t *testing.T) {
+	server := oktatest.NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	anna := server.AddUser(oktatest.User{ID: "00uanna", Profile: map[string]interface{}{
+		"login": "anna@example.com", "email": "anna@example.com", "firstName": "Anna", "lastName": "Smith", "title": "Engineer", "costCentre": "CC-1",
+	}})
+	bob := server.AddUser(oktatest.User{ID: "00ubob", Profile: map[string]interface{}{
+		"login": "bob@example.com", "email": "bob@example.com", "firstName": "Bob", "lastName": "Jones",
+	}})
+	eng := server.AddGroup(oktatest.Group{ID: "00geng", Name: "Engineering"})
+	server.AddGroupMember(eng, anna)
+	app := server.AddApp(oktatest.App{ID: "0oaapp", Name: "salesforce", Label: "Salesforce"})
+	server.AssignGroupToApp(app, eng)
+	server.AssignUserToApp(app, bob)
+
+	before, err := Take(client)
+	if err != nil {
+		t.Fatalf("Take returned error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := before.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+	if before, err = Read(&buf); err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if len(before.Users) != 2 || !reflect.DeepEqual(before.Groups[0].Members, []string{anna}) || len(before.Apps[0].Users) != 2 {
+		t.Fatalf("unexpected snapshot %+v", before)
+	}
+
+	if _, _, err := client.Users.UpdateProfile(anna, map[string]interface{}{"title": "Manager", "costCentre": nil}); err != nil {
+		t.Fatalf("UpdateProfile returned error: %v", err)
+	}
+	server.AddUser(oktatest.User{ID: "00ucarol", Profile: map[string]interface{}{"login": "carol@example.com"}})
+	client.Groups.AddUserToGroup(eng, bob)
+	client.Groups.RemoveUserFromGroup(eng, anna)
+
+	after, err := Take(client)
+	if err != nil {
+		t.Fatalf("Take returned error: %v", err)
+	}
+	report := Diff(before, after)
+
+	var got []string
+	for _, change := range report.Changes {
+		got = append(got, change.Action+" "+change.Type+" "+change.ID+" "+change.Member)
+	}
+	want := []string{
+		"modified user 00uanna ",
+		"added user 00ucarol ",
+		"removed groupMember 00geng 00uanna",
+		"added groupMember 00geng 00ubob",
+		"removed appUser 0oaapp 00uanna",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("changes should be\n%v\nbut were\n%v", want, got)
+	}
+	fields := report.Changes[0].Fields
+	wantFields := []FieldChange{{Field: "profile.costCentre", Old: "CC-1"}, {Field: "profile.title", Old: "Engineer", New: "Manager"}}
+	if !reflect.DeepEqual(fields, wantFields) {
+		t.Errorf("fields should be %+v but were %+v", wantFields, fields)
+	}
+
+	buf.Reset()
+	report.WriteText(&buf)
+	for _, line := range []string{
+		"2 added, 2 removed, 1 modified",
+		"~ user 00uanna anna@example.com\n    profile.costCentre: \"CC-1\" -> (unset)\n    profile.title: \"Engineer\" -> \"Manager\"\n",
+		"- groupMember 00geng Engineering: 00uanna anna@example.com",
+		"+ user 00ucarol carol@example.com",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("text report should contain %q:\n%v", line, buf.String())
+		}
+	}
+}
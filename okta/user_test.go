@@ -1,6 +1,7 @@
 package okta
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -151,6 +152,36 @@ func TestUserGet(t *testing.T) {
 	}
 }
 
+func TestUserProfileMarshalCustom(t *testing.T) {
+	for _, test := range []struct {
+		profile userProfile
+		want    string
+	}{
+		{
+			userProfile{Login: "anna@example.com", Custom: map[string]interface{}{"favoriteColor": "green"}},
+			`{"email":"","favoriteColor":"green","firstName":"","lastName":"","login":"anna@example.com"}`,
+		},
+		{
+			// a custom attribute named like an empty field is written under the field's name
+			userProfile{Login: "anna@example.com", Custom: map[string]interface{}{"Email": "anna@example.com", "NICKNAME": "Anna"}},
+			`{"email":"anna@example.com","firstName":"","lastName":"","login":"anna@example.com","nickname":"Anna"}`,
+		},
+		{
+			// a field that is set wins
+			userProfile{Login: "anna@example.com", Email: "anna@example.com", Custom: map[string]interface{}{"email": "other@example.com"}},
+			`{"email":"anna@example.com","firstName":"","lastName":"","login":"anna@example.com"}`,
+		},
+	} {
+		b, err := json.Marshal(test.profile)
+		if err != nil {
+			t.Fatalf("json.Marshal returned error: %v", err)
+		}
+		if string(b) != test.want {
+			t.Errorf("profile should marshal to\n%v\nbut was\n%v", test.want, string(b))
+		}
+	}
+}
+
 //  Test User Search Query Parameter Generation
 // Test Pagination
 //
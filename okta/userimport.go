@@ -0,0 +1,379 @@
+package okta
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Targets for UserImportOptions.Columns besides profile attributes
+const (
+	// ImportPassword sets the password of created users
+	ImportPassword = "credentials.password"
+	// ImportRecoveryQuestion and ImportRecoveryAnswer set the recovery question of created users
+	ImportRecoveryQuestion = "credentials.recoveryQuestion"
+	ImportRecoveryAnswer   = "credentials.recoveryAnswer"
+	// ImportGroups are group IDs or names, separated by ";" in CSV
+	ImportGroups = "groups"
+	// ImportIgnore drops a column
+	ImportIgnore = "-"
+)
+
+// Import actions recorded in BulkResult.Action
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	// ImportExisting is a user that already existed and was only added to the row's groups
+	ImportExisting = "existing"
+)
+
+// DefaultImportRequired are the profile attributes OKTA requires
+var DefaultImportRequired = []string{"login", "email", "firstName", "lastName"}
+
+// UserImportRow is one user read from the input
+type UserImportRow struct {
+	// Line in the input, for error messages
+	Line int
+	// Profile has standard and custom attributes
+	Profile          map[string]interface{}
+	Password         string
+	RecoveryQuestion string
+	RecoveryAnswer   string
+	Groups           []string
+}
+
+// Login is the row's login
+func (r *UserImportRow) Login() string {
+	login, _ := r.Profile["login"].(string)
+	return login
+}
+
+// UserImportOptions control Bulk.ImportUsers and the readers
+type UserImportOptions struct {
+	// Columns maps input columns to profile attributes (standard or custom) or to one of the Import*
+	// targets. Columns that aren't in the map are used as profile attribute names, except "password",
+	// "recoveryQuestion", "recoveryAnswer" and "groups".
+	Columns map[string]string
+	// Required are the profile attributes every row must have. Defaults to DefaultImportRequired.
+	Required []string
+	// Update existing users (matched by login) with the row's profile. Otherwise their profile is
+	// left alone and they are only added to the row's groups, which also lets a run that failed on
+	// the groups be repeated. Passwords and recovery questions are only set on created users.
+	Update bool
+	// Activate created users
+	Activate bool
+	// Bulk configures the bulk run
+	Bulk *BulkOptions
+}
+
+var defaultImportColumns = map[string]string{
+	"password":         ImportPassword,
+	"recoveryQuestion": ImportRecoveryQuestion,
+	"recoveryAnswer":   ImportRecoveryAnswer,
+	"groups":           ImportGroups,
+}
+
+func (opt *UserImportOptions) target(column string) string {
+	if opt != nil {
+		if target, ok := opt.Columns[column]; ok {
+			return target
+		}
+	}
+	if target, ok := defaultImportColumns[column]; ok {
+		return target
+	}
+	return column
+}
+
+func (opt *UserImportOptions) set(row *UserImportRow, column string, value interface{}) error {
+	target := opt.target(column)
+	if s, ok := value.(string); ok {
+		value = strings.TrimSpace(s)
+		if value == "" {
+			return nil
+		}
+	}
+	if value == nil || target == ImportIgnore || target == "" {
+		return nil
+	}
+
+	switch target {
+	case ImportPassword, ImportRecoveryQuestion, ImportRecoveryAnswer:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("line %d: %v must be a string", row.Line, column)
+		}
+		switch target {
+		case ImportPassword:
+			row.Password = s
+		case ImportRecoveryQuestion:
+			row.RecoveryQuestion = s
+		default:
+			row.RecoveryAnswer = s
+		}
+	case ImportGroups:
+		switch v := value.(type) {
+		case string:
+			for _, group := range strings.Split(v, ";") {
+				if group = strings.TrimSpace(group); group != "" {
+					row.Groups = append(row.Groups, group)
+				}
+			}
+		case []interface{}:
+			for _, group := range v {
+				s, ok := group.(string)
+				if !ok {
+					return fmt.Errorf("line %d: %v must be a list of strings", row.Line, column)
+				}
+				row.Groups = append(row.Groups, s)
+			}
+		default:
+			return fmt.Errorf("line %d: %v must be a string or a list of strings", row.Line, column)
+		}
+	default:
+		row.Profile[target] = value
+	}
+	return nil
+}
+
+// ReadUserImportCSV reads users from CSV with a header row
+func ReadUserImportCSV(r io.Reader, opt *UserImportOptions) ([]UserImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %v", err)
+	}
+
+	var rows []UserImportRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		row := UserImportRow{Line: line, Profile: map[string]interface{}{}}
+		for i, value := range record {
+			if err := opt.set(&row, strings.TrimSpace(header[i]), value); err != nil {
+				return rows, err
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// ReadUserImportJSON reads users from JSON lines, one object per line. Values that aren't
+// strings (numbers, booleans, lists) are kept for custom attributes.
+func ReadUserImportJSON(r io.Reader, opt *UserImportOptions) ([]UserImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []UserImportRow
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var object map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &object); err != nil {
+			return rows, fmt.Errorf("line %d: %v", line, err)
+		}
+		row := UserImportRow{Line: line, Profile: map[string]interface{}{}}
+		for column, value := range object {
+			if err := opt.set(&row, column, value); err != nil {
+				return rows, err
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
+
+// ValidateUserImport checks every row before anything is sent. The result has the problems of each
+// failed row by index, nil when all rows are valid.
+func ValidateUserImport(rows []UserImportRow, opt *UserImportOptions) map[int][]string {
+	required := DefaultImportRequired
+	if opt != nil && opt.Required != nil {
+		required = opt.Required
+	}
+
+	problems := map[int][]string{}
+	logins := map[string]int{}
+	for i, row := range rows {
+		for _, attr := range required {
+			if row.Profile[attr] == nil {
+				problems[i] = append(problems[i], fmt.Sprintf("%v is required", attr))
+			}
+		}
+		for _, attr := range []string{"login", "email"} {
+			if s, ok := row.Profile[attr].(string); ok && !strings.Contains(s, "@") {
+				problems[i] = append(problems[i], fmt.Sprintf("%v %q is not an email address", attr, s))
+			}
+		}
+		if (row.RecoveryQuestion == "") != (row.RecoveryAnswer == "") {
+			problems[i] = append(problems[i], "recovery question and answer must be given together")
+		}
+		if login := strings.ToLower(row.Login()); login != "" {
+			if first, ok := logins[login]; ok {
+				problems[i] = append(problems[i], fmt.Sprintf("login is repeated from line %d", rows[first].Line))
+			} else {
+				logins[login] = i
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
+
+// ImportUsers creates, or with opt.Update updates, a user for every row and adds it to the row's
+// groups. Results are keyed by login, have the user ID and whether it was created, updated or
+// already existed.
+// Invalid rows fail without calling OKTA.
+func (s *BulkService) ImportUsers(ctx context.Context, rows []UserImportRow, opt *UserImportOptions) *BulkReport {
+	if opt == nil {
+		opt = new(UserImportOptions)
+	}
+	problems := ValidateUserImport(rows, opt)
+	groups := &groupResolver{groups: s.client.Groups, lookups: map[string]*groupLookup{}}
+
+	// results are keyed by login; repeated logins (which fail validation) get their line added
+	keys := make([]string, len(rows))
+	index := make(map[string]int, len(rows))
+	for i, row := range rows {
+		keys[i] = row.Login()
+		if _, repeated := index[keys[i]]; repeated || keys[i] == "" {
+			keys[i] = strings.TrimSpace(fmt.Sprintf("%v line %d", keys[i], row.Line))
+		}
+		index[keys[i]] = i
+	}
+
+	var mu sync.Mutex
+	actions := map[string]string{}
+	report := s.Run(ctx, "ImportUsers", keys, func(ctx context.Context, key string) (string, *Response, error) {
+		i := index[key]
+		if len(problems[i]) > 0 {
+			return "", nil, fmt.Errorf("line %d: %v", rows[i].Line, strings.Join(problems[i], ", "))
+		}
+		id, action, resp, err := s.importUser(rows[i], opt, groups)
+		mu.Lock()
+		actions[key] = action
+		mu.Unlock()
+		return id, resp, err
+	}, opt.Bulk)
+
+	for i := range report.Results {
+		report.Results[i].Action = actions[report.Results[i].Key]
+	}
+	return report
+}
+
+func (s *BulkService) importUser(row UserImportRow, opt *UserImportOptions, groups *groupResolver) (id string, action string, resp *Response, err error) {
+	existing, resp, err := s.client.Users.GetByID(row.Login())
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return "", "", resp, err
+	}
+
+	switch {
+	case existing != nil && !opt.Update:
+		id, action = existing.ID, ImportExisting
+	case existing != nil:
+		if _, resp, err = s.client.Users.UpdateProfile(existing.ID, row.Profile); err != nil {
+			return existing.ID, "", resp, err
+		}
+		id, action = existing.ID, ImportUpdated
+	default:
+		user := s.client.Users.NewUser()
+		b, err := json.Marshal(row.Profile)
+		if err != nil {
+			return "", "", nil, err
+		}
+		if err := json.Unmarshal(b, &user.Profile); err != nil {
+			return "", "", nil, err
+		}
+		user.SetPassword(row.Password)
+		user.SetRecoveryQuestion(row.RecoveryQuestion, row.RecoveryAnswer)
+		created, createResp, err := s.client.Users.Create(user, opt.Activate)
+		if err != nil {
+			return "", "", createResp, err
+		}
+		id, action, resp = created.ID, ImportCreated, createResp
+	}
+
+	for _, group := range row.Groups {
+		groupID, err := groups.id(group)
+		if err != nil {
+			return id, action, nil, err
+		}
+		if groupResp, err := s.client.Groups.AddUserToGroup(groupID, id); err != nil {
+			return id, action, groupResp, fmt.Errorf("user %v was %v but not added to group %v: %v", row.Login(), action, group, err)
+		}
+	}
+	return id, action, resp, nil
+}
+
+// groupResolver turns group names into IDs, once per name. Workers asking for a name that is
+// being looked up wait for that lookup instead of listing the groups again, and names that
+// aren't found are remembered too. Lookups that fail with an error are tried again.
+type groupResolver struct {
+	groups  GroupsAPI
+	mu      sync.Mutex
+	lookups map[string]*groupLookup
+}
+
+// groupLookup is one name's lookup, id and err are set before done is closed
+type groupLookup struct {
+	done chan struct{}
+	id   string
+	err  error
+}
+
+func (r *groupResolver) id(group string) (string, error) {
+	if len(group) == 20 && strings.HasPrefix(group, "00g") && !strings.ContainsAny(group, " ") {
+		return group, nil
+	}
+	r.mu.Lock()
+	if lookup, ok := r.lookups[group]; ok {
+		r.mu.Unlock()
+		<-lookup.done
+		return lookup.id, lookup.err
+	}
+	lookup := &groupLookup{done: make(chan struct{})}
+	r.lookups[group] = lookup
+	r.mu.Unlock()
+
+	var found bool
+	lookup.id, found, lookup.err = r.find(group)
+	if lookup.err == nil && !found {
+		lookup.err = fmt.Errorf("group %q not found", group)
+	} else if lookup.err != nil {
+		r.mu.Lock()
+		delete(r.lookups, group)
+		r.mu.Unlock()
+	}
+	close(lookup.done)
+	return lookup.id, lookup.err
+}
+
+// find lists the groups whose name starts with group and picks the one with exactly that name
+func (r *groupResolver) find(group string) (id string, found bool, err error) {
+	groups, _, err := r.groups.ListWithFilter(&GroupFilterOptions{NameStartsWith: group, GetAllPages: true})
+	if err != nil {
+		return "", false, err
+	}
+	for _, g := range groups {
+		if g.Profile.Name == group {
+			return g.ID, true, nil
+		}
+	}
+	return "", false, nil
+}
@@ -0,0 +1,226 @@
+package okta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testImportCSV = `login,email,firstName,lastName,Cost Centre,password,groups
+anna@example.com,anna@example.com,Anna,Smith,CC-1,Sup3rS3cret!,Engineering;00g1aaaaaaaaaaaaaaaa
+bob@example.com,bob@example.com,Bob,Jones,CC-2,,
+carol,carol@example.com,Carol,,CC-3,,
+anna@example.com,anna@example.com,Anna,Again,,,
+`
+
+func TestReadUserImport(t *testing.T) {
+	opt := &UserImportOptions{Columns: map[string]string{"Cost Centre": "costCentre"}}
+	rows, err := ReadUserImportCSV(strings.NewReader(testImportCSV), opt)
+	if err != nil {
+		t.Fatalf("ReadUserImportCSV returned error: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("should read 4 rows, got %v", len(rows))
+	}
+	want := UserImportRow{
+		Line:     2,
+		Profile:  map[string]interface{}{"login": "anna@example.com", "email": "anna@example.com", "firstName": "Anna", "lastName": "Smith", "costCentre": "CC-1"},
+		Password: "Sup3rS3cret!",
+		Groups:   []string{"Engineering", "00g1aaaaaaaaaaaaaaaa"},
+	}
+	if !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("first row should be %+v, got %+v", want, rows[0])
+	}
+
+	problems := ValidateUserImport(rows, opt)
+	if len(problems) != 2 || len(problems[2]) != 2 || !strings.Contains(problems[3][0], "repeated from line 2") {
+		t.Errorf("unexpected problems %v", problems)
+	}
+
+	rows, err = ReadUserImportJSON(strings.NewReader(`{"login":"dan@example.com","badges":3,"groups":["Sales"]}`+"\n\n"), nil)
+	if err != nil || len(rows) != 1 || rows[0].Profile["badges"] != float64(3) || rows[0].Groups[0] != "Sales" {
+		t.Errorf("unexpected JSON rows %+v %v", rows, err)
+	}
+}
+
+func TestBulkImportUsers(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var mu sync.Mutex
+	var calls []string
+	record := func(call string) {
+		mu.Lock()
+		calls = append(calls, call)
+		mu.Unlock()
+	}
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/users/")
+		switch {
+		case r.Method == "GET" && id == "bob@example.com":
+			fmt.Fprint(w, `{"id":"00ubob","profile":{"login":"bob@example.com"}}`)
+		case r.Method == "GET":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errorCode":"E0000007","errorSummary":"Not found: Resource not found: `+id+` (User)"}`)
+		default:
+			var body map[string]map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			record(fmt.Sprintf("update %v %v", id, body["profile"]["costCentre"]))
+			fmt.Fprintf(w, `{"id":%q}`, id)
+		}
+	})
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		if r.URL.Query().Get("activate") != "true" {
+			t.Errorf("users should be activated, got %v", r.URL.RawQuery)
+		}
+		var user NewUser
+		json.NewDecoder(r.Body).Decode(&user)
+		record(fmt.Sprintf("create %v %v %v", user.Profile.Login, user.Profile.Custom["costCentre"], user.Credentials.Password.Value))
+		fmt.Fprint(w, `{"id":"00uanna"}`)
+	})
+	mux.HandleFunc("/groups", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != "Engineering" {
+			t.Errorf("group should be searched by name, got %v", q)
+		}
+		fmt.Fprint(w, `[{"id":"00g2","profile":{"name":"Engineering Managers"}},{"id":"00geng","profile":{"name":"Engineering"}}]`)
+	})
+	mux.HandleFunc("/groups/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		record("add " + strings.TrimPrefix(r.URL.Path, "/groups/"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	opt := &UserImportOptions{Columns: map[string]string{"Cost Centre": "costCentre"}, Update: true, Activate: true, Bulk: &BulkOptions{Workers: 1}}
+	rows, _ := ReadUserImportCSV(strings.NewReader(testImportCSV), opt)
+	report := client.Bulk.ImportUsers(context.Background(), rows, opt)
+
+	if report.Succeeded != 2 || report.Failed != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	anna, bob, carol, again := report.Results[0], report.Results[1], report.Results[2], report.Results[3]
+	if anna.Action != ImportCreated || anna.ID != "00uanna" || bob.Action != ImportUpdated || bob.ID != "00ubob" {
+		t.Errorf("unexpected results %+v %+v", anna, bob)
+	}
+	if carol.Key != "carol" || !strings.Contains(carol.Error, "line 4: lastName is required") {
+		t.Errorf("invalid row should fail, got %+v", carol)
+	}
+	if again.Key != "anna@example.com line 5" || again.Action != "" {
+		t.Errorf("repeated login should fail, got %+v", again)
+	}
+
+	want := []string{
+		"create anna@example.com CC-1 Sup3rS3cret!",
+		"add 00geng/users/00uanna",
+		"add 00g1aaaaaaaaaaaaaaaa/users/00uanna",
+		"update 00ubob CC-2",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls should be %v but were %v", want, calls)
+	}
+}
+
+func TestBulkImportUsersResumeAfterGroupFailure(t *testing.T) {
+	setup()
+	defer teardown()
+
+	created := false
+	groupDown := true
+	var profileWrites int
+	mux.HandleFunc("/users/dan@example.com", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method != "GET":
+			profileWrites++
+			fmt.Fprint(w, `{"id":"00udan"}`)
+		case created:
+			fmt.Fprint(w, `{"id":"00udan","profile":{"login":"dan@example.com"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errorCode":"E0000007","errorSummary":"Not found: Resource not found: dan@example.com (User)"}`)
+		}
+	})
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		if created {
+			t.Errorf("dan should only be created once")
+		}
+		created = true
+		fmt.Fprint(w, `{"id":"00udan"}`)
+	})
+	mux.HandleFunc("/groups/00g1aaaaaaaaaaaaaaaa/users/00udan", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		if groupDown {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"errorCode":"E0000001","errorSummary":"Api validation failed"}`)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rows := []UserImportRow{{Line: 2, Profile: map[string]interface{}{"login": "dan@example.com", "email": "dan@example.com", "firstName": "Dan", "lastName": "Brown"}, Groups: []string{"00g1aaaaaaaaaaaaaaaa"}}}
+	opt := &UserImportOptions{Bulk: &BulkOptions{Workers: 1}}
+	first := client.Bulk.ImportUsers(context.Background(), rows, opt)
+	if first.Failed != 1 || first.Results[0].Action != ImportCreated || !strings.Contains(first.Results[0].Error, "not added to group") {
+		t.Fatalf("the group add should fail after the create, got %+v", first.Results)
+	}
+
+	groupDown = false
+	opt.Bulk.Resume = first
+	resumed := client.Bulk.ImportUsers(context.Background(), rows, opt)
+	if resumed.Succeeded != 1 || resumed.Results[0].Action != ImportExisting || resumed.Results[0].ID != "00udan" {
+		t.Errorf("resuming should add the existing user to the group, got %+v", resumed.Results)
+	}
+	if profileWrites != 0 {
+		t.Errorf("without Update the existing profile shouldn't be written, got %v writes", profileWrites)
+	}
+}
+
+func TestGroupResolverLooksUpEachNameOnce(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var mu sync.Mutex
+	lookups := map[string]int{}
+	mux.HandleFunc("/groups", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		name := r.URL.Query().Get("q")
+		mu.Lock()
+		lookups[name]++
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		if name == "Engineering" {
+			fmt.Fprint(w, `[{"id":"00geng","profile":{"name":"Engineering"}}]`)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	})
+
+	groups := &groupResolver{groups: client.Groups, lookups: map[string]*groupLookup{}}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if id, err := groups.id("Engineering"); err != nil || id != "00geng" {
+				t.Errorf("Engineering should resolve to 00geng, got %v %v", id, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := groups.id("Missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+				t.Errorf("Missing should not be found, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if want := map[string]int{"Engineering": 1, "Missing": 1}; !reflect.DeepEqual(lookups, want) {
+		t.Errorf("each name should be listed once, got %v", lookups)
+	}
+}
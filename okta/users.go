@@ -1,9 +1,12 @@
 package okta
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
+	"reflect"
+	"strings"
 	"time"
 )
 
@@ -39,6 +42,27 @@ const (
 // methods of the OKTA API.
 type UsersService service
 
+// UsersAPI is the method set of UsersService.
+type UsersAPI interface {
+	NewUser() NewUser
+	GetByID(id string) (*User, *Response, error)
+	ListWithFilter(opt *UserListFilterOptions) ([]User, *Response, error)
+	PopulateGroups(user *User) (*Response, error)
+	PopulateEnrolledFactors(user *User) (*Response, error)
+	PopulateMFAFactors(user *User) (*Response, error)
+	Create(userIn NewUser, createAsActive bool) (*User, *Response, error)
+	Activate(id string, sendEmail bool) (*ActivationResponse, *Response, error)
+	Deactivate(id string) (*Response, error)
+	Suspend(id string) (*Response, error)
+	Unsuspend(id string) (*Response, error)
+	Unlock(id string) (*Response, error)
+	SetPassword(id string, newPassword string) (*User, *Response, error)
+	ResetPassword(id string, sendEmail bool) (*ResetPasswordResponse, *Response, error)
+	UpdateProfile(id string, profile map[string]interface{}) (*User, *Response, error)
+}
+
+var _ UsersAPI = (*UsersService)(nil)
+
 // ActivationResponse - Response coming back from a user activation
 type ActivationResponse struct {
 	ActivationURL string `json:"activationUrl"`
@@ -89,6 +113,69 @@ type userProfile struct {
 	State             string `json:"state,omitempty"`
 	ZipCode           string `json:"zipCode,omitempty"`
 	CountryCode       string `json:"countryCode,omitempty"`
+
+	// Custom has the attributes added to the profile in the OKTA Universal Directory
+	Custom map[string]interface{} `json:"-"`
+}
+
+type profileFields userProfile
+
+// profileKeys maps the lower case JSON names of the userProfile fields to their JSON names.
+// encoding/json matches them case insensitively, so "nickName" is NickName.
+var profileKeys = func() map[string]string {
+	keys := map[string]string{}
+	t := reflect.TypeOf(userProfile{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			keys[strings.ToLower(name)] = name
+		}
+	}
+	return keys
+}()
+
+// MarshalJSON adds the custom attributes to the profile
+func (p userProfile) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(profileFields(p))
+	if err != nil || len(p.Custom) == 0 {
+		return b, err
+	}
+	profile := map[string]interface{}{}
+	if err := json.Unmarshal(b, &profile); err != nil {
+		return nil, err
+	}
+	// a custom attribute with a field's name, in any case, fills that field when it is empty
+	for name, value := range p.Custom {
+		if field, ok := profileKeys[strings.ToLower(name)]; ok {
+			if v, set := profile[field]; set && v != "" {
+				continue
+			}
+			name = field
+		}
+		profile[name] = value
+	}
+	return json.Marshal(profile)
+}
+
+// UnmarshalJSON puts the attributes without a field in Custom
+func (p *userProfile) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, (*profileFields)(p)); err != nil {
+		return err
+	}
+	var profile map[string]interface{}
+	if err := json.Unmarshal(b, &profile); err != nil {
+		return err
+	}
+	p.Custom = nil
+	for name, value := range profile {
+		if _, ok := profileKeys[strings.ToLower(name)]; ok {
+			continue
+		}
+		if p.Custom == nil {
+			p.Custom = map[string]interface{}{}
+		}
+		p.Custom[name] = value
+	}
+	return nil
 }
 
 type userLinks struct {
@@ -252,6 +339,11 @@ type UserListFilterOptions struct {
 	// FirstNameStartsWith    string    `url:"-"`
 	// LastNameStartsWith     string    `url:"-"`
 
+	// Search is an OKTA search expression, which can use any profile attribute and operators like sw,
+	// e.g. profile.department eq "Engineering". Q matches the start of first name, last name or email.
+	Search string `url:"search,omitempty"`
+	Q      string `url:"q,omitempty"`
+
 	// This will be built by internal - may not need to export
 	FilterString  string     `url:"filter,omitempty"`
 	NextURL       *url.URL   `url:"-"`
@@ -583,6 +675,24 @@ func (s *UsersService) SetPassword(id string, newPassword string) (*User, *Respo
 	return user, resp, err
 }
 
+// UpdateProfile - Sets the given profile attributes, standard or custom, on a user. Attributes that
+// aren't given are left as they are. A nil value clears an attribute.
+func (s *UsersService) UpdateProfile(id string, profile map[string]interface{}) (*User, *Response, error) {
+	u := fmt.Sprintf("users/%v", id)
+	req, err := s.client.NewRequest("POST", u, map[string]interface{}{"profile": profile})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user := new(User)
+	resp, err := s.client.Do(req, user)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return user, resp, err
+}
+
 // ResetPassword - Generates a one-time token (OTT) that can be used to reset a user’s password.
 // The OTT link can be automatically emailed to the user or returned to the API caller and distributed using a custom flow.
 // http://developer.okta.com/docs/api/resources/users.html#reset-password